@@ -12,13 +12,18 @@ import (
 	goruntime "runtime"
 	"skillui/internal/platform"
 	"strings"
+	"sync"
 	"time"
 
+	"skillui/internal/api"
 	"skillui/internal/config"
 	"skillui/internal/logging"
+	"skillui/internal/manifest"
+	"skillui/internal/mcp"
 	"skillui/internal/process"
 	"skillui/internal/service"
 	"skillui/internal/store"
+	"skillui/internal/watcher"
 
 	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -42,6 +47,30 @@ type App struct {
 	autoStartMgr *service.AutoStartManager
 	systemLogger *logging.RollingStore
 	dataDir      string
+	// manifest 记录同步到工具目录的文件，首次使用时加载（见 syncManifest）
+	manifest     *manifest.Manifest
+	manifestOnce sync.Once
+	// skillWatcher 监听技能目录变化（见 startSkillWatcher）
+	skillWatcher *watcher.Watcher
+	watchMu      sync.Mutex
+	// watchIndexOnly 为 true 时监听只更新技能索引，不刷新同步副本（skillui mcp，交给窗口实例处理）
+	watchIndexOnly bool
+	// skillIndex 为技能目录的内存索引（按目录名），仅在监听运行时使用
+	skillIndex    map[string]SkillMeta
+	skillIndexDir string
+	indexMu       sync.Mutex
+	// apiServer 为本机 HTTP API（见 app_api.go），未启用时为 nil
+	apiServer *http.Server
+	apiErr    string
+	apiMu     sync.Mutex
+	// events 向 API 事件流（SSE）广播进程日志与技能变化
+	events *api.Broker
+	// mcp 为技能 MCP 服务（见 app_mcp.go），首次使用时创建
+	mcp     *mcp.Server
+	mcpOnce sync.Once
+	// mcpManifest 记录写入工具 MCP 配置的服务条目（见 app_mcp_servers.go），首次使用时加载
+	mcpManifest     *manifest.Manifest
+	mcpManifestOnce sync.Once
 }
 
 // 数据根目录解析：SKILLUI_DATA_ROOT 环境变量 > ~/.skillui/client.json 的 dataPath > 默认 ~/.skillui/data。
@@ -160,6 +189,7 @@ func NewApp() *App {
 		loggers:      make(map[string]*ProcessLogger),
 		autoStartMgr: service.NewAutoStartManager(AppName, AppDisplayName),
 		dataDir:      dataDir,
+		events:       api.NewBroker(),
 	}
 }
 
@@ -167,7 +197,35 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.load()
 
+	// Watch the skill directory so synced copies follow edits and upgrades
+	a.startSkillWatcher()
+
+	// Auto-start processes if configured (unless another SkillUI instance already runs them)
+	for _, def := range a.config.Processes {
+		if def.AutoStart && process.RunningPID(a.processRunDir(), def.ID) == 0 {
+			go a.pm.Start(ctx, def.ID)
+		}
+	}
+
+	// Local HTTP API (opt-in)
+	if a.config.API.Enabled {
+		if err := a.ensureAPIToken(); err != nil {
+			a.LogSystemError("APIServer", err.Error())
+		} else if err := a.startAPIServer(); err != nil {
+			a.LogSystemError("APIServer", err.Error())
+		}
+	}
+
+	// Log successful startup
+	a.LogSystemError("startup", fmt.Sprintf("Application started successfully, version: %s, platform: %s", appConfig.Version, a.autoStartMgr.GetPlatform()))
+}
+
+// load reads the configuration and prepares skills, tools and processes. It
+// is shared by the window (startup) and the command line (see cli.go) and
+// does not need a Wails context.
+func (a *App) load() {
 	// Load configuration
 	cfg, err := a.store.Load()
 	if err != nil {
@@ -207,24 +265,24 @@ func (a *App) startup(ctx context.Context) {
 		}
 	}
 
+	// Load tool definitions (bundled registry + marketplace / user overrides)
+	a.loadToolRegistry()
+
+	// Clean up skill installs interrupted by a crash or forced quit, and record
+	// synced links created before the sync manifest existed
+	a.recoverSkillInstalls()
+	a.adoptLegacySyncLinks()
+
+	// PID files let the window and the command line see each other's processes
+	a.pm.SetPIDDir(a.processRunDir())
+
 	// Set up log callback for process manager
 	a.pm.SetLogCallback(func(processID, stream, line string) {
-		logger, ok := a.loggers[processID]
-		if !ok {
-			return
-		}
-
-		entry := logging.Entry{
+		a.recordProcessLog(processID, logging.Entry{
 			Timestamp: time.Now(),
 			Stream:    stream,
 			Line:      line,
-		}
-
-		// Store in memory hub
-		logger.hub.Push(entry)
-
-		// Store in rolling file
-		logger.store.Append(entry)
+		})
 	})
 
 	// Register saved processes
@@ -232,20 +290,60 @@ func (a *App) startup(ctx context.Context) {
 		a.pm.Register(def)
 
 		// Create logger for this process
-		processLogDir := filepath.Join(logDir, def.ID)
 		a.loggers[def.ID] = &ProcessLogger{
-			store: logging.NewRollingStore(processLogDir, a.config.MaxLogLines, a.config.MaxLogFiles),
+			store: logging.NewRollingStore(a.processLogDir(def.ID), a.config.MaxLogLines, a.config.MaxLogFiles),
 			hub:   logging.NewStreamHub(100),
 		}
+	}
+}
 
-		// Auto-start processes if configured
-		if def.AutoStart {
-			go a.pm.Start(ctx, def.ID)
-		}
+// recordProcessLog stores one output line of a process
+func (a *App) recordProcessLog(processID string, entry logging.Entry) {
+	logger, ok := a.loggers[processID]
+	if !ok {
+		return
 	}
 
-	// Log successful startup
-	a.LogSystemError("startup", fmt.Sprintf("Application started successfully, version: %s, platform: %s", appConfig.Version, a.autoStartMgr.GetPlatform()))
+	// Store in memory hub
+	logger.hub.Push(entry)
+
+	// Store in rolling file
+	logger.store.Append(entry)
+
+	a.publishEvent(EventProcessLog, ProcessLogEvent{Process: processID, Entry: entry})
+}
+
+// processLogDir is the rolling log directory of a process
+func (a *App) processLogDir(id string) string {
+	return filepath.Join(a.dataDir, a.config.LogDir, id)
+}
+
+// processRunDir is the directory of the process PID files
+func (a *App) processRunDir() string {
+	return filepath.Join(a.dataDir, "run")
+}
+
+// processContext is the context processes are started with: the Wails
+// context, or a background context on the command line
+func (a *App) processContext() context.Context {
+	if a.ctx != nil {
+		return a.ctx
+	}
+	return context.Background()
+}
+
+// emitEvent sends an event to the frontend and the API event stream; the
+// frontend part is a no-op before startup
+func (a *App) emitEvent(name string, data ...interface{}) {
+	var payload interface{}
+	if len(data) > 0 {
+		payload = data[0]
+	}
+	a.publishEvent(name, payload)
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, name, data...)
 }
 
 // Greet returns a greeting for the given name
@@ -264,6 +362,9 @@ func (a *App) AddProcess(def process.Definition) error {
 	if def.RestartPolicy == "" {
 		def.RestartPolicy = process.RestartOnFailure
 	}
+	if err := validateHealthCheck(def); err != nil {
+		return err
+	}
 
 	// Register with process manager
 	a.pm.Register(def)
@@ -316,6 +417,10 @@ func (a *App) RemoveProcess(id string) error {
 
 // UpdateProcess updates a process configuration
 func (a *App) UpdateProcess(id string, def process.Definition) error {
+	if err := validateHealthCheck(def); err != nil {
+		return err
+	}
+
 	// Stop the process first
 	err := a.pm.Stop(id)
 	if err != nil {
@@ -342,9 +447,23 @@ func (a *App) UpdateProcess(id string, def process.Definition) error {
 	return err
 }
 
+// validateHealthCheck checks the health check of a process definition, if any
+func validateHealthCheck(def process.Definition) error {
+	if def.HealthCheck == nil {
+		return nil
+	}
+	if err := def.HealthCheck.Validate(); err != nil {
+		return fmt.Errorf("健康检查配置无效: %w", err)
+	}
+	return nil
+}
+
 // StartProcess starts a process by ID
 func (a *App) StartProcess(id string) error {
-	err := a.pm.Start(a.ctx, id)
+	if a.runningElsewhere(id) {
+		return fmt.Errorf("进程已由其他 SkillUI 实例运行: %s", id)
+	}
+	err := a.pm.Start(a.processContext(), id)
 	if err != nil {
 		a.LogSystemError("StartProcess", fmt.Sprintf("Failed to start process %s: %v", id, err))
 	}
@@ -353,6 +472,14 @@ func (a *App) StartProcess(id string) error {
 
 // StopProcess stops a process by ID
 func (a *App) StopProcess(id string) error {
+	if a.runningElsewhere(id) {
+		err := process.StopPID(a.processRunDir(), id)
+		if err != nil && err != process.ErrNotRunning {
+			a.LogSystemError("StopProcess", fmt.Sprintf("Failed to stop process %s run by another instance: %v", id, err))
+			return err
+		}
+		return nil
+	}
 	err := a.pm.Stop(id)
 	if err != nil {
 		a.LogSystemError("StopProcess", fmt.Sprintf("Failed to stop process %s: %v", id, err))
@@ -362,11 +489,17 @@ func (a *App) StopProcess(id string) error {
 
 // RestartProcess restarts a process by ID
 func (a *App) RestartProcess(id string) error {
+	if a.runningElsewhere(id) {
+		if err := process.StopPID(a.processRunDir(), id); err != nil && err != process.ErrNotRunning {
+			a.LogSystemError("RestartProcess", fmt.Sprintf("Failed to stop process %s run by another instance: %v", id, err))
+			return err
+		}
+	}
 	if err := a.pm.Stop(id); err != nil {
 		a.LogSystemError("RestartProcess", fmt.Sprintf("Failed to stop process %s during restart: %v", id, err))
 		return err
 	}
-	err := a.pm.Start(a.ctx, id)
+	err := a.pm.Start(a.processContext(), id)
 	if err != nil {
 		a.LogSystemError("RestartProcess", fmt.Sprintf("Failed to start process %s during restart: %v", id, err))
 	}
@@ -375,7 +508,25 @@ func (a *App) RestartProcess(id string) error {
 
 // ListProcesses returns all processes with their status
 func (a *App) ListProcesses() []process.Snapshot {
-	return a.pm.List()
+	snapshots := a.pm.List()
+	// 由其他 SkillUI 实例（如命令行）运行的进程按 PID 文件显示为运行中
+	for i, snap := range snapshots {
+		if snap.Status != process.StatusRunning && snap.Status != process.StatusStarting {
+			if pid := process.RunningPID(a.processRunDir(), snap.Definition.ID); pid != 0 {
+				snapshots[i].Status, snapshots[i].PID = process.StatusRunning, pid
+			}
+		}
+	}
+	return snapshots
+}
+
+// runningElsewhere reports whether a process is run by another SkillUI instance
+func (a *App) runningElsewhere(id string) bool {
+	snap, err := a.pm.Get(id)
+	if err != nil || snap.Status == process.StatusRunning || snap.Status == process.StatusStarting {
+		return false
+	}
+	return process.RunningPID(a.processRunDir(), id) != 0
 }
 
 // GetProcessLogs returns logs for a specific process
@@ -899,6 +1050,9 @@ func (a *App) shutdown(ctx context.Context) {
 	// Log shutdown
 	a.LogSystemError("shutdown", "Application is shutting down")
 
+	a.stopSkillWatcher()
+	a.stopAPIServer()
+
 	// Stop all running processes gracefully
 	a.pm.StopAll()
 
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"skillui/internal/api"
+	"skillui/internal/config"
+	"skillui/internal/logging"
+)
+
+// 本机 HTTP API：默认关闭，启用后只监听 127.0.0.1，提供技能列表 / 安装 / 同步、工具扫描与进程控制，
+// 复用与界面相同的 App 方法。请求需携带令牌（Authorization: Bearer 或 ?token=），
+// GET /api/v1/openapi.yaml 提供 OpenAPI 描述，GET /api/v1/events 以 SSE 推送进程日志与技能变化，
+// /mcp 为技能 MCP 服务的 streamable HTTP 端点。
+// 运行时地址与令牌写入数据目录下的 api.json（仅当前用户可读），供本机脚本与编辑器扩展读取。
+
+const (
+	apiPrefix   = "/api/v1"
+	apiInfoFile = "api.json"
+)
+
+// EventProcessLog is published for every output line of a process (API event stream only)
+const EventProcessLog = "process:log"
+
+// ProcessLogEvent is the payload of EventProcessLog
+type ProcessLogEvent struct {
+	Process string `json:"process"`
+	logging.Entry
+}
+
+// APIStatus is the state of the local HTTP API
+type APIStatus struct {
+	Enabled bool   `json:"enabled"`
+	Running bool   `json:"running"`
+	Port    int    `json:"port"`
+	URL     string `json:"url"`
+	Token   string `json:"token"`
+	// Error 为最近一次启动失败的原因（如端口被占用）
+	Error string `json:"error,omitempty"`
+}
+
+// apiInfo is written to api.json while the server runs
+type apiInfo struct {
+	URL   string `json:"url"`
+	Port  int    `json:"port"`
+	Token string `json:"token"`
+	PID   int    `json:"pid"`
+}
+
+// apiSyncRequest is the body of the sync / unsync endpoints
+type apiSyncRequest struct {
+	Tools []string `json:"tools"`
+	// ProjectID 为空表示全局规则目录
+	ProjectID string `json:"projectId"`
+}
+
+// publishEvent sends an event to the API event stream
+func (a *App) publishEvent(name string, data interface{}) {
+	if a.events == nil {
+		return
+	}
+	a.events.Publish(api.Event{Type: name, Data: data})
+}
+
+func apiPort(cfg config.APIConfig) int {
+	if cfg.Port > 0 {
+		return cfg.Port
+	}
+	return config.DefaultAPIPort
+}
+
+// GetAPIStatus returns the settings and state of the local HTTP API
+func (a *App) GetAPIStatus() APIStatus {
+	a.apiMu.Lock()
+	defer a.apiMu.Unlock()
+	port := apiPort(a.config.API)
+	return APIStatus{
+		Enabled: a.config.API.Enabled,
+		Running: a.apiServer != nil,
+		Port:    port,
+		URL:     fmt.Sprintf("http://127.0.0.1:%d%s", port, apiPrefix),
+		Token:   a.config.API.Token,
+		Error:   a.apiErr,
+	}
+}
+
+// SetAPIEnabled turns the local HTTP API on or off; a token is generated the
+// first time it is enabled
+func (a *App) SetAPIEnabled(enabled bool) (APIStatus, error) {
+	if enabled {
+		if err := a.ensureAPIToken(); err != nil {
+			return a.GetAPIStatus(), err
+		}
+	}
+	a.setAPIConfig(func(c *config.APIConfig) { c.Enabled = enabled })
+	if err := a.store.Save(a.config); err != nil {
+		return a.GetAPIStatus(), err
+	}
+	a.stopAPIServer()
+	if enabled {
+		if err := a.startAPIServer(); err != nil {
+			return a.GetAPIStatus(), err
+		}
+	}
+	return a.GetAPIStatus(), nil
+}
+
+// SetAPIPort changes the port of the local HTTP API (0 = default) and
+// restarts the server when it runs
+func (a *App) SetAPIPort(port int) (APIStatus, error) {
+	if port < 0 || port > 65535 {
+		return a.GetAPIStatus(), fmt.Errorf("无效的端口: %d", port)
+	}
+	a.setAPIConfig(func(c *config.APIConfig) { c.Port = port })
+	if err := a.store.Save(a.config); err != nil {
+		return a.GetAPIStatus(), err
+	}
+	if a.config.API.Enabled {
+		a.stopAPIServer()
+		if err := a.startAPIServer(); err != nil {
+			return a.GetAPIStatus(), err
+		}
+	}
+	return a.GetAPIStatus(), nil
+}
+
+// RegenerateAPIToken replaces the access token; clients using the old one are rejected
+func (a *App) RegenerateAPIToken() (APIStatus, error) {
+	token, err := api.NewToken()
+	if err != nil {
+		return a.GetAPIStatus(), fmt.Errorf("生成令牌失败: %w", err)
+	}
+	a.setAPIConfig(func(c *config.APIConfig) { c.Token = token })
+	if err := a.store.Save(a.config); err != nil {
+		return a.GetAPIStatus(), err
+	}
+	a.apiMu.Lock()
+	running := a.apiServer != nil
+	a.apiMu.Unlock()
+	if running {
+		a.writeAPIInfo()
+	}
+	return a.GetAPIStatus(), nil
+}
+
+// ensureAPIToken generates the access token when there is none yet (e.g. the
+// API was enabled by editing config.json)
+func (a *App) ensureAPIToken() error {
+	if a.apiToken() != "" {
+		return nil
+	}
+	token, err := api.NewToken()
+	if err != nil {
+		return fmt.Errorf("生成令牌失败: %w", err)
+	}
+	a.setAPIConfig(func(c *config.APIConfig) { c.Token = token })
+	return a.store.Save(a.config)
+}
+
+// setAPIConfig changes the API settings under the lock the server reads them with
+func (a *App) setAPIConfig(fn func(c *config.APIConfig)) {
+	a.apiMu.Lock()
+	defer a.apiMu.Unlock()
+	fn(&a.config.API)
+}
+
+// apiToken returns the current access token
+func (a *App) apiToken() string {
+	a.apiMu.Lock()
+	defer a.apiMu.Unlock()
+	return a.config.API.Token
+}
+
+// startAPIServer listens on 127.0.0.1 and serves the API in the background
+func (a *App) startAPIServer() error {
+	a.apiMu.Lock()
+	defer a.apiMu.Unlock()
+	if a.apiServer != nil {
+		return nil
+	}
+	port := apiPort(a.config.API)
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		a.apiErr = err.Error()
+		return fmt.Errorf("启动本机 API 失败: %w", err)
+	}
+	a.apiErr = ""
+	srv := &http.Server{Handler: a.apiHandler(), ReadHeaderTimeout: 10 * time.Second}
+	a.apiServer = srv
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.LogSystemError("APIServer", fmt.Sprintf("Local API stopped: %v", err))
+		}
+	}()
+	a.writeAPIInfoLocked(port)
+	return nil
+}
+
+// stopAPIServer closes the server and its event streams
+func (a *App) stopAPIServer() {
+	a.apiMu.Lock()
+	defer a.apiMu.Unlock()
+	if a.apiServer == nil {
+		return
+	}
+	_ = a.apiServer.Close()
+	a.apiServer = nil
+	_ = os.Remove(filepath.Join(a.dataDir, apiInfoFile))
+}
+
+func (a *App) writeAPIInfo() {
+	a.apiMu.Lock()
+	defer a.apiMu.Unlock()
+	a.writeAPIInfoLocked(apiPort(a.config.API))
+}
+
+func (a *App) writeAPIInfoLocked(port int) {
+	info := apiInfo{
+		URL:   fmt.Sprintf("http://127.0.0.1:%d%s", port, apiPrefix),
+		Port:  port,
+		Token: a.config.API.Token,
+		PID:   os.Getpid(),
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return
+	}
+	// 含令牌，仅当前用户可读
+	if err := os.WriteFile(filepath.Join(a.dataDir, apiInfoFile), data, 0o600); err != nil {
+		a.LogSystemError("APIServer", fmt.Sprintf("Failed to write %s: %v", apiInfoFile, err))
+	}
+}
+
+// apiHandler routes the API to App methods
+func (a *App) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /skills", a.apiListSkills)
+	mux.HandleFunc("POST /skills", a.apiInstallSkill)
+	mux.HandleFunc("DELETE /skills/{name}", a.apiDeleteSkill)
+	mux.HandleFunc("POST /skills/{name}/sync", a.apiSyncSkill(true))
+	mux.HandleFunc("POST /skills/{name}/unsync", a.apiSyncSkill(false))
+	mux.HandleFunc("GET /tools", a.apiScanTools)
+	mux.HandleFunc("GET /processes", a.apiListProcesses)
+	mux.HandleFunc("POST /processes/{id}/start", a.apiProcessAction(a.StartProcess))
+	mux.HandleFunc("POST /processes/{id}/stop", a.apiProcessAction(a.StopProcess))
+	mux.HandleFunc("POST /processes/{id}/restart", a.apiProcessAction(a.RestartProcess))
+	mux.HandleFunc("GET /processes/{id}/logs", a.apiProcessLogs)
+	mux.HandleFunc("GET /events", a.apiEvents)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		api.WriteError(w, http.StatusNotFound, "not found")
+	})
+
+	root := http.NewServeMux()
+	// OpenAPI 描述不含敏感信息，无需令牌
+	root.HandleFunc("GET "+apiPrefix+"/openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(api.Spec)
+	})
+	root.Handle(apiPrefix+"/", http.StripPrefix(apiPrefix, api.RequireToken(a.apiToken, mux)))
+	// MCP streamable HTTP 端点（见 app_mcp.go）
+	root.Handle(mcpPath, api.RequireToken(a.apiToken, a.mcpServer().HTTPHandler()))
+	return root
+}
+
+func (a *App) apiListSkills(w http.ResponseWriter, r *http.Request) {
+	skills, err := a.ListLocalSkills()
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, skills)
+}
+
+func (a *App) apiInstallSkill(w http.ResponseWriter, r *http.Request) {
+	var opts InstallSourceOptions
+	if err := api.DecodeJSON(r, &opts); err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results, err := a.InstallSkillFromSource(opts)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, results)
+}
+
+func (a *App) apiDeleteSkill(w http.ResponseWriter, r *http.Request) {
+	report, err := a.DeleteSkillWithReport(r.PathValue("name"))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, report)
+}
+
+func (a *App) apiSyncSkill(sync bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req apiSyncRequest
+		if err := api.DecodeJSON(r, &req); err != nil {
+			api.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if len(req.Tools) == 0 {
+			api.WriteError(w, http.StatusBadRequest, "tools is required")
+			return
+		}
+		name := r.PathValue("name")
+		// 路径参数已解码，..%2F 会变成 ../
+		if err := validateSkillName(name); err != nil {
+			api.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var err error
+		switch {
+		case sync && req.ProjectID != "":
+			err = a.SyncSkillToProject(req.ProjectID, name, req.Tools)
+		case sync:
+			err = a.SyncSkillToTools(name, req.Tools)
+		case req.ProjectID != "":
+			err = a.UnsyncSkillFromProject(req.ProjectID, name, req.Tools)
+		default:
+			err = a.UnsyncSkillFromTools(name, req.Tools)
+		}
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (a *App) apiScanTools(w http.ResponseWriter, r *http.Request) {
+	tools, err := a.ScanIDETools()
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, tools)
+}
+
+func (a *App) apiListProcesses(w http.ResponseWriter, r *http.Request) {
+	api.WriteJSON(w, http.StatusOK, a.ListProcesses())
+}
+
+func (a *App) apiProcessAction(action func(id string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, err := a.pm.Get(id); err != nil {
+			api.WriteError(w, http.StatusNotFound, fmt.Sprintf("进程不存在: %s", id))
+			return
+		}
+		if err := action(id); err != nil {
+			api.WriteError(w, http.StatusConflict, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (a *App) apiProcessLogs(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := a.pm.Get(id); err != nil {
+		api.WriteError(w, http.StatusNotFound, fmt.Sprintf("进程不存在: %s", id))
+		return
+	}
+	n := 100
+	if s := r.URL.Query().Get("n"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			api.WriteError(w, http.StatusBadRequest, "n must be a number")
+			return
+		}
+		n = v
+	}
+	entries, err := logging.ReadTail(a.processLogDir(id), n)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, entries)
+}
+
+// apiEvents streams process logs and skill changes; ?types= limits the event
+// types (comma separated) and ?process= the processes whose logs are sent
+func (a *App) apiEvents(w http.ResponseWriter, r *http.Request) {
+	types := splitList(r.URL.Query().Get("types"))
+	processes := splitList(r.URL.Query().Get("process"))
+	api.ServeEvents(w, r, a.events, func(e api.Event) bool {
+		if len(types) > 0 && !containsString(types, e.Type) {
+			return false
+		}
+		if log, ok := e.Data.(ProcessLogEvent); ok && len(processes) > 0 {
+			return containsString(processes, log.Process)
+		}
+		return true
+	})
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"skillui/internal/config"
+	"skillui/internal/manifest"
+)
+
+// 技能集合：命名的技能集合（如 backend、frontend-review）保存在配置中，可在工具（全局或项目内）上激活。
+// 激活后该工具中恰好同步集合内的技能：缺少的同步，不在集合内且由 SkillUI 同步的移除；
+// 集合内容变化时，所有激活处随之更新。开启自动同步的工具若激活了集合，新安装的技能只在属于集合时同步。
+
+// CollectionApplyResult is the outcome of applying a collection to one tool
+type CollectionApplyResult struct {
+	CollectionID string `json:"collectionId"`
+	ProjectID    string `json:"projectId"`
+	ToolID       string `json:"toolId"`
+	// Synced 为同步（或重新同步）的技能
+	Synced []string `json:"synced"`
+	// Removed 为移除的不在集合内的技能
+	Removed []string `json:"removed"`
+	// Refused 为不是由 SkillUI 创建或被修改而保留的文件
+	Refused []RefusedFile `json:"refused"`
+	// Missing 为集合中尚未安装的技能
+	Missing []string `json:"missing"`
+	Errors  []string `json:"errors"`
+}
+
+// ListCollections returns all skill collections
+func (a *App) ListCollections() []config.Collection {
+	if a.config.Collections == nil {
+		return []config.Collection{}
+	}
+	return a.config.Collections
+}
+
+// AddCollection creates a named skill collection
+func (a *App) AddCollection(name, description string, skills []string) (config.Collection, error) {
+	c, err := normalizeCollection(config.Collection{
+		ID:          "coll-" + uuid.NewString()[:8],
+		Name:        name,
+		Description: description,
+		Skills:      skills,
+	})
+	if err != nil {
+		return config.Collection{}, err
+	}
+	for _, existing := range a.config.Collections {
+		if strings.EqualFold(existing.Name, c.Name) {
+			return config.Collection{}, fmt.Errorf("集合名称已存在: %s", c.Name)
+		}
+	}
+	a.config.Collections = append(a.config.Collections, c)
+	if err := a.store.Save(a.config); err != nil {
+		return config.Collection{}, err
+	}
+	return c, nil
+}
+
+// UpdateCollection changes a collection and re-applies it wherever it is active
+func (a *App) UpdateCollection(c config.Collection) ([]CollectionApplyResult, error) {
+	c, err := normalizeCollection(c)
+	if err != nil {
+		return nil, err
+	}
+	idx := a.collectionIndex(c.ID)
+	if idx < 0 {
+		return nil, fmt.Errorf("集合不存在: %s", c.ID)
+	}
+	for _, existing := range a.config.Collections {
+		if existing.ID != c.ID && strings.EqualFold(existing.Name, c.Name) {
+			return nil, fmt.Errorf("集合名称已存在: %s", c.Name)
+		}
+	}
+	a.config.Collections[idx] = c
+	if err := a.store.Save(a.config); err != nil {
+		return nil, err
+	}
+	results := make([]CollectionApplyResult, 0)
+	for _, act := range a.config.ActiveCollections {
+		if act.CollectionID == c.ID {
+			results = append(results, a.applyCollection(c, act))
+		}
+	}
+	return results, nil
+}
+
+// RemoveCollection deletes a collection and its activations; skills already
+// synced by it stay where they are
+func (a *App) RemoveCollection(id string) error {
+	idx := a.collectionIndex(id)
+	if idx < 0 {
+		return fmt.Errorf("集合不存在: %s", id)
+	}
+	a.config.Collections = append(a.config.Collections[:idx:idx], a.config.Collections[idx+1:]...)
+	active := make([]config.CollectionActivation, 0, len(a.config.ActiveCollections))
+	for _, act := range a.config.ActiveCollections {
+		if act.CollectionID != id {
+			active = append(active, act)
+		}
+	}
+	a.config.ActiveCollections = active
+	return a.store.Save(a.config)
+}
+
+// ListCollectionActivations returns where collections are active
+func (a *App) ListCollectionActivations() []config.CollectionActivation {
+	if a.config.ActiveCollections == nil {
+		return []config.CollectionActivation{}
+	}
+	return a.config.ActiveCollections
+}
+
+// ActivateCollection makes a collection the exact set of skills synced to
+// the given tools of a project (global rules dirs when projectID is empty),
+// replacing a collection previously active there
+func (a *App) ActivateCollection(collectionID, projectID string, toolIds []string) ([]CollectionApplyResult, error) {
+	idx := a.collectionIndex(collectionID)
+	if idx < 0 {
+		return nil, fmt.Errorf("集合不存在: %s", collectionID)
+	}
+	root, err := a.projectRoot(projectID)
+	if err != nil {
+		return nil, err
+	}
+	defMap := make(map[string]ideToolDef)
+	for _, d := range ideToolDefs(a.config.ToolPaths, a.config.CustomTools) {
+		defMap[d.ID] = d
+	}
+	for _, toolID := range toolIds {
+		def, ok := defMap[toolID]
+		if !ok {
+			return nil, fmt.Errorf("未知的工具: %s", toolID)
+		}
+		if def.rulesDirIn(root) == "" {
+			return nil, fmt.Errorf("%s 不支持项目级同步", def.Name)
+		}
+	}
+
+	results := make([]CollectionApplyResult, 0, len(toolIds))
+	for _, toolID := range toolIds {
+		act := config.CollectionActivation{CollectionID: collectionID, ToolID: toolID, ProjectID: projectID}
+		a.setCollectionActivation(act)
+		results = append(results, a.applyCollection(a.config.Collections[idx], act))
+	}
+	return results, a.store.Save(a.config)
+}
+
+// DeactivateCollection forgets the collection active on a tool; the synced
+// skills stay and can be managed individually again
+func (a *App) DeactivateCollection(projectID, toolID string) error {
+	active := make([]config.CollectionActivation, 0, len(a.config.ActiveCollections))
+	for _, act := range a.config.ActiveCollections {
+		if act.ProjectID != projectID || act.ToolID != toolID {
+			active = append(active, act)
+		}
+	}
+	a.config.ActiveCollections = active
+	return a.store.Save(a.config)
+}
+
+// applyCollection syncs exactly the collection's skills to one tool
+func (a *App) applyCollection(c config.Collection, act config.CollectionActivation) CollectionApplyResult {
+	result := CollectionApplyResult{
+		CollectionID: c.ID, ProjectID: act.ProjectID, ToolID: act.ToolID,
+		Synced: []string{}, Removed: []string{}, Refused: []RefusedFile{}, Missing: []string{}, Errors: []string{},
+	}
+	root, err := a.projectRoot(act.ProjectID)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	installed, err := a.syncableSkillNames(nil)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	isInstalled := make(map[string]bool, len(installed))
+	for _, name := range installed {
+		isInstalled[name] = true
+	}
+
+	wanted := make(map[string]bool, len(c.Skills))
+	for _, name := range c.Skills {
+		wanted[name] = true
+		if !isInstalled[name] {
+			result.Missing = append(result.Missing, name)
+			continue
+		}
+		if err := a.syncSkillToTools(root, name, []string{act.ToolID}); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		result.Synced = append(result.Synced, name)
+	}
+
+	// 移除该工具中不在集合内的技能：已安装且检测到已同步的，以及同步清单中记录的（技能可能已删除）
+	candidates := map[string]bool{}
+	defs := ideToolDefs(a.config.ToolPaths, a.config.CustomTools)
+	for _, name := range installed {
+		for _, def := range syncedToolDefs(defs, root, name) {
+			if def.ID == act.ToolID {
+				candidates[name] = true
+			}
+		}
+	}
+	for _, e := range a.syncManifest().Entries(func(e manifest.Entry) bool {
+		return e.ToolID == act.ToolID && e.Project == root
+	}) {
+		candidates[e.Skill] = true
+	}
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		if !wanted[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report, err := a.unsyncSkill(root, name, []string{act.ToolID})
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", name, err))
+		}
+		if len(report.Removed) > 0 {
+			result.Removed = append(result.Removed, name)
+		}
+		result.Refused = append(result.Refused, report.Refused...)
+	}
+	return result
+}
+
+// collectionAllows reports whether auto-sync may add a skill to a global
+// tool: always, unless a collection without the skill is active on it
+func (a *App) collectionAllows(toolID, skillName string) bool {
+	for _, act := range a.config.ActiveCollections {
+		if act.ToolID != toolID || act.ProjectID != "" {
+			continue
+		}
+		idx := a.collectionIndex(act.CollectionID)
+		if idx < 0 {
+			return true
+		}
+		for _, name := range a.config.Collections[idx].Skills {
+			if name == skillName {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// setCollectionActivation records an activation, replacing the one of the same tool and scope
+func (a *App) setCollectionActivation(act config.CollectionActivation) {
+	for i, existing := range a.config.ActiveCollections {
+		if existing.ProjectID == act.ProjectID && existing.ToolID == act.ToolID {
+			a.config.ActiveCollections[i] = act
+			return
+		}
+	}
+	a.config.ActiveCollections = append(a.config.ActiveCollections, act)
+}
+
+// collectionIndex returns the index of a collection in the config, or -1
+func (a *App) collectionIndex(id string) int {
+	for i, c := range a.config.Collections {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeCollection trims a collection and validates / de-duplicates its skills
+func normalizeCollection(c config.Collection) (config.Collection, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Description = strings.TrimSpace(c.Description)
+	if c.Name == "" {
+		return c, fmt.Errorf("集合名称不能为空")
+	}
+	seen := map[string]bool{}
+	skills := make([]string, 0, len(c.Skills))
+	for _, name := range c.Skills {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			continue
+		}
+		if err := validateSkillName(name); err != nil {
+			return c, err
+		}
+		seen[name] = true
+		skills = append(skills, name)
+	}
+	c.Skills = skills
+	return c, nil
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"skillui/internal/mcp"
+	"skillui/internal/skill"
+)
+
+// MCP 服务：把技能目录中已安装的技能通过 Model Context Protocol 提供给智能体，
+// 任何支持 MCP 的工具无需逐个同步即可使用技能。
+// 每个技能的 SKILL.md 是一个资源（skill://<技能>/SKILL.md），其余文件按需通过
+// skill://<技能>/<路径> 读取；每个技能同时是一个 prompt。工具 search_skills / get_skill /
+// read_skill_file 供智能体按标签和描述搜索并读取技能。
+// 传输：`skillui mcp` 使用 stdio；本机 API 启用时 /mcp 为 streamable HTTP 端点（同一令牌）。
+// 文件范围与同步到工具时一致：skillui.json 与 .skilluiignore 中忽略的文件不对外提供。
+
+const (
+	mcpURIScheme = "skill://"
+	mcpPath      = "/mcp"
+	// defaultMCPPort 为 `skillui mcp --http` 的默认端口（与窗口内的本机 API 错开）
+	defaultMCPPort = 17322
+	// maxMCPFileSize 为单个资源文件的读取上限
+	maxMCPFileSize = 5 << 20
+	// defaultSearchLimit 为 search_skills 默认返回的技能数
+	defaultSearchLimit = 20
+)
+
+const mcpInstructions = `This server provides the skills installed in SkillUI. Each skill is a SKILL.md with instructions for a kind of task, plus optional resource files.
+Call search_skills to find skills relevant to the task (by words in the name, title, description or tags), then get_skill to load the instructions and the list of resource files, and read_skill_file for a resource file the instructions refer to.`
+
+// mcpServer returns the app's MCP server, created on first use
+func (a *App) mcpServer() *mcp.Server {
+	a.mcpOnce.Do(func() {
+		a.mcp = mcp.NewServer(mcp.Implementation{
+			Name:    AppName,
+			Title:   AppDisplayName,
+			Version: appConfig.Version,
+		}, mcpInstructions, &skillMCPProvider{app: a})
+	})
+	return a.mcp
+}
+
+// notifySkillListChanged tells connected MCP clients to reload the skill lists
+func (a *App) notifySkillListChanged() {
+	srv := a.mcpServer()
+	srv.Notify(mcp.NotifyResourcesChanged)
+	srv.Notify(mcp.NotifyPromptsChanged)
+}
+
+// skillMCPProvider serves the installed skills
+type skillMCPProvider struct {
+	app *App
+}
+
+// mcpSkillSummary is one search_skills hit
+type mcpSkillSummary struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	URI         string   `json:"uri"`
+}
+
+// mcpSkillFile is a file of a skill
+type mcpSkillFile struct {
+	Path string `json:"path"`
+	URI  string `json:"uri"`
+	Size int64  `json:"size"`
+}
+
+func skillURI(name, rel string) string {
+	return mcpURIScheme + name + "/" + rel
+}
+
+// skills returns the installed skills keyed by directory name
+func (p *skillMCPProvider) skills() (map[string]SkillMeta, []string, error) {
+	list, err := p.app.indexedSkills()
+	if err != nil {
+		return nil, nil, err
+	}
+	byName := make(map[string]SkillMeta, len(list))
+	names := make([]string, 0, len(list))
+	for _, meta := range list {
+		name := filepath.Base(meta.Location)
+		byName[name] = meta
+		names = append(names, name)
+	}
+	return byName, names, nil
+}
+
+// skill looks up one installed skill by directory name
+func (p *skillMCPProvider) skill(name string) (SkillMeta, bool) {
+	if validateSkillName(name) != nil {
+		return SkillMeta{}, false
+	}
+	dir := filepath.Join(p.app.getSkillDir(), name)
+	if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err != nil {
+		return SkillMeta{}, false
+	}
+	return parseSkillMeta(dir), true
+}
+
+// files lists the files of a skill that are served, SKILL.md first
+func (p *skillMCPProvider) files(name string) ([]mcpSkillFile, error) {
+	dir := filepath.Join(p.app.getSkillDir(), name)
+	ig, err := skill.LoadIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+	rels, err := ig.Files(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(rels, func(i, j int) bool {
+		if (rels[i] == "SKILL.md") != (rels[j] == "SKILL.md") {
+			return rels[i] == "SKILL.md"
+		}
+		return rels[i] < rels[j]
+	})
+	files := make([]mcpSkillFile, 0, len(rels))
+	for _, rel := range rels {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		files = append(files, mcpSkillFile{Path: rel, URI: skillURI(name, rel), Size: info.Size()})
+	}
+	return files, nil
+}
+
+// readFile reads a served file of a skill. Only paths listed by files are
+// accepted, which keeps ignored files, symlinks and paths outside the skill out.
+func (p *skillMCPProvider) readFile(name, rel string) (mcp.ResourceContents, error) {
+	if _, ok := p.skill(name); !ok {
+		return mcp.ResourceContents{}, mcp.Errorf(mcp.CodeResourceNotFound, "skill not found: %s", name)
+	}
+	files, err := p.files(name)
+	if err != nil {
+		return mcp.ResourceContents{}, err
+	}
+	rel = path.Clean(strings.TrimPrefix(rel, "/"))
+	var file *mcpSkillFile
+	for i := range files {
+		if files[i].Path == rel {
+			file = &files[i]
+			break
+		}
+	}
+	if file == nil {
+		return mcp.ResourceContents{}, mcp.Errorf(mcp.CodeResourceNotFound, "file not found in skill %s: %s", name, rel)
+	}
+	if file.Size > maxMCPFileSize {
+		return mcp.ResourceContents{}, mcp.Errorf(mcp.CodeInvalidParams, "file %s is too large (%d bytes)", rel, file.Size)
+	}
+	data, err := os.ReadFile(filepath.Join(p.app.getSkillDir(), name, filepath.FromSlash(rel)))
+	if err != nil {
+		return mcp.ResourceContents{}, err
+	}
+	contents := mcp.ResourceContents{URI: file.URI, MimeType: mcpMimeType(rel, data)}
+	if utf8.Valid(data) {
+		contents.Text = string(data)
+	} else {
+		contents.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+	return contents, nil
+}
+
+func mcpMimeType(rel string, data []byte) string {
+	switch strings.ToLower(path.Ext(rel)) {
+	case ".md", ".markdown":
+		return "text/markdown"
+	}
+	if t := mime.TypeByExtension(path.Ext(rel)); t != "" {
+		return t
+	}
+	if utf8.Valid(data) {
+		return "text/plain"
+	}
+	return "application/octet-stream"
+}
+
+func skillTitle(meta SkillMeta) string {
+	if meta.Title != "" {
+		return meta.Title
+	}
+	return meta.Name
+}
+
+func skillDescription(meta SkillMeta) string {
+	if meta.Description != "" {
+		return meta.Description
+	}
+	if meta.DescEn != "" {
+		return meta.DescEn
+	}
+	return meta.DescZh
+}
+
+// ---- resources ----
+
+func (p *skillMCPProvider) Resources() ([]mcp.Resource, error) {
+	byName, names, err := p.skills()
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]mcp.Resource, 0, len(names))
+	for _, name := range names {
+		meta := byName[name]
+		resources = append(resources, mcp.Resource{
+			URI:         skillURI(name, "SKILL.md"),
+			Name:        name,
+			Title:       skillTitle(meta),
+			Description: skillDescription(meta),
+			MimeType:    "text/markdown",
+		})
+	}
+	return resources, nil
+}
+
+func (p *skillMCPProvider) ResourceTemplates() []mcp.ResourceTemplate {
+	return []mcp.ResourceTemplate{{
+		URITemplate: mcpURIScheme + "{skill}/{+path}",
+		Name:        "skill-file",
+		Title:       "Skill file",
+		Description: "A file of an installed skill: SKILL.md or a resource file it refers to (see get_skill for the list)",
+	}}
+}
+
+func (p *skillMCPProvider) ReadResource(uri string) ([]mcp.ResourceContents, error) {
+	rest, ok := strings.CutPrefix(uri, mcpURIScheme)
+	name, rel, found := strings.Cut(rest, "/")
+	if !ok || !found || name == "" || rel == "" {
+		return nil, mcp.Errorf(mcp.CodeResourceNotFound, "unknown resource: %s", uri)
+	}
+	contents, err := p.readFile(name, rel)
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{contents}, nil
+}
+
+// ---- prompts ----
+
+func (p *skillMCPProvider) Prompts() ([]mcp.Prompt, error) {
+	byName, names, err := p.skills()
+	if err != nil {
+		return nil, err
+	}
+	prompts := make([]mcp.Prompt, 0, len(names))
+	for _, name := range names {
+		meta := byName[name]
+		prompts = append(prompts, mcp.Prompt{
+			Name:        name,
+			Title:       skillTitle(meta),
+			Description: skillDescription(meta),
+			Arguments: []mcp.PromptArgument{
+				{Name: "task", Description: "The task to apply the skill to"},
+			},
+		})
+	}
+	return prompts, nil
+}
+
+func (p *skillMCPProvider) GetPrompt(name string, args map[string]string) (mcp.GetPromptResult, error) {
+	meta, ok := p.skill(name)
+	if !ok {
+		return mcp.GetPromptResult{}, mcp.Errorf(mcp.CodeInvalidParams, "unknown prompt: %s", name)
+	}
+	text, err := p.skillInstructions(name)
+	if err != nil {
+		return mcp.GetPromptResult{}, err
+	}
+	if task := strings.TrimSpace(args["task"]); task != "" {
+		text = fmt.Sprintf("Use the skill below for this task: %s\n\n%s", task, text)
+	}
+	return mcp.GetPromptResult{
+		Description: skillDescription(meta),
+		Messages:    []mcp.PromptMessage{{Role: "user", Content: mcp.TextContent(text)}},
+	}, nil
+}
+
+// skillInstructions returns SKILL.md followed by the URIs of the resource files
+func (p *skillMCPProvider) skillInstructions(name string) (string, error) {
+	doc, err := p.readFile(name, "SKILL.md")
+	if err != nil {
+		return "", err
+	}
+	files, err := p.files(name)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(doc.Text, "\n"))
+	if len(files) > 1 {
+		b.WriteString("\n\n---\nResource files of this skill (read them with read_skill_file or as resources):\n")
+		for _, f := range files[1:] {
+			fmt.Fprintf(&b, "- %s (%s)\n", f.Path, f.URI)
+		}
+	}
+	return b.String(), nil
+}
+
+// ---- tools ----
+
+func (p *skillMCPProvider) Tools() []mcp.Tool {
+	readOnly := map[string]interface{}{"readOnlyHint": true}
+	return []mcp.Tool{
+		{
+			Name:        "search_skills",
+			Title:       "Search skills",
+			Description: "Search the installed skills by words in their name, title, description or tags. Returns the best matches first; without a query and tags all skills are listed.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{"type": "string", "description": "Words that must all appear in the skill's name, title, description or tags"},
+					"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Only skills having all of these tags"},
+					"limit": map[string]interface{}{"type": "integer", "description": fmt.Sprintf("Maximum number of skills (default %d)", defaultSearchLimit)},
+				},
+			},
+			Annotations: readOnly,
+		},
+		{
+			Name:        "get_skill",
+			Title:       "Get skill",
+			Description: "Load the instructions (SKILL.md) of a skill and the list of its resource files.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "string", "description": "Skill name as returned by search_skills"},
+				},
+				"required": []string{"name"},
+			},
+			Annotations: readOnly,
+		},
+		{
+			Name:        "read_skill_file",
+			Title:       "Read skill file",
+			Description: "Read a resource file of a skill (a path listed by get_skill).",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "string", "description": "Skill name"},
+					"path": map[string]interface{}{"type": "string", "description": "Path of the file inside the skill, e.g. scripts/run.py"},
+				},
+				"required": []string{"name", "path"},
+			},
+			Annotations: readOnly,
+		},
+	}
+}
+
+func (p *skillMCPProvider) CallTool(ctx context.Context, name string, args json.RawMessage) (mcp.CallToolResult, error) {
+	switch name {
+	case "search_skills":
+		var in struct {
+			Query string   `json:"query"`
+			Tags  []string `json:"tags"`
+			Limit int      `json:"limit"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil {
+			return toolError("invalid arguments: %v", err), nil
+		}
+		return p.searchSkills(in.Query, in.Tags, in.Limit)
+	case "get_skill":
+		var in struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil {
+			return toolError("invalid arguments: %v", err), nil
+		}
+		if _, ok := p.skill(in.Name); !ok {
+			return toolError("skill not found: %s (use search_skills to list skills)", in.Name), nil
+		}
+		text, err := p.skillInstructions(in.Name)
+		if err != nil {
+			return toolError("%v", err), nil
+		}
+		files, err := p.files(in.Name)
+		if err != nil {
+			return toolError("%v", err), nil
+		}
+		return mcp.CallToolResult{
+			Content: []mcp.Content{mcp.TextContent(text)},
+			StructuredContent: map[string]interface{}{
+				"name":  in.Name,
+				"uri":   skillURI(in.Name, "SKILL.md"),
+				"files": files,
+			},
+		}, nil
+	case "read_skill_file":
+		var in struct {
+			Name string `json:"name"`
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(args, &in); err != nil {
+			return toolError("invalid arguments: %v", err), nil
+		}
+		contents, err := p.readFile(in.Name, in.Path)
+		if err != nil {
+			return toolError("%v", err), nil
+		}
+		return mcp.CallToolResult{Content: []mcp.Content{mcp.ResourceContent(contents)}}, nil
+	}
+	return mcp.CallToolResult{}, mcp.Errorf(mcp.CodeInvalidParams, "unknown tool: %s", name)
+}
+
+func toolError(format string, args ...interface{}) mcp.CallToolResult {
+	return mcp.CallToolResult{Content: []mcp.Content{mcp.TextContent(fmt.Sprintf(format, args...))}, IsError: true}
+}
+
+// searchSkills ranks skills matching every query word and tag: name matches
+// first, then title and tag matches, then description matches
+func (p *skillMCPProvider) searchSkills(query string, tags []string, limit int) (mcp.CallToolResult, error) {
+	byName, names, err := p.skills()
+	if err != nil {
+		return toolError("%v", err), nil
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	words := strings.Fields(strings.ToLower(query))
+
+	type hit struct {
+		summary mcpSkillSummary
+		score   int
+	}
+	hits := make([]hit, 0)
+	for _, name := range names {
+		meta := byName[name]
+		if !hasAllTags(meta.Tags, tags) {
+			continue
+		}
+		title := strings.ToLower(strings.Join([]string{meta.Title, meta.TitleEn, meta.TitleZh}, " "))
+		desc := strings.ToLower(strings.Join([]string{meta.Description, meta.DescEn, meta.DescZh}, " "))
+		tagText := strings.ToLower(strings.Join(meta.Tags, " "))
+		score, matched := 0, true
+		for _, w := range words {
+			switch {
+			case strings.Contains(strings.ToLower(name), w):
+				score += 4
+			case strings.Contains(title, w) || strings.Contains(tagText, w):
+				score += 2
+			case strings.Contains(desc, w):
+				score++
+			default:
+				matched = false
+			}
+		}
+		if !matched {
+			continue
+		}
+		hits = append(hits, hit{score: score, summary: mcpSkillSummary{
+			Name:        name,
+			Title:       meta.Title,
+			Description: skillDescription(meta),
+			Tags:        meta.Tags,
+			URI:         skillURI(name, "SKILL.md"),
+		}})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	summaries := make([]mcpSkillSummary, 0, len(hits))
+	var b strings.Builder
+	for _, h := range hits {
+		summaries = append(summaries, h.summary)
+		fmt.Fprintf(&b, "- %s: %s", h.summary.Name, h.summary.Description)
+		if len(h.summary.Tags) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(h.summary.Tags, ", "))
+		}
+		b.WriteString("\n")
+	}
+	if len(hits) == 0 {
+		b.WriteString("No matching skills.")
+	}
+	return mcp.CallToolResult{
+		Content:           []mcp.Content{mcp.TextContent(b.String())},
+		StructuredContent: map[string]interface{}{"skills": summaries},
+	}, nil
+}
+
+func hasAllTags(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if strings.EqualFold(h, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
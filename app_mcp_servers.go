@@ -0,0 +1,489 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"skillui/internal/config"
+	"skillui/internal/manifest"
+	"skillui/internal/mcp"
+	"skillui/internal/mcpconfig"
+	"skillui/internal/process"
+)
+
+// MCP 服务管理：MCP 服务（stdio 命令或 streamable HTTP 地址）保存在配置中，
+// 像同步技能一样写入各工具的 MCP 配置文件（Claude Code 的 ~/.claude.json、Cursor 的 mcp.json、
+// Zed / VS Code 设置等，见工具注册表的 mcpFormat / mcpConfig），可按工具启用或停用。
+// 写入的条目记录在数据目录下的 mcp_manifest.json 中：停用与删除只移除 SkillUI 写入且未被修改的条目，
+// 工具配置中不是 SkillUI 写入的同名服务一律保留。握手测试启动服务、完成 initialize 并列出其工具。
+
+// mcpManifestFile records the server entries written into tool configs
+const mcpManifestFile = "mcp_manifest.json"
+
+// MCP server state in a tool config
+const (
+	// MCPStateWritten: 条目由 SkillUI 写入且与当前定义一致
+	MCPStateWritten = "written"
+	// MCPStateOutdated: 条目由 SkillUI 写入，但服务定义已变化，需重新写入
+	MCPStateOutdated = "outdated"
+	// MCPStateMissing: 已启用但配置中没有条目（如被手动删除）
+	MCPStateMissing = "missing"
+	// MCPStateModified: SkillUI 写入后被手动修改
+	MCPStateModified = "modified"
+	// MCPStateConflict: 配置中有不是 SkillUI 写入的同名服务
+	MCPStateConflict = "conflict"
+	// MCPStateAbsent: 未启用且配置中没有条目
+	MCPStateAbsent = "absent"
+)
+
+var mcpServerNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// MCPToolTarget is a tool whose MCP config SkillUI can write
+type MCPToolTarget struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	// ConfigPath 为该工具在当前系统上的 MCP 配置文件
+	ConfigPath string `json:"configPath"`
+	// Exists 表示配置文件已存在
+	Exists bool `json:"exists"`
+}
+
+// MCPToolState is the state of a server in one tool's MCP config
+type MCPToolState struct {
+	ToolID  string `json:"toolId"`
+	Enabled bool   `json:"enabled"`
+	State   string `json:"state"`
+	Error   string `json:"error,omitempty"`
+}
+
+// MCPServerInfo is a server with its state in every MCP-capable tool
+type MCPServerInfo struct {
+	config.MCPServer
+	States []MCPToolState `json:"states"`
+}
+
+// MCPApplyResult is the outcome of writing or removing a server in one tool
+type MCPApplyResult struct {
+	ServerID string `json:"serverId"`
+	ToolID   string `json:"toolId"`
+	// Action 为 written / removed / unchanged / refused
+	Action string `json:"action"`
+	// Reason 说明保留未动的原因（refused）
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// MCPServerChange is a saved server and what writing it into tool configs did
+type MCPServerChange struct {
+	Server  MCPServerInfo    `json:"server"`
+	Results []MCPApplyResult `json:"results"`
+}
+
+// MCPTestResult is the outcome of a handshake test
+type MCPTestResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	mcp.ProbeResult
+}
+
+// mcpConfigManifest returns the manifest of written server entries, loading it on first use
+func (a *App) mcpConfigManifest() *manifest.Manifest {
+	a.mcpManifestOnce.Do(func() {
+		a.mcpManifest = manifest.Load(filepath.Join(a.dataDir, mcpManifestFile))
+	})
+	return a.mcpManifest
+}
+
+// ListMCPTools returns the tools whose MCP config SkillUI can write on this system
+func (a *App) ListMCPTools() []MCPToolTarget {
+	targets := make([]MCPToolTarget, 0)
+	for _, def := range registryTools() {
+		path := def.MCPConfigFor("")
+		if path == "" {
+			continue
+		}
+		_, err := os.Stat(path)
+		targets = append(targets, MCPToolTarget{ID: def.ID, Name: def.Name, Format: def.MCPFormat, ConfigPath: path, Exists: err == nil})
+	}
+	return targets
+}
+
+// mcpTarget returns the MCP config target of a tool
+func (a *App) mcpTarget(toolID string) (MCPToolTarget, error) {
+	for _, t := range a.ListMCPTools() {
+		if t.ID == toolID {
+			return t, nil
+		}
+	}
+	return MCPToolTarget{}, fmt.Errorf("工具不支持写入 MCP 配置: %s", toolID)
+}
+
+// ListMCPServers returns the managed MCP servers and their state in each tool
+func (a *App) ListMCPServers() []MCPServerInfo {
+	targets := a.ListMCPTools()
+	// 每个配置文件只读取一次
+	files := map[string]map[string]json.RawMessage{}
+	fileErrs := map[string]error{}
+	for _, t := range targets {
+		if _, ok := files[t.ConfigPath]; ok {
+			continue
+		}
+		files[t.ConfigPath], fileErrs[t.ConfigPath] = mcpconfig.Read(t.ConfigPath, t.Format)
+	}
+
+	infos := make([]MCPServerInfo, 0, len(a.config.MCPServers))
+	for _, s := range a.config.MCPServers {
+		info := MCPServerInfo{MCPServer: s, States: make([]MCPToolState, 0, len(targets))}
+		for _, t := range targets {
+			st := MCPToolState{ToolID: t.ID, Enabled: containsString(s.Tools, t.ID)}
+			if err := fileErrs[t.ConfigPath]; err != nil {
+				st.Error = err.Error()
+			} else {
+				st.State = a.mcpEntryState(s, t, files[t.ConfigPath][s.Name], st.Enabled)
+			}
+			info.States = append(info.States, st)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// mcpEntryState classifies the entry named like s in a tool config (nil = none)
+func (a *App) mcpEntryState(s config.MCPServer, t MCPToolTarget, existing json.RawMessage, enabled bool) string {
+	if existing == nil {
+		if enabled {
+			return MCPStateMissing
+		}
+		return MCPStateAbsent
+	}
+	owned, ok := a.mcpConfigManifest().Get(mcpEntryKey(t.ConfigPath, s.Name))
+	if !ok || owned.Server != s.ID {
+		return MCPStateConflict
+	}
+	current := mcpconfig.Hash(existing)
+	if current != owned.Hash {
+		return MCPStateModified
+	}
+	if want, err := mcpconfig.Entry(t.Format, mcpConfigServer(s)); err == nil && mcpconfig.Hash(want) != current {
+		return MCPStateOutdated
+	}
+	return MCPStateWritten
+}
+
+// AddMCPServer creates a server and writes it into the tools it is enabled for
+func (a *App) AddMCPServer(s config.MCPServer) (MCPServerChange, error) {
+	s.ID = "mcp-" + uuid.NewString()[:8]
+	s, err := a.normalizeMCPServer(s)
+	if err != nil {
+		return MCPServerChange{}, err
+	}
+	a.config.MCPServers = append(a.config.MCPServers, s)
+	if err := a.store.Save(a.config); err != nil {
+		return MCPServerChange{}, err
+	}
+	results := make([]MCPApplyResult, 0, len(s.Tools))
+	for _, toolID := range s.Tools {
+		results = append(results, a.writeMCPServer(s, toolID))
+	}
+	return MCPServerChange{Server: a.mcpServerInfo(s.ID), Results: results}, nil
+}
+
+// UpdateMCPServer changes a server: tool configs it is enabled in are
+// rewritten, tools no longer in its list (and entries under an old name) are cleaned up
+func (a *App) UpdateMCPServer(s config.MCPServer) (MCPServerChange, error) {
+	idx := a.mcpServerIndex(s.ID)
+	if idx < 0 {
+		return MCPServerChange{}, fmt.Errorf("MCP 服务不存在: %s", s.ID)
+	}
+	s, err := a.normalizeMCPServer(s)
+	if err != nil {
+		return MCPServerChange{}, err
+	}
+	old := a.config.MCPServers[idx]
+	results := make([]MCPApplyResult, 0)
+	for _, toolID := range old.Tools {
+		if old.Name != s.Name || !containsString(s.Tools, toolID) {
+			results = append(results, a.removeMCPServer(old, toolID))
+		}
+	}
+	a.config.MCPServers[idx] = s
+	if err := a.store.Save(a.config); err != nil {
+		return MCPServerChange{Results: results}, err
+	}
+	for _, toolID := range s.Tools {
+		results = append(results, a.writeMCPServer(s, toolID))
+	}
+	return MCPServerChange{Server: a.mcpServerInfo(s.ID), Results: results}, nil
+}
+
+// SetMCPServerEnabled enables or disables a server in one tool
+func (a *App) SetMCPServerEnabled(id, toolID string, enabled bool) (MCPApplyResult, error) {
+	idx := a.mcpServerIndex(id)
+	if idx < 0 {
+		return MCPApplyResult{}, fmt.Errorf("MCP 服务不存在: %s", id)
+	}
+	if _, err := a.mcpTarget(toolID); err != nil {
+		return MCPApplyResult{}, err
+	}
+	s := &a.config.MCPServers[idx]
+	tools := make([]string, 0, len(s.Tools)+1)
+	for _, t := range s.Tools {
+		if t != toolID {
+			tools = append(tools, t)
+		}
+	}
+	if enabled {
+		tools = append(tools, toolID)
+	}
+	s.Tools = tools
+	if err := a.store.Save(a.config); err != nil {
+		return MCPApplyResult{}, err
+	}
+	if enabled {
+		return a.writeMCPServer(*s, toolID), nil
+	}
+	return a.removeMCPServer(*s, toolID), nil
+}
+
+// RemoveMCPServer removes a server from every tool config and deletes it
+func (a *App) RemoveMCPServer(id string) ([]MCPApplyResult, error) {
+	idx := a.mcpServerIndex(id)
+	if idx < 0 {
+		return nil, fmt.Errorf("MCP 服务不存在: %s", id)
+	}
+	s := a.config.MCPServers[idx]
+	results := make([]MCPApplyResult, 0, len(s.Tools))
+	for _, toolID := range s.Tools {
+		results = append(results, a.removeMCPServer(s, toolID))
+	}
+	a.config.MCPServers = append(a.config.MCPServers[:idx:idx], a.config.MCPServers[idx+1:]...)
+	return results, a.store.Save(a.config)
+}
+
+// TestMCPServer launches (or connects to) a server, performs the MCP
+// handshake and lists its tools. The server does not have to be saved yet.
+func (a *App) TestMCPServer(s config.MCPServer) (MCPTestResult, error) {
+	s.Name = "test"
+	if err := validateMCPServer(&s); err != nil {
+		return MCPTestResult{}, err
+	}
+	probe, err := mcp.Probe(a.processContext(), mcp.ProbeOptions{
+		Transport: s.Transport,
+		Command:   s.Command,
+		Args:      s.Args,
+		Env:       process.Environment(s.Env),
+		URL:       s.URL,
+		Headers:   s.Headers,
+	})
+	result := MCPTestResult{OK: err == nil, ProbeResult: probe}
+	if err != nil {
+		result.Error = fmt.Sprintf("握手失败: %v", err)
+	}
+	return result, nil
+}
+
+// writeMCPServer writes a server's entry into a tool config, unless an entry
+// of the same name that SkillUI did not write (or that was edited) is in the way
+func (a *App) writeMCPServer(s config.MCPServer, toolID string) MCPApplyResult {
+	result := MCPApplyResult{ServerID: s.ID, ToolID: toolID}
+	t, err := a.mcpTarget(toolID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	entry, err := mcpconfig.Entry(t.Format, mcpConfigServer(s))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	servers, err := mcpconfig.Read(t.ConfigPath, t.Format)
+	if err != nil {
+		result.Error = fmt.Sprintf("读取 MCP 配置失败: %v", err)
+		return result
+	}
+	key := mcpEntryKey(t.ConfigPath, s.Name)
+	want := mcpconfig.Hash(entry)
+	if existing, ok := servers[s.Name]; ok {
+		current := mcpconfig.Hash(existing)
+		owned, isOwned := a.mcpConfigManifest().Get(key)
+		switch {
+		case current == want:
+			// 内容一致（包括手动添加的相同条目）：收编即可
+			result.Action = "unchanged"
+			if err := a.recordMCPEntry(s, toolID, key, want); err != nil {
+				result.Error = err.Error()
+			}
+			return result
+		case !isOwned || owned.Server != s.ID:
+			result.Action = "refused"
+			result.Reason = "配置中已有同名 MCP 服务（不是由 SkillUI 写入）"
+			return result
+		case owned.Hash != current:
+			result.Action = "refused"
+			result.Reason = "写入后已被手动修改"
+			return result
+		}
+	}
+	if err := mcpconfig.Put(t.ConfigPath, t.Format, s.Name, entry); err != nil {
+		result.Error = fmt.Sprintf("写入 MCP 配置失败: %v", err)
+		return result
+	}
+	if err := a.recordMCPEntry(s, toolID, key, want); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Action = "written"
+	return result
+}
+
+// removeMCPServer removes a server's entry from a tool config when SkillUI
+// wrote it and it is unchanged
+func (a *App) removeMCPServer(s config.MCPServer, toolID string) MCPApplyResult {
+	result := MCPApplyResult{ServerID: s.ID, ToolID: toolID, Action: "unchanged"}
+	t, err := a.mcpTarget(toolID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	key := mcpEntryKey(t.ConfigPath, s.Name)
+	owned, ok := a.mcpConfigManifest().Get(key)
+	if !ok || owned.Server != s.ID {
+		return result
+	}
+	servers, err := mcpconfig.Read(t.ConfigPath, t.Format)
+	if err != nil {
+		result.Error = fmt.Sprintf("读取 MCP 配置失败: %v", err)
+		return result
+	}
+	if existing, ok := servers[s.Name]; ok {
+		if mcpconfig.Hash(existing) != owned.Hash {
+			// 被手动修改的条目交还给用户
+			result.Action = "refused"
+			result.Reason = "写入后已被手动修改"
+		} else if _, err := mcpconfig.Remove(t.ConfigPath, t.Format, s.Name); err != nil {
+			result.Error = fmt.Sprintf("写入 MCP 配置失败: %v", err)
+			return result
+		} else {
+			result.Action = "removed"
+		}
+	}
+	if err := a.mcpConfigManifest().Delete(key); err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (a *App) recordMCPEntry(s config.MCPServer, toolID, key, hash string) error {
+	return a.mcpConfigManifest().Put(manifest.Entry{
+		Server: s.ID,
+		ToolID: toolID,
+		Path:   key,
+		Kind:   manifest.KindMCPServer,
+		Hash:   hash,
+	})
+}
+
+// mcpEntryKey is the manifest path of a server entry in a config file
+func mcpEntryKey(configPath, name string) string {
+	return configPath + "#" + name
+}
+
+// mcpConfigServer converts a managed server to what is written into tool configs
+func mcpConfigServer(s config.MCPServer) mcpconfig.Server {
+	return mcpconfig.Server{
+		Transport: s.Transport,
+		Command:   s.Command,
+		Args:      s.Args,
+		Env:       s.Env,
+		URL:       s.URL,
+		Headers:   s.Headers,
+	}
+}
+
+func (a *App) mcpServerIndex(id string) int {
+	for i, s := range a.config.MCPServers {
+		if s.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (a *App) mcpServerInfo(id string) MCPServerInfo {
+	for _, info := range a.ListMCPServers() {
+		if info.ID == id {
+			return info
+		}
+	}
+	return MCPServerInfo{}
+}
+
+// normalizeMCPServer validates a server, checks its name is unique and its tools support MCP configs
+func (a *App) normalizeMCPServer(s config.MCPServer) (config.MCPServer, error) {
+	if err := validateMCPServer(&s); err != nil {
+		return s, err
+	}
+	for _, existing := range a.config.MCPServers {
+		if existing.ID != s.ID && strings.EqualFold(existing.Name, s.Name) {
+			return s, fmt.Errorf("MCP 服务名称已存在: %s", s.Name)
+		}
+	}
+	seen := map[string]bool{}
+	tools := make([]string, 0, len(s.Tools))
+	for _, toolID := range s.Tools {
+		toolID = strings.TrimSpace(toolID)
+		if toolID == "" || seen[toolID] {
+			continue
+		}
+		if _, err := a.mcpTarget(toolID); err != nil {
+			return s, err
+		}
+		seen[toolID] = true
+		tools = append(tools, toolID)
+	}
+	sort.Strings(tools)
+	s.Tools = tools
+	return s, nil
+}
+
+// validateMCPServer trims a server and checks the fields of its transport
+func validateMCPServer(s *config.MCPServer) error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.Transport = strings.TrimSpace(s.Transport)
+	s.Command = strings.TrimSpace(s.Command)
+	s.URL = strings.TrimSpace(s.URL)
+	if !mcpServerNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("MCP 服务名称只能包含字母、数字、点、下划线和连字符: %q", s.Name)
+	}
+	if s.Transport == "" {
+		s.Transport = mcpconfig.TransportStdio
+	}
+	switch s.Transport {
+	case mcpconfig.TransportStdio:
+		if s.Command == "" {
+			return fmt.Errorf("stdio MCP 服务需要启动命令")
+		}
+		s.URL, s.Headers = "", nil
+	case mcpconfig.TransportHTTP:
+		u, err := url.Parse(s.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("无效的 MCP 服务地址: %s", s.URL)
+		}
+		s.Command, s.Args, s.Env = "", nil, nil
+	default:
+		return fmt.Errorf("未知的 MCP 传输方式: %s", s.Transport)
+	}
+	if s.Args == nil {
+		s.Args = []string{}
+	}
+	return nil
+}
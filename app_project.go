@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"skillui/internal/config"
+)
+
+// 项目级同步：登记项目根目录后，技能可同步到项目内各工具的规则目录
+// （如 <项目>/.cursor/rules、<项目>/.claude/skills），随仓库一起提交。
+// 以下方法的 projectID 为空时表示全局规则目录，与 SyncSkillToTools 等方法一致。
+
+// ListProjects returns all registered projects
+func (a *App) ListProjects() []config.Project {
+	if a.config.Projects == nil {
+		return []config.Project{}
+	}
+	return a.config.Projects
+}
+
+// AddProject registers a project root. The path must be an existing directory;
+// name defaults to the directory name. Registering the same path twice returns
+// the existing project.
+func (a *App) AddProject(path, name string) (config.Project, error) {
+	path = strings.TrimSpace(expandHome(path))
+	if path == "" {
+		return config.Project{}, fmt.Errorf("项目路径不能为空")
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return config.Project{}, fmt.Errorf("无效的项目路径: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return config.Project{}, fmt.Errorf("路径不存在: %s", abs)
+	}
+	if !info.IsDir() {
+		return config.Project{}, fmt.Errorf("路径不是目录: %s", abs)
+	}
+	for _, p := range a.config.Projects {
+		if p.Path == abs {
+			return p, nil
+		}
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = filepath.Base(abs)
+	}
+	project := config.Project{
+		ID:   "proj-" + uuid.NewString()[:8],
+		Name: name,
+		Path: abs,
+	}
+	a.config.Projects = append(a.config.Projects, project)
+	if err := a.store.Save(a.config); err != nil {
+		return config.Project{}, err
+	}
+	return project, nil
+}
+
+// RemoveProject unregisters a project. Files already synced into the project are kept.
+func (a *App) RemoveProject(id string) error {
+	projects := make([]config.Project, 0, len(a.config.Projects))
+	found := false
+	for _, p := range a.config.Projects {
+		if p.ID == id {
+			found = true
+			continue
+		}
+		projects = append(projects, p)
+	}
+	if !found {
+		return fmt.Errorf("项目不存在: %s", id)
+	}
+	a.config.Projects = projects
+	active := make([]config.CollectionActivation, 0, len(a.config.ActiveCollections))
+	for _, act := range a.config.ActiveCollections {
+		if act.ProjectID != id {
+			active = append(active, act)
+		}
+	}
+	a.config.ActiveCollections = active
+	return a.store.Save(a.config)
+}
+
+// projectRoot resolves a project ID to its root directory, "" for the global scope
+func (a *App) projectRoot(projectID string) (string, error) {
+	if projectID == "" {
+		return "", nil
+	}
+	for _, p := range a.config.Projects {
+		if p.ID == projectID {
+			if _, err := os.Stat(p.Path); err != nil {
+				return "", fmt.Errorf("项目目录不存在: %s", p.Path)
+			}
+			return p.Path, nil
+		}
+	}
+	return "", fmt.Errorf("项目不存在: %s", projectID)
+}
+
+// ListProjectTools returns the tools that support project-level rules, with
+// their rules dir resolved inside the project. Installed reports whether the
+// project already contains that tool's directory.
+func (a *App) ListProjectTools(projectID string) ([]IDEToolInfo, error) {
+	root, err := a.projectRoot(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if root == "" {
+		return a.ScanIDETools()
+	}
+	result := make([]IDEToolInfo, 0)
+	for _, def := range ideToolDefs(a.config.ToolPaths, a.config.CustomTools) {
+		rulesDir := def.rulesDirIn(root)
+		if rulesDir == "" {
+			continue
+		}
+		tool := IDEToolInfo{
+			ID:            def.ID,
+			Name:          def.Name,
+			SkillRulesDir: rulesDir,
+		}
+		// 项目内存在该工具的配置目录（如 .cursor）即视为项目已使用该工具
+		toolRoot := strings.SplitN(def.ProjectRulesDir, "/", 2)[0]
+		if _, err := os.Stat(filepath.Join(root, toolRoot)); err == nil {
+			tool.Installed = true
+			tool.Path = filepath.Join(root, toolRoot)
+		}
+		result = append(result, tool)
+	}
+	return result, nil
+}
+
+// SyncSkillToProject syncs a skill into the given tools' rules dirs of a
+// project (global rules dirs when projectID is empty)
+func (a *App) SyncSkillToProject(projectID, skillName string, toolIds []string) error {
+	root, err := a.projectRoot(projectID)
+	if err != nil {
+		return err
+	}
+	return a.syncSkillToTools(root, skillName, toolIds)
+}
+
+// UnsyncSkillFromProject removes a skill from the given tools' rules dirs of
+// a project (global rules dirs when projectID is empty)
+func (a *App) UnsyncSkillFromProject(projectID, skillName string, toolIds []string) error {
+	root, err := a.projectRoot(projectID)
+	if err != nil {
+		return err
+	}
+	return a.unsyncSkillFromTools(root, skillName, toolIds)
+}
+
+// DetectSyncedTools returns the IDs of the tools a skill is synced to in a
+// project (global rules dirs when projectID is empty)
+func (a *App) DetectSyncedTools(projectID, skillName string) ([]string, error) {
+	root, err := a.projectRoot(projectID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0)
+	for _, def := range syncedToolDefs(ideToolDefs(a.config.ToolPaths, a.config.CustomTools), root, skillName) {
+		ids = append(ids, def.ID)
+	}
+	return ids, nil
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"archive/zip"
-	"bufio"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/exec"
@@ -13,6 +16,10 @@ import (
 	goruntime "runtime"
 	"strings"
 	"time"
+
+	"skillui/internal/adapter"
+	"skillui/internal/config"
+	"skillui/internal/skill"
 )
 
 // SkillMeta represents metadata of an installed skill
@@ -26,13 +33,24 @@ type SkillMeta struct {
 	DescZh       string   `json:"descZh"`
 	Owner        string   `json:"owner"`
 	Version      string   `json:"version"`
+	License      string   `json:"license"`
 	Tags         []string `json:"tags"`
+	AllowedTools []string `json:"allowedTools"`
 	IsMarket     bool     `json:"isMarket"`
 	MarketID     int      `json:"marketId"`
-	SyncedTools  []string `json:"syncedTools"`
-	Location     string   `json:"location"`
-	UpdatedAt    string   `json:"updatedAt"`
-	SkillContent string   `json:"skillContent"`
+	Md5          string   `json:"md5"`
+	// Source 记录非市场来源（如 git 仓库）的安装信息，为空表示未知来源
+	Source       *SkillSource `json:"source"`
+	SyncedTools  []string     `json:"syncedTools"`
+	Location     string       `json:"location"`
+	UpdatedAt    string       `json:"updatedAt"`
+	SkillContent string       `json:"skillContent"`
+	// Extras 保留 frontmatter 中未被识别的字段（含嵌套结构）
+	Extras map[string]interface{} `json:"extras"`
+	// ParseError 记录 SKILL.md 解析失败的原因，为空表示解析成功
+	ParseError string `json:"parseError"`
+	// Diagnostics 为技能校验结果，前端据此标记有问题的技能
+	Diagnostics []skill.Diagnostic `json:"diagnostics"`
 }
 
 // IDEToolInfo represents an IDE or AI coding tool detected on the system
@@ -42,22 +60,51 @@ type IDEToolInfo struct {
 	Installed     bool   `json:"installed"`
 	Path          string `json:"path"`
 	SkillRulesDir string `json:"skillRulesDir"`
+	// Format 为同步到该工具时的输出格式
+	Format string `json:"format"`
+	// ResourceMode 为单文件格式携带资源文件的方式：none / inline / reference
+	ResourceMode string `json:"resourceMode"`
 	// Manual 标记该工具规则目录由用户手动指定
 	Manual bool `json:"manual"`
+	// Custom 标记用户自定义的工具
+	Custom bool `json:"custom"`
+	// Version 为检测到的已安装版本，VersionSource 为来源：binary（--version）/ metadata（应用元数据文件）
+	Version       string `json:"version"`
+	VersionSource string `json:"versionSource"`
+	// ReadDirs 为工具实际读取规则 / 技能且存在的目录
+	ReadDirs     []string         `json:"readDirs"`
+	Capabilities ToolCapabilities `json:"capabilities"`
+	// Hints 为界面提示（如版本过低导致同步格式降级）
+	Hints []string `json:"hints"`
 }
 
-// skillUIJson is the structure saved as skillui.json inside market-installed skills
+// skillUIJson is the structure saved as skillui.json inside market-, git- and url-installed skills
 type skillUIJson struct {
-	MarketID    int    `json:"marketId"`
-	Name        string `json:"name"`
-	TitleEn     string `json:"titleEn"`
-	TitleZh     string `json:"titleZh"`
-	DescEn      string `json:"descEn"`
-	DescZh      string `json:"descZh"`
-	Owner       string `json:"owner"`
+	MarketID int    `json:"marketId"`
+	Name     string `json:"name"`
+	TitleEn  string `json:"titleEn"`
+	TitleZh  string `json:"titleZh"`
+	DescEn   string `json:"descEn"`
+	DescZh   string `json:"descZh"`
+	Owner    string `json:"owner"`
+	Version  string `json:"version"`
+	// Md5 是下载的 ZIP 包哈希，TreeHash/Files 是安装后目录内容哈希（用于完整性校验）
+	Md5         string            `json:"md5"`
+	TreeHash    string            `json:"treeHash,omitempty"`
+	Files       map[string]string `json:"files,omitempty"`
+	InstalledAt string            `json:"installedAt"`
+	// History 记录升级前的历史版本，最新的在最后
+	History []skillUIHistory `json:"history,omitempty"`
+	// Source 记录 git、URL 等非市场来源，用于后续拉取更新与锁定文件
+	Source *SkillSource `json:"source,omitempty"`
+}
+
+// skillUIHistory is one previously installed version of a market skill
+type skillUIHistory struct {
 	Version     string `json:"version"`
 	Md5         string `json:"md5"`
 	InstalledAt string `json:"installedAt"`
+	ReplacedAt  string `json:"replacedAt"`
 }
 
 // ideToolDef defines detection rules for an IDE tool per platform
@@ -70,8 +117,30 @@ type ideToolDef struct {
 	RulesDirMac   string
 	RulesDirWin   string
 	RulesDirLin   string
+	// Binaries 为在 PATH 中查找的命令名（CLI 工具）
+	Binaries []string
+	// Format 为同步时使用的输出格式（见 internal/adapter），为空表示原样链接 SKILL.md
+	Format string
+	// ProjectRulesDir 为项目级规则目录（相对项目根目录，/ 分隔），为空表示该工具不支持项目级同步
+	ProjectRulesDir string
+	// FileName 为文件名模式（{name} 替换为技能名），为空使用输出格式的默认文件名
+	FileName string
 	// ManualRulesDir 用户手动指定的规则目录（覆盖默认 RulesDir）
 	ManualRulesDir string
+	// Custom 标记用户自定义的工具
+	Custom bool
+	// VersionArgs / VersionFiles 用于检测已安装版本（见 app_tools_detect.go）
+	VersionArgs  []string
+	VersionFiles []string
+	// ReadDirs 为工具除规则目录外还会读取规则 / 技能的目录
+	ReadDirs []string
+	// LegacyRulesDirs 为旧版本同步过 {name}.md 的全局规则目录，仅用于检测和清理
+	LegacyRulesDirs []string
+	// Capabilities 为能力 -> 最低版本（空表示所有版本）
+	Capabilities map[string]string
+	// FallbackFrom 为已安装版本不支持而被降级前的输出格式，FallbackMinVersion 为该格式所需的最低版本
+	FallbackFrom       string
+	FallbackMinVersion string
 }
 
 // expandHome replaces leading ~ with the user's home directory
@@ -116,28 +185,35 @@ func (a *App) SetAutoSyncToolIDs(ids []string) error {
 	return a.store.Save(a.config)
 }
 
-// syncToInstalledTools syncs a skill to all tools that are installed AND have auto-sync enabled
-func (a *App) syncToInstalledTools(skillName string) {
+// syncToInstalledTools syncs a skill to all tools that are installed AND have auto-sync enabled.
+// Skills that fail validation are skipped (logged) rather than failing the install.
+func (a *App) syncToInstalledTools(skillName string) error {
 	autoIDs := map[string]bool{}
 	for _, id := range a.config.AutoSyncToolIDs {
 		autoIDs[id] = true
 	}
 	if len(autoIDs) == 0 {
-		return
+		return nil
+	}
+	if err := checkSkillValid(filepath.Join(a.getSkillDir(), skillName)); err != nil {
+		a.LogSystemError("syncToInstalledTools", fmt.Sprintf("Skip auto-sync of skill %s: %v", skillName, err))
+		return nil
 	}
 	scanned, err := a.ScanIDETools()
 	if err != nil {
-		return
+		return err
 	}
 	targets := make([]string, 0)
 	for _, t := range scanned {
-		if t.Installed && autoIDs[t.ID] {
+		// 激活了技能集合的工具只同步集合内的技能
+		if t.Installed && autoIDs[t.ID] && a.collectionAllows(t.ID, skillName) {
 			targets = append(targets, t.ID)
 		}
 	}
 	if len(targets) > 0 {
-		_ = a.SyncSkillToTools(skillName, targets)
+		return a.SyncSkillToTools(skillName, targets)
 	}
+	return nil
 }
 
 // GetSkillDir returns the current skill directory to the frontend
@@ -160,7 +236,13 @@ func (a *App) SetSkillDir(newDir string, migrate bool) error {
 		}
 	}
 	a.config.SkillDir = newDir
-	return a.store.Save(a.config)
+	if err := a.store.Save(a.config); err != nil {
+		return err
+	}
+	if a.ctx != nil {
+		a.startSkillWatcher()
+	}
+	return nil
 }
 
 // moveDir moves all immediate subdirectories from src to dst
@@ -191,16 +273,32 @@ func moveDir(src, dst string) error {
 
 // copyDir recursively copies a directory
 func copyDir(src, dst string) error {
+	return copyDirFiltered(src, dst, nil)
+}
+
+// copyDirFiltered recursively copies a directory, leaving out the entries
+// (and everything below them) for which skip returns true
+func copyDirFiltered(src, dst string, skip func(rel string, d fs.DirEntry) bool) error {
 	if err := os.MkdirAll(dst, 0755); err != nil {
 		return err
 	}
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		rel, _ := filepath.Rel(src, path)
+		if path != src && skip != nil && skip(filepath.ToSlash(rel), d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		dstPath := filepath.Join(dst, rel)
-		if info.IsDir() {
+		if d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
 			return os.MkdirAll(dstPath, info.Mode())
 		}
 		return copyFile(path, dstPath)
@@ -226,57 +324,44 @@ func copyFile(src, dst string) error {
 	return err
 }
 
-// parseSkillMeta reads SKILL.md frontmatter and optionally skillui.json from a skill directory
+// parseSkillMeta reads SKILL.md frontmatter and optionally skillui.json from a skill directory.
+// Frontmatter errors are reported in ParseError; the frontmatter fields are then left empty.
 func parseSkillMeta(dir string) SkillMeta {
 	meta := SkillMeta{
 		Name:     filepath.Base(dir),
 		Location: dir,
+		Tags:     []string{},
+		Extras:   map[string]interface{}{},
 	}
 
 	// Parse SKILL.md frontmatter
 	skillFile := filepath.Join(dir, "SKILL.md")
-	if f, err := os.Open(skillFile); err == nil {
-		defer f.Close()
-		scanner := bufio.NewScanner(f)
-		inFrontmatter := false
-		firstLine := true
-		for scanner.Scan() {
-			line := scanner.Text()
-			if firstLine {
-				firstLine = false
-				if line == "---" {
-					inFrontmatter = true
-					continue
-				}
-				break
+	if data, err := os.ReadFile(skillFile); err == nil {
+		meta.SkillContent = string(data)
+		doc, err := skill.Parse(data)
+		if err != nil {
+			// 没有 frontmatter 的纯 Markdown 技能仍然可用，不视为解析错误
+			if !errors.Is(err, skill.ErrNoFrontmatter) {
+				meta.ParseError = err.Error()
 			}
-			if inFrontmatter {
-				if line == "---" {
-					break
-				}
-				if idx := strings.Index(line, ":"); idx > 0 {
-					key := strings.TrimSpace(line[:idx])
-					val := strings.TrimSpace(line[idx+1:])
-					val = strings.Trim(val, "'\"")
-					switch key {
-					case "name":
-						meta.Name = val
-					case "title":
-						meta.Title = val
-					case "description":
-						meta.Description = val
-					case "owner":
-						meta.Owner = val
-					case "version":
-						meta.Version = val
-					}
-				}
+		} else {
+			fm := doc.Metadata
+			if fm.Name != "" {
+				meta.Name = fm.Name
 			}
+			meta.Title = fm.Title
+			meta.Description = fm.Description
+			meta.Owner = fm.Owner
+			meta.Version = fm.Version
+			meta.License = fm.License
+			if len(fm.Tags) > 0 {
+				meta.Tags = fm.Tags
+			}
+			meta.AllowedTools = fm.AllowedTools
+			meta.Extras = fm.Extras
 		}
-	}
-	// Read full SKILL.md content
-	if data, err := os.ReadFile(skillFile); err == nil {
-		meta.SkillContent = string(data)
+	} else {
+		meta.ParseError = fmt.Sprintf("无法读取 SKILL.md: %v", err)
 	}
 
 	// Fallback: use dir name as title if empty
@@ -289,8 +374,10 @@ func parseSkillMeta(dir string) SkillMeta {
 	if data, err := os.ReadFile(skillUIFile); err == nil {
 		var sj skillUIJson
 		if json.Unmarshal(data, &sj) == nil {
-			meta.IsMarket = true
+			meta.IsMarket = sj.MarketID > 0
+			meta.Source = sj.Source
 			meta.MarketID = sj.MarketID
+			meta.Md5 = sj.Md5
 			meta.TitleEn = sj.TitleEn
 			meta.TitleZh = sj.TitleZh
 			meta.DescEn = sj.DescEn
@@ -312,42 +399,87 @@ func parseSkillMeta(dir string) SkillMeta {
 	return meta
 }
 
-// ListLocalSkills scans the skill directory and returns all installed skills
+// ListLocalSkills returns all installed skills. While the skill directory
+// watcher runs this is a read of the in-memory index (see app_skill_index.go).
 func (a *App) ListLocalSkills() ([]SkillMeta, error) {
-	skillDir := a.getSkillDir()
-	if err := os.MkdirAll(skillDir, 0755); err != nil {
-		return nil, err
-	}
-	entries, err := os.ReadDir(skillDir)
+	skills, err := a.indexedSkills()
 	if err != nil {
 		return nil, err
 	}
-	skills := make([]SkillMeta, 0)
-	for _, entry := range entries {
-		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
-			continue
-		}
-		dir := filepath.Join(skillDir, entry.Name())
-		skill := parseSkillMeta(dir)
+	for i := range skills {
 		// Detect synced tools
-		skill.SyncedTools = detectSyncedTools(entry.Name(), a.getSkillDir(), a.config.ToolPaths)
-		skills = append(skills, skill)
+		skills[i].SyncedTools = detectSyncedTools(filepath.Base(skills[i].Location), a.config.ToolPaths, a.config.CustomTools)
 	}
 	return skills, nil
 }
 
 // InstallSkillFromUrl downloads a zip from the given URL and installs it
 func (a *App) InstallSkillFromUrl(url, name string) error {
-	skillDir := a.getSkillDir()
-	if err := os.MkdirAll(skillDir, 0755); err != nil {
+	result, err := a.InstallSkillFromUrlWithPolicy(url, name, "")
+	if err != nil {
 		return err
 	}
-	// 将临时文件创建在 skillDir 下（~/.skillui/skills），该目录在 macOS App Sandbox
-	// 的授权范围内，避免用 os.TempDir()（/var/folders/...）触发 EPERM。
-	// 写完后直接 Seek 回头部复用同一文件描述符，无需重新 open 路径。
-	tmpFile, err := os.CreateTemp(skillDir, ".tmp-skill-*.zip")
+	return result.err()
+}
+
+// InstallSkillFromUrlWithPolicy installs a zip from url, resolving a name
+// collision with the given conflict policy (empty = configured default)
+func (a *App) InstallSkillFromUrlWithPolicy(url, name, policy string) (InstallResult, error) {
+	p, err := a.parseConflictPolicy(policy)
 	if err != nil {
-		return fmt.Errorf("创建临时文件失败: %w", err)
+		return InstallResult{}, err
+	}
+	return a.installSkillFromUrl(url, "", skillInstallRequest{Name: name, Source: url, Policy: p}, func(staged, archiveMd5 string) error {
+		return writeURLSkillUIJson(staged, name, url, archiveMd5)
+	}), nil
+}
+
+// writeURLSkillUIJson records the download url and hashes of a skill installed from a zip url
+func writeURLSkillUIJson(staged, name, url, archiveMd5 string) error {
+	tree, err := skill.HashTree(staged)
+	if err != nil {
+		return fmt.Errorf("计算技能哈希失败: %w", err)
+	}
+	doc, _ := skill.ParseFile(filepath.Join(staged, "SKILL.md"))
+	return writeSkillUIJson(staged, skillUIJson{
+		Name:        name,
+		Owner:       doc.Metadata.Owner,
+		Version:     doc.Metadata.Version,
+		Md5:         archiveMd5,
+		TreeHash:    tree.Hash,
+		Files:       tree.Files,
+		InstalledAt: time.Now().Format(skillUITimeLayout),
+		Source:      &SkillSource{Type: SourceTypeURL, URL: url},
+	})
+}
+
+// installSkillFromUrl downloads and installs a zip, verifying it against
+// expectedMd5 when given. prepare (optional) receives the archive md5 and can
+// add files such as skillui.json to the staged skill before it is swapped in.
+func (a *App) installSkillFromUrl(url, expectedMd5 string, req skillInstallRequest, prepare func(staged, archiveMd5 string) error) InstallResult {
+	req.Fill = func(staged string) error {
+		sum, err := downloadAndExtract(url, filepath.Dir(staged), staged, expectedMd5)
+		if err != nil {
+			return err
+		}
+		if prepare != nil {
+			return prepare(staged, sum)
+		}
+		return nil
+	}
+	return a.installSkill(req)
+}
+
+// downloadAndExtract downloads a zip archive and extracts it into destDir.
+// The archive md5 is returned and, when expectedMd5 is not empty, checked
+// before anything is extracted.
+// 将临时文件创建在 tmpDir（技能目录）下，该目录在 macOS App Sandbox
+// 的授权范围内，避免用 os.TempDir()（/var/folders/...）触发 EPERM。
+// 写完后直接 Seek 回头部复用同一文件描述符，无需重新 open 路径。
+func downloadAndExtract(url, tmpDir, destDir, expectedMd5 string) (string, error) {
+	tmpFile, err := os.CreateTemp(tmpDir, ".tmp-skill-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %w", err)
 	}
 	tmpPath := tmpFile.Name()
 	defer os.Remove(tmpPath)
@@ -355,18 +487,25 @@ func (a *App) InstallSkillFromUrl(url, name string) error {
 
 	resp, err := http.Get(url)
 	if err != nil {
-		return fmt.Errorf("下载失败: %w", err)
+		return "", fmt.Errorf("下载失败: %w", err)
 	}
 	defer resp.Body.Close()
-	size, err := io.Copy(tmpFile, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("下载失败: HTTP %d", resp.StatusCode)
+	}
+	hasher := md5.New()
+	size, err := io.Copy(io.MultiWriter(tmpFile, hasher), resp.Body)
 	if err != nil {
-		return fmt.Errorf("写入临时文件失败: %w", err)
+		return "", fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	if expectedMd5 != "" && !strings.EqualFold(expectedMd5, sum) {
+		return "", fmt.Errorf("文件校验失败: 期望 MD5 %s，实际 %s", expectedMd5, sum)
 	}
 	if _, err = tmpFile.Seek(0, io.SeekStart); err != nil {
-		return fmt.Errorf("读取临时文件失败: %w", err)
+		return "", fmt.Errorf("读取临时文件失败: %w", err)
 	}
-	destDir := filepath.Join(skillDir, name)
-	return extractZipReader(tmpFile, size, destDir)
+	return sum, extractZipReader(tmpFile, size, destDir)
 }
 
 // findSkillDirs recursively walks rootDir and returns all directories containing
@@ -392,156 +531,229 @@ func findSkillDirs(rootDir string) ([]string, error) {
 	return skillDirs, err
 }
 
-// InstallSkillFromGit clones a git repository and installs all detected skills (directories containing SKILL.md)
-func (a *App) InstallSkillFromGit(repoUrl string) ([]string, error) {
-	// Verify git is available
-	if _, err := exec.LookPath("git"); err != nil {
-		return nil, fmt.Errorf("未找到 git 命令，请先安装 Git")
-	}
-
-	// Create temp dir for clone
-	tmpDir, err := os.MkdirTemp("", "skill-git-*")
-	if err != nil {
-		return nil, err
-	}
-	defer os.RemoveAll(tmpDir)
-
-	// git clone --depth=1 <repoUrl> <tmpDir>
-	cmd := exec.Command("git", "clone", "--depth=1", repoUrl, tmpDir)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("git clone 失败: %w\n%s", err, strings.TrimSpace(string(output)))
-	}
-
-	skillDir := a.getSkillDir()
-	if err := os.MkdirAll(skillDir, 0755); err != nil {
-		return nil, err
-	}
-
-	var installed []string
-
-	// Recursively find all directories containing SKILL.md
-	skillPaths, err := findSkillDirs(tmpDir)
+// InstallSkillFromMarket downloads a zip and writes skillui.json metadata.
+// meta.Md5 is the archive hash from the market download endpoint; when present
+// the download is verified against it.
+func (a *App) InstallSkillFromMarket(url string, meta SkillMeta) error {
+	policy, err := a.parseConflictPolicy("")
 	if err != nil {
-		return nil, fmt.Errorf("扫描仓库失败: %w", err)
+		return err
 	}
-
-	for _, subDir := range skillPaths {
-		name := filepath.Base(subDir)
-		// If the repo root itself is detected, use the repo name
-		if subDir == tmpDir {
-			name = filepath.Base(strings.TrimSuffix(repoUrl, ".git"))
-		}
-		destDir := filepath.Join(skillDir, name)
-		if err := copyDir(subDir, destDir); err != nil {
-			continue
-		}
-		installed = append(installed, name)
+	// 同一个市场技能重复安装视为覆盖更新，只有与其他技能重名时才应用冲突策略
+	if existing, err := readSkillUIJson(filepath.Join(a.getSkillDir(), meta.Name)); err == nil && existing.MarketID == meta.MarketID {
+		policy = ConflictOverwrite
 	}
-
-	if len(installed) == 0 {
-		return nil, fmt.Errorf("未在仓库中找到任何有效技能（含 SKILL.md 的目录）")
+	req := skillInstallRequest{
+		Name:   meta.Name,
+		Source: url,
+		Owner:  meta.Owner,
+		Policy: policy,
 	}
-
-	// Auto-sync each installed skill
-	for _, name := range installed {
-		if len(a.config.AutoSyncToolIDs) > 0 {
-			a.syncToInstalledTools(name)
+	result := a.installSkillFromUrl(url, meta.Md5, req, func(staged, archiveMd5 string) error {
+		tree, err := skill.HashTree(staged)
+		if err != nil {
+			return fmt.Errorf("计算技能哈希失败: %w", err)
 		}
-	}
-
-	return installed, nil
-}
-
-// InstallSkillFromMarket downloads a zip and writes skillui.json metadata
-func (a *App) InstallSkillFromMarket(url string, meta SkillMeta) error {
-	if err := a.InstallSkillFromUrl(url, meta.Name); err != nil {
-		return err
-	}
-	// Write skillui.json
-	sj := skillUIJson{
-		MarketID:    meta.MarketID,
-		Name:        meta.Name,
-		TitleEn:     meta.TitleEn,
-		TitleZh:     meta.TitleZh,
-		DescEn:      meta.DescEn,
-		DescZh:      meta.DescZh,
-		Owner:       meta.Owner,
-		Version:     meta.Version,
-		InstalledAt: time.Now().Format("2006-01-02T15:04:05Z"),
-	}
-	data, err := json.MarshalIndent(sj, "", "  ")
-	if err != nil {
-		return err
-	}
-	destDir := filepath.Join(a.getSkillDir(), meta.Name)
-	if err := os.WriteFile(filepath.Join(destDir, "skillui.json"), data, 0644); err != nil {
+		// Write skillui.json
+		return writeSkillUIJson(staged, skillUIJson{
+			MarketID:    meta.MarketID,
+			Name:        meta.Name,
+			TitleEn:     meta.TitleEn,
+			TitleZh:     meta.TitleZh,
+			DescEn:      meta.DescEn,
+			DescZh:      meta.DescZh,
+			Owner:       meta.Owner,
+			Version:     meta.Version,
+			Md5:         archiveMd5,
+			TreeHash:    tree.Hash,
+			Files:       tree.Files,
+			InstalledAt: time.Now().Format(skillUITimeLayout),
+		})
+	})
+	if err := result.err(); err != nil {
 		return err
 	}
-	// Auto-sync to configured IDE tools if any
-	if len(a.config.AutoSyncToolIDs) > 0 {
-		a.syncToInstalledTools(meta.Name)
+	// 安装完成后再自动同步：回滚只恢复技能目录，同步中途失败时已写入其他工具的文件会与旧版本不一致，因此只报告错误
+	if err := a.syncToInstalledTools(result.Name); err != nil {
+		a.LogSystemError("InstallSkillFromMarket", fmt.Sprintf("Failed to auto-sync skill %s: %v", result.Name, err))
+		return fmt.Errorf("技能已安装，但自动同步失败: %w", err)
 	}
 	return nil
 }
 
 // InstallSkillFromLocalPath installs a skill from a local directory or file path
 func (a *App) InstallSkillFromLocalPath(srcPath string) error {
-	skillDir := a.getSkillDir()
-	if err := os.MkdirAll(skillDir, 0755); err != nil {
+	result, err := a.InstallSkillFromLocalPathWithPolicy(srcPath, "")
+	if err != nil {
 		return err
 	}
+	return result.err()
+}
+
+// InstallSkillFromLocalPathWithPolicy installs a local directory or zip,
+// resolving a name collision with the given conflict policy
+func (a *App) InstallSkillFromLocalPathWithPolicy(srcPath, policy string) (InstallResult, error) {
+	p, err := a.parseConflictPolicy(policy)
+	if err != nil {
+		return InstallResult{}, err
+	}
 	name := filepath.Base(srcPath)
 	// strip extension for zip/tar files
 	name = strings.TrimSuffix(name, ".zip")
 	name = strings.TrimSuffix(name, ".tar.gz")
 
 	info, err := os.Stat(srcPath)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	return a.installSkill(skillInstallRequest{
+		Name:   name,
+		Source: srcPath,
+		Owner:  filepath.Base(filepath.Dir(srcPath)),
+		Policy: p,
+		Fill: func(staged string) error {
+			if info.IsDir() {
+				return copyDir(srcPath, staged)
+			}
+			// treat as zip
+			return extractZip(srcPath, staged)
+		},
+	}), nil
+}
+
+// InstallSkillFromText creates a skill from pasted markdown text
+func (a *App) InstallSkillFromText(name, content string) error {
+	result, err := a.InstallSkillFromTextWithPolicy(name, content, "")
 	if err != nil {
 		return err
 	}
-	destDir := filepath.Join(skillDir, name)
+	return result.err()
+}
+
+// InstallSkillFromTextWithPolicy creates a skill from markdown text,
+// resolving a name collision with the given conflict policy
+func (a *App) InstallSkillFromTextWithPolicy(name, content, policy string) (InstallResult, error) {
+	p, err := a.parseConflictPolicy(policy)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	return a.installSkill(skillInstallRequest{
+		Name:   name,
+		Source: "text",
+		Policy: p,
+		Fill: func(staged string) error {
+			if err := os.MkdirAll(staged, 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(staged, "SKILL.md"), []byte(content), 0644)
+		},
+	}), nil
+}
+
+// Install source types accepted by InstallSkillFromSource
+const (
+	InstallFromGit  = "git"
+	InstallFromURL  = "url"
+	InstallFromPath = "path"
+)
 
-	if info.IsDir() {
-		return copyDir(srcPath, destDir)
+// InstallSourceOptions describe an install from a git repository, a zip URL or a local path
+type InstallSourceOptions struct {
+	Source string `json:"source"`
+	// From 为 git / url / path，为空时按 Source 自动判断
+	From string `json:"from"`
+	// Name 为 URL 安装的技能名，为空时取 ZIP 文件名
+	Name string `json:"name"`
+	// Policy 为同名技能冲突处理策略，为空时使用配置中的默认策略
+	Policy string `json:"policy"`
+	// Ref / Subpath 仅对 git 来源有效
+	Ref     string `json:"ref"`
+	Subpath string `json:"subpath"`
+}
+
+// InstallSkillFromSource installs from whatever the source is: an existing
+// local path, a zip URL or (otherwise) a git repository. It is the entry
+// point of the command line and the local API.
+func (a *App) InstallSkillFromSource(opts InstallSourceOptions) ([]InstallResult, error) {
+	source := strings.TrimSpace(opts.Source)
+	if source == "" {
+		return nil, fmt.Errorf("安装来源不能为空")
+	}
+	from := opts.From
+	if from == "" {
+		from = guessInstallSource(source)
+	}
+	switch from {
+	case InstallFromPath:
+		r, err := a.InstallSkillFromLocalPathWithPolicy(expandHome(source), opts.Policy)
+		if err != nil {
+			return nil, err
+		}
+		return []InstallResult{r}, nil
+	case InstallFromURL:
+		name := opts.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(strings.SplitN(source, "?", 2)[0]), ".zip")
+		}
+		r, err := a.InstallSkillFromUrlWithPolicy(source, name, opts.Policy)
+		if err != nil {
+			return nil, err
+		}
+		return []InstallResult{r}, nil
+	case InstallFromGit:
+		return a.InstallSkillsFromGit(GitInstallOptions{RepoURL: source, Ref: opts.Ref, Subpath: opts.Subpath, Policy: opts.Policy})
 	}
-	// treat as zip
-	return extractZip(srcPath, destDir)
+	return nil, fmt.Errorf("未知的安装来源类型: %s（可选 git / url / path）", from)
 }
 
-// InstallSkillFromText creates a skill from pasted markdown text
-func (a *App) InstallSkillFromText(name, content string) error {
-	skillDir := a.getSkillDir()
-	destDir := filepath.Join(skillDir, name)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return err
+// guessInstallSource picks the install source type of an argument: an
+// existing local path, a zip URL, otherwise a git repository
+func guessInstallSource(source string) string {
+	if _, err := os.Stat(expandHome(source)); err == nil {
+		return InstallFromPath
+	}
+	lower := strings.ToLower(strings.SplitN(source, "?", 2)[0])
+	if (strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")) && strings.HasSuffix(lower, ".zip") {
+		return InstallFromURL
 	}
-	return os.WriteFile(filepath.Join(destDir, "SKILL.md"), []byte(content), 0644)
+	return InstallFromGit
 }
 
 // DeleteSkill removes a skill directory and cleans up all synced tool files
 func (a *App) DeleteSkill(name string) error {
-	// Remove synced files from all IDE tool rules directories
-	defs := ideToolDefs(a.config.ToolPaths)
-	for _, def := range defs {
-		rulesDir := def.getRulesDir()
-		if rulesDir == "" {
-			continue
-		}
-		// Remove skillName.md symlink/file
-		skillFile := filepath.Join(rulesDir, name+".md")
-		if _, err := os.Lstat(skillFile); err == nil {
-			os.Remove(skillFile)
-		}
-		// Remove skillName dir/link (fallback path)
-		skillLink := filepath.Join(rulesDir, name)
-		if _, err := os.Lstat(skillLink); err == nil {
-			os.RemoveAll(skillLink)
+	report, err := a.DeleteSkillWithReport(name)
+	if err == nil && len(report.Refused) > 0 {
+		a.LogSystemError("DeleteSkill", fmt.Sprintf("Kept files not created by SkillUI for skill %s: %s", name, report.refusedSummary()))
+	}
+	return err
+}
+
+// DeleteSkillWithReport removes a skill directory and the files SkillUI synced
+// for it (global and registered projects). Files with the skill's name that
+// SkillUI did not create, or that were modified after syncing, are kept and
+// listed in the report.
+func (a *App) DeleteSkillWithReport(name string) (SyncRemovalReport, error) {
+	if err := validateSkillName(name); err != nil {
+		return SyncRemovalReport{}, err
+	}
+	report := newSyncRemovalReport()
+	defs := ideToolDefs(a.config.ToolPaths, a.config.CustomTools)
+	roots := []string{""}
+	for _, p := range a.config.Projects {
+		roots = append(roots, p.Path)
+	}
+	for _, root := range roots {
+		for _, def := range defs {
+			if err := a.removeSynced(root, name, def, false, &report); err != nil {
+				a.LogSystemError("DeleteSkill", fmt.Sprintf("Failed to remove synced files of %s from %s: %v", name, def.Name, err))
+			}
 		}
 	}
 
 	skillDir := filepath.Join(a.getSkillDir(), name)
-	return os.RemoveAll(skillDir)
+	err := os.RemoveAll(skillDir)
+	a.reindexSkills([]string{name})
+	return report, err
 }
 
 // extractZip extracts a zip file to destDir, auto-handling single-root nesting
@@ -637,454 +849,40 @@ func extractZip(zipPath, destDir string) error {
 	return extractZipReader(f, info.Size(), destDir)
 }
 
-// ideToolDefs returns the list of known IDE tools with per-platform detection rules.
-// manualPaths (toolID -> user-specified rules dir) overrides the default rules dir.
-func ideToolDefs(manualPaths map[string]string) []ideToolDef {
-	home, _ := os.UserHomeDir()
+// ideToolDefs returns the tool definitions of the current registry (see
+// app_tools.go) resolved for this machine followed by the user's custom
+// tools, with the user's manual rules dirs applied
+func ideToolDefs(manualPaths map[string]string, custom []config.CustomTool) []ideToolDef {
 	if manualPaths == nil {
 		manualPaths = map[string]string{}
 	}
-	defs := []ideToolDef{
-		{
-			ID:   "cursor",
-			Name: "Cursor",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".cursor"),
-				"/Applications/Cursor.app",
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "cursor", "Cursor.exe"),
-				filepath.Join(os.Getenv("APPDATA"), "Cursor"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".config", "Cursor"),
-				"/usr/share/applications/cursor.desktop",
-			},
-			RulesDirMac: filepath.Join(home, ".cursor", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "Cursor", "User", "rules"),
-			RulesDirLin: filepath.Join(home, ".config", "Cursor", "User", "rules"),
-		},
-		{
-			ID:            "claude_code",
-			Name:          "Claude Code",
-			CheckPathsMac: []string{},
-			CheckPathsWin: []string{},
-			CheckPathsLin: []string{},
-			RulesDirMac:   filepath.Join(home, ".claude", "commands"),
-			RulesDirWin:   filepath.Join(os.Getenv("APPDATA"), ".claude", "commands"),
-			RulesDirLin:   filepath.Join(home, ".claude", "commands"),
-		},
-		{
-			ID:   "windsurf",
-			Name: "Windsurf",
-			CheckPathsMac: []string{
-				"/Applications/Windsurf.app",
-				filepath.Join(home, "Library", "Application Support", "Windsurf"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "windsurf", "Windsurf.exe"),
-				filepath.Join(os.Getenv("APPDATA"), "Windsurf"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".config", "Windsurf"),
-				"/usr/share/applications/windsurf.desktop",
-			},
-			RulesDirMac: filepath.Join(home, ".codeium", "windsurf", "memories"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "Codeium", "windsurf", "memories"),
-			RulesDirLin: filepath.Join(home, ".codeium", "windsurf", "memories"),
-		},
-		{
-			ID:   "trae",
-			Name: "TRAE IDE",
-			CheckPathsMac: []string{
-				"/Applications/Trae.app",
-				filepath.Join(home, "Library", "Application Support", "Trae"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "trae", "Trae.exe"),
-				filepath.Join(os.Getenv("APPDATA"), "Trae"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".config", "Trae"),
-			},
-			RulesDirMac: filepath.Join(home, "Library", "Application Support", "Trae", "User", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "Trae", "User", "rules"),
-			RulesDirLin: filepath.Join(home, ".config", "Trae", "User", "rules"),
-		},
-		{
-			ID:   "zed",
-			Name: "Zed",
-			CheckPathsMac: []string{
-				"/Applications/Zed.app",
-				filepath.Join(home, "Library", "Application Support", "Zed"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "Zed"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".config", "zed"),
-			},
-			RulesDirMac: filepath.Join(home, "Library", "Application Support", "Zed", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "Zed", "rules"),
-			RulesDirLin: filepath.Join(home, ".config", "zed", "rules"),
-		},
-		{
-			ID:   "kilo_code",
-			Name: "Kilo Code",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".kilo"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "Kilo"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".kilo"),
-			},
-			RulesDirMac: filepath.Join(home, ".kilo", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "Kilo", "rules"),
-			RulesDirLin: filepath.Join(home, ".kilo", "rules"),
-		},
-		{
-			ID:   "roo_code",
-			Name: "Roo Code",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".roo"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "Roo"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".roo"),
-			},
-			RulesDirMac: filepath.Join(home, ".roo", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "Roo", "rules"),
-			RulesDirLin: filepath.Join(home, ".roo", "rules"),
-		},
-		{
-			ID:   "goose",
-			Name: "Goose",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".config", "goose"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "goose"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".config", "goose"),
-			},
-			RulesDirMac: filepath.Join(home, ".config", "goose", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "goose", "rules"),
-			RulesDirLin: filepath.Join(home, ".config", "goose", "rules"),
-		},
-		{
-			ID:            "gemini_cli",
-			Name:          "Gemini CLI",
-			CheckPathsMac: []string{},
-			CheckPathsWin: []string{},
-			CheckPathsLin: []string{},
-			RulesDirMac:   filepath.Join(home, ".gemini", "rules"),
-			RulesDirWin:   filepath.Join(os.Getenv("APPDATA"), "gemini", "rules"),
-			RulesDirLin:   filepath.Join(home, ".gemini", "rules"),
-		},
-		{
-			ID:   "github_copilot",
-			Name: "GitHub Copilot",
-			CheckPathsMac: []string{
-				filepath.Join(home, "Library", "Application Support", "GitHub Copilot"),
-				filepath.Join(home, ".config", "github-copilot"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "GitHub Copilot"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".config", "github-copilot"),
-			},
-			RulesDirMac: filepath.Join(home, ".github", "copilot", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("USERPROFILE"), ".github", "copilot", "rules"),
-			RulesDirLin: filepath.Join(home, ".github", "copilot", "rules"),
-		},
-		{
-			ID:   "opencode",
-			Name: "OpenCode",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".config", "opencode"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "opencode"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".config", "opencode"),
-			},
-			RulesDirMac: filepath.Join(home, ".config", "opencode", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "opencode", "rules"),
-			RulesDirLin: filepath.Join(home, ".config", "opencode", "rules"),
-		},
-		{
-			ID:   "amp",
-			Name: "Amp",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".amp"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "Amp"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".amp"),
-			},
-			RulesDirMac: filepath.Join(home, ".amp", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "Amp", "rules"),
-			RulesDirLin: filepath.Join(home, ".amp", "rules"),
-		},
-		{
-			ID:            "codex",
-			Name:          "Codex",
-			CheckPathsMac: []string{},
-			CheckPathsWin: []string{},
-			CheckPathsLin: []string{},
-			RulesDirMac:   filepath.Join(home, ".codex", "rules"),
-			RulesDirWin:   filepath.Join(os.Getenv("APPDATA"), "codex", "rules"),
-			RulesDirLin:   filepath.Join(home, ".codex", "rules"),
-		},
-		{
-			ID:   "amazon_q",
-			Name: "Amazon Q",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".aws", "amazonq"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("USERPROFILE"), ".aws", "amazonq"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".aws", "amazonq"),
-			},
-			RulesDirMac: filepath.Join(home, ".aws", "amazonq", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("USERPROFILE"), ".aws", "amazonq", "rules"),
-			RulesDirLin: filepath.Join(home, ".aws", "amazonq", "rules"),
-		},
-		{
-			ID:   "cline",
-			Name: "Cline",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".cline"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "cline"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".cline"),
-			},
-			RulesDirMac: filepath.Join(home, ".cline", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "cline", "rules"),
-			RulesDirLin: filepath.Join(home, ".cline", "rules"),
-		},
-		{
-			ID:   "antigravity",
-			Name: "Antigravity",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".antigravity"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "antigravity"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".antigravity"),
-			},
-			RulesDirMac: filepath.Join(home, ".antigravity", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "antigravity", "rules"),
-			RulesDirLin: filepath.Join(home, ".antigravity", "rules"),
-		},
-		{
-			ID:   "qoder",
-			Name: "Qoder",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".qoder"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "qoder"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".qoder"),
-			},
-			RulesDirMac: filepath.Join(home, ".qoder", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "qoder", "rules"),
-			RulesDirLin: filepath.Join(home, ".qoder", "rules"),
-		},
-		{
-			ID:            "auggie_cli",
-			Name:          "Auggie CLI",
-			CheckPathsMac: []string{},
-			CheckPathsWin: []string{},
-			CheckPathsLin: []string{},
-			RulesDirMac:   filepath.Join(home, ".augment", "rules"),
-			RulesDirWin:   filepath.Join(os.Getenv("APPDATA"), "augment", "rules"),
-			RulesDirLin:   filepath.Join(home, ".augment", "rules"),
-		},
-		{
-			ID:            "qwen_code",
-			Name:          "Qwen Code",
-			CheckPathsMac: []string{},
-			CheckPathsWin: []string{},
-			CheckPathsLin: []string{},
-			RulesDirMac:   filepath.Join(home, ".qwen-code", "rules"),
-			RulesDirWin:   filepath.Join(os.Getenv("APPDATA"), "qwen-code", "rules"),
-			RulesDirLin:   filepath.Join(home, ".qwen-code", "rules"),
-		},
-		{
-			ID:   "codebuddy",
-			Name: "CodeBuddy",
-			CheckPathsMac: []string{
-				filepath.Join(home, "Library", "Application Support", "CodeBuddy"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "CodeBuddy"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".config", "CodeBuddy"),
-			},
-			RulesDirMac: filepath.Join(home, ".codebuddy", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "CodeBuddy", "rules"),
-			RulesDirLin: filepath.Join(home, ".codebuddy", "rules"),
-		},
-		{
-			ID:   "costrict",
-			Name: "CoStrict",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".costrict"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "costrict"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".costrict"),
-			},
-			RulesDirMac: filepath.Join(home, ".costrict", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "costrict", "rules"),
-			RulesDirLin: filepath.Join(home, ".costrict", "rules"),
-		},
-		{
-			ID:            "crush",
-			Name:          "Crush",
-			CheckPathsMac: []string{},
-			CheckPathsWin: []string{},
-			CheckPathsLin: []string{},
-			RulesDirMac:   filepath.Join(home, ".crush", "rules"),
-			RulesDirWin:   filepath.Join(os.Getenv("APPDATA"), "crush", "rules"),
-			RulesDirLin:   filepath.Join(home, ".crush", "rules"),
-		},
-		{
-			ID:   "factory_droid",
-			Name: "Factory Droid",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".factory"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "factory"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".factory"),
-			},
-			RulesDirMac: filepath.Join(home, ".factory", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "factory", "rules"),
-			RulesDirLin: filepath.Join(home, ".factory", "rules"),
-		},
-		{
-			ID:   "iflow",
-			Name: "iFlow",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".iflow"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "iflow"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".iflow"),
-			},
-			RulesDirMac: filepath.Join(home, ".iflow", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "iflow", "rules"),
-			RulesDirLin: filepath.Join(home, ".iflow", "rules"),
-		},
-		{
-			ID:   "continue",
-			Name: "Continue",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".continue"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("USERPROFILE"), ".continue"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".continue"),
-			},
-			RulesDirMac: filepath.Join(home, ".continue", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("USERPROFILE"), ".continue", "rules"),
-			RulesDirLin: filepath.Join(home, ".continue", "rules"),
-		},
-		{
-			ID:   "aider",
-			Name: "Aider",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".aider.conf.yml"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("USERPROFILE"), ".aider.conf.yml"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".aider.conf.yml"),
-			},
-			RulesDirMac: filepath.Join(home, ".config", "aider"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "aider"),
-			RulesDirLin: filepath.Join(home, ".config", "aider"),
-		},
-		{
-			ID:   "tabby",
-			Name: "Tabby",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".tabby"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("APPDATA"), "tabby"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".tabby"),
-			},
-			RulesDirMac: filepath.Join(home, ".tabby", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "tabby", "rules"),
-			RulesDirLin: filepath.Join(home, ".tabby", "rules"),
-		},
-		{
-			ID:   "coco",
-			Name: "Coco",
-			CheckPathsMac: []string{
-				filepath.Join(home, ".coco"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("USERPROFILE"), ".coco"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".coco"),
-			},
-			RulesDirMac: filepath.Join(home, ".coco", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("USERPROFILE"), ".coco", "rules"),
-			RulesDirLin: filepath.Join(home, ".coco", "rules"),
-		},
-		{
-			ID:   "mars_code",
-			Name: "MarsCode",
-			CheckPathsMac: []string{
-				"/Applications/MarsCode.app",
-				filepath.Join(home, "Library", "Application Support", "MarsCode"),
-			},
-			CheckPathsWin: []string{
-				filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "MarsCode", "MarsCode.exe"),
-				filepath.Join(os.Getenv("APPDATA"), "MarsCode"),
-			},
-			CheckPathsLin: []string{
-				filepath.Join(home, ".config", "MarsCode"),
-			},
-			RulesDirMac: filepath.Join(home, ".mars-code", "rules"),
-			RulesDirWin: filepath.Join(os.Getenv("APPDATA"), "MarsCode", "User", "rules"),
-			RulesDirLin: filepath.Join(home, ".config", "MarsCode", "User", "rules"),
-		},
+	registry := registryTools()
+	defs := make([]ideToolDef, 0, len(registry))
+	for _, t := range registry {
+		def := ideToolDef{
+			ID:              t.ID,
+			Name:            t.Name,
+			Binaries:        t.Binaries,
+			CheckPathsMac:   t.CheckPathsFor("darwin"),
+			CheckPathsWin:   t.CheckPathsFor("windows"),
+			CheckPathsLin:   t.CheckPathsFor("linux"),
+			RulesDirMac:     t.RulesDirFor("darwin"),
+			RulesDirWin:     t.RulesDirFor("windows"),
+			RulesDirLin:     t.RulesDirFor("linux"),
+			Format:          t.Format,
+			ProjectRulesDir: t.ProjectRulesDir,
+			FileName:        t.FileName,
+			VersionArgs:     t.VersionArgs,
+			VersionFiles:    t.VersionFilesFor(""),
+			ReadDirs:        t.ReadDirsFor(""),
+			LegacyRulesDirs: t.LegacyRulesDirsFor(""),
+			Capabilities:    t.Capabilities,
+		}
+		// 按上次扫描到的已安装版本选择其支持的输出格式
+		defs = append(defs, def.withVersion(detectedToolVersion(t.ID)))
+	}
+	for _, c := range custom {
+		defs = append(defs, customToolDef(c))
 	}
 
 	// 注入用户手动指定的规则目录，覆盖默认值
@@ -1124,6 +922,61 @@ func (def *ideToolDef) getRulesDir() string {
 	}
 }
 
+// rulesDirIn returns the tool's rules dir for a sync scope: the global rules
+// dir when projectRoot is empty, otherwise the project-relative dir below it
+// ("" when the tool has no project-level rules)
+func (def *ideToolDef) rulesDirIn(projectRoot string) string {
+	if projectRoot == "" {
+		return def.getRulesDir()
+	}
+	if def.ProjectRulesDir == "" {
+		return ""
+	}
+	return filepath.Join(projectRoot, filepath.FromSlash(def.ProjectRulesDir))
+}
+
+// placedPaths returns every path a skill may be placed at for the tool in a
+// sync scope, including the legacy paths in the global scope
+func (def *ideToolDef) placedPaths(projectRoot, skillName string) []string {
+	rulesDir := def.rulesDirIn(projectRoot)
+	if rulesDir == "" {
+		return nil
+	}
+	paths := make([]string, 0)
+	for _, rel := range def.adapter().Paths(skillName) {
+		paths = append(paths, filepath.Join(rulesDir, rel))
+	}
+	if projectRoot == "" {
+		paths = append(paths, def.legacyPaths(skillName)...)
+	}
+	return paths
+}
+
+// legacyPaths returns where older versions placed a skill for the tool in the
+// global scope (the rules dir has moved since)
+func (def *ideToolDef) legacyPaths(skillName string) []string {
+	paths := make([]string, 0, len(def.LegacyRulesDirs))
+	for _, dir := range def.LegacyRulesDirs {
+		if dir != "" && dir != def.getRulesDir() {
+			paths = append(paths, filepath.Join(dir, skillName+".md"))
+		}
+	}
+	return paths
+}
+
+// format returns the output format used when syncing to the tool
+func (def *ideToolDef) format() string {
+	if def.Format == "" {
+		return adapter.FormatMarkdown
+	}
+	return def.Format
+}
+
+// adapter returns the adapter that renders skills for the tool
+func (def *ideToolDef) adapter() adapter.Adapter {
+	return adapter.WithNaming(adapter.Get(def.format()), def.FileName)
+}
+
 // isCommandInPath checks if a command is available in PATH
 func isCommandInPath(cmd string) (bool, string) {
 	path, err := exec.LookPath(cmd)
@@ -1135,7 +988,7 @@ func isCommandInPath(cmd string) (bool, string) {
 
 // ScanIDETools detects installed AI coding tools on the current system
 func (a *App) ScanIDETools() ([]IDEToolInfo, error) {
-	defs := ideToolDefs(a.config.ToolPaths)
+	defs := ideToolDefs(a.config.ToolPaths, a.config.CustomTools)
 	result := make([]IDEToolInfo, 0, len(defs))
 
 	for _, def := range defs {
@@ -1145,6 +998,9 @@ func (a *App) ScanIDETools() ([]IDEToolInfo, error) {
 			Installed:     false,
 			Path:          "",
 			SkillRulesDir: def.getRulesDir(),
+			Format:        def.format(),
+			ResourceMode:  a.GetToolResourceMode(def.ID),
+			Custom:        def.Custom,
 		}
 
 		// 用户手动指定的路径优先：只要目录存在即视为已安装，并直接作为规则目录
@@ -1154,57 +1010,19 @@ func (a *App) ScanIDETools() ([]IDEToolInfo, error) {
 				tool.Path = def.ManualRulesDir
 				tool.SkillRulesDir = def.ManualRulesDir
 				tool.Manual = true
-				result = append(result, tool)
-				continue
 			}
 		}
 
 		// Check command in PATH first (works cross-platform for CLI tools)
-		switch def.ID {
-		case "claude_code":
-			if found, p := isCommandInPath("claude"); found {
-				tool.Installed = true
-				tool.Path = p
-			}
-		case "gemini_cli":
-			if found, p := isCommandInPath("gemini"); found {
-				tool.Installed = true
-				tool.Path = p
-			}
-		case "goose":
-			if found, p := isCommandInPath("goose"); found {
-				tool.Installed = true
-				tool.Path = p
-			}
-		case "codex":
-			if found, p := isCommandInPath("codex"); found {
-				tool.Installed = true
-				tool.Path = p
-			}
-		case "amazon_q":
-			if found, p := isCommandInPath("q"); found {
-				tool.Installed = true
-				tool.Path = p
-			}
-		case "auggie_cli":
-			if found, p := isCommandInPath("auggie"); found {
-				tool.Installed = true
-				tool.Path = p
-			}
-		case "qwen_code":
-			if found, p := isCommandInPath("qwen-code"); found {
-				tool.Installed = true
-				tool.Path = p
-			}
-		case "crush":
-			if found, p := isCommandInPath("crush"); found {
-				tool.Installed = true
-				tool.Path = p
-			}
-		case "factory_droid":
-			if found, p := isCommandInPath("droid"); found {
-				tool.Installed = true
-				tool.Path = p
+		binary := ""
+		for _, bin := range def.Binaries {
+			if found, p := isCommandInPath(bin); found {
+				binary = p
+				if !tool.Installed {
+					tool.Installed = true
+					tool.Path = p
+				}
+				break
 			}
 		}
 
@@ -1222,6 +1040,7 @@ func (a *App) ScanIDETools() ([]IDEToolInfo, error) {
 			}
 		}
 
+		describeTool(&tool, def, binary)
 		result = append(result, tool)
 	}
 	return result, nil
@@ -1265,36 +1084,94 @@ func (a *App) ClearToolPath(toolID string) error {
 	return a.store.Save(a.config)
 }
 
+// GetToolResourceMode returns how a single-file tool carries the files next to
+// SKILL.md: none (default), inline or reference
+func (a *App) GetToolResourceMode(toolID string) string {
+	if mode := a.config.ToolResourceModes[toolID]; mode != "" {
+		return mode
+	}
+	return adapter.ResourcesNone
+}
+
+// SetToolResourceMode saves the resource mode of a tool. Folder based tools
+// (Claude Code skills) always receive the whole skill directory.
+func (a *App) SetToolResourceMode(toolID, mode string) error {
+	if !adapter.ValidResourceMode(mode) {
+		return fmt.Errorf("未知的资源处理方式: %s", mode)
+	}
+	if a.config.ToolResourceModes == nil {
+		a.config.ToolResourceModes = map[string]string{}
+	}
+	if mode == "" || mode == adapter.ResourcesNone {
+		delete(a.config.ToolResourceModes, toolID)
+	} else {
+		a.config.ToolResourceModes[toolID] = mode
+	}
+	return a.store.Save(a.config)
+}
+
 // detectSyncedTools checks which tools have this skill synced to their rules dir
-func detectSyncedTools(skillName, _ string, manualPaths map[string]string) []string {
-	defs := ideToolDefs(manualPaths)
+func detectSyncedTools(skillName string, manualPaths map[string]string, custom []config.CustomTool) []string {
 	synced := make([]string, 0)
+	for _, def := range syncedToolDefs(ideToolDefs(manualPaths, custom), "", skillName) {
+		synced = append(synced, def.Name)
+	}
+	return synced
+}
+
+// syncedToolDefs returns the tools whose rules dir in the given scope
+// (global when projectRoot is empty) contains the skill
+func syncedToolDefs(defs []ideToolDef, projectRoot, skillName string) []ideToolDef {
+	synced := make([]ideToolDef, 0)
 	for _, def := range defs {
-		rulesDir := def.getRulesDir()
+		rulesDir := def.rulesDirIn(projectRoot)
 		if rulesDir == "" {
 			continue
 		}
-		// Check if skill file or dir exists inside tools rules dir
-		skillFile := filepath.Join(rulesDir, skillName+".md")
-		skillLink := filepath.Join(rulesDir, skillName)
-		if _, err := os.Lstat(skillFile); err == nil {
-			synced = append(synced, def.Name)
-		} else if _, err := os.Lstat(skillLink); err == nil {
-			synced = append(synced, def.Name)
+		if adapter.Detect(def.adapter(), rulesDir, skillName) {
+			synced = append(synced, def)
+			continue
+		}
+		if projectRoot != "" {
+			continue
+		}
+		// 旧版本同步到已迁移目录的文件也算已同步
+		for _, path := range def.legacyPaths(skillName) {
+			if _, err := os.Lstat(path); err == nil {
+				synced = append(synced, def)
+				break
+			}
 		}
 	}
 	return synced
 }
 
-// SyncSkillToTools copies (or symlinks on unix) the skill's SKILL.md to each tool's rules dir
+// SyncSkillToTools renders the skill into each tool's rules dir in the tool-native format
+// (symlinks on unix where the format allows it)
 func (a *App) SyncSkillToTools(skillName string, toolIds []string) error {
+	return a.syncSkillToTools("", skillName, toolIds)
+}
+
+// syncSkillToTools syncs a skill into the tools' global rules dirs, or into
+// their project-relative rules dirs below projectRoot when it is set
+func (a *App) syncSkillToTools(projectRoot, skillName string, toolIds []string) error {
+	if err := validateSkillName(skillName); err != nil {
+		return err
+	}
 	skillDir := a.getSkillDir()
 	skillMdPath := filepath.Join(skillDir, skillName, "SKILL.md")
 	if _, err := os.Stat(skillMdPath); err != nil {
 		return fmt.Errorf("技能文件不存在: %s", skillMdPath)
 	}
+	if err := checkSkillValid(filepath.Join(skillDir, skillName)); err != nil {
+		return err
+	}
+	src, err := adapter.LoadSource(skillName, filepath.Join(skillDir, skillName))
+	if err != nil {
+		return err
+	}
 
-	defs := ideToolDefs(a.config.ToolPaths)
+	defs := ideToolDefs(a.config.ToolPaths, a.config.CustomTools)
 	defMap := make(map[string]ideToolDef, len(defs))
 	for _, d := range defs {
 		defMap[d.ID] = d
@@ -1306,7 +1183,7 @@ func (a *App) SyncSkillToTools(skillName string, toolIds []string) error {
 		if !ok {
 			continue
 		}
-		rulesDir := def.getRulesDir()
+		rulesDir := def.rulesDirIn(projectRoot)
 		if rulesDir == "" {
 			continue
 		}
@@ -1314,27 +1191,21 @@ func (a *App) SyncSkillToTools(skillName string, toolIds []string) error {
 			errs = append(errs, fmt.Sprintf("%s: %v", def.Name, err))
 			continue
 		}
-		destFile := filepath.Join(rulesDir, skillName+".md")
-
-		// Remove existing
-		os.Remove(destFile)
-		if _, err := os.Lstat(destFile); err == nil {
-			os.RemoveAll(destFile)
+		// 目标位置已有非 SkillUI 创建（或同步后被修改）的同名文件时不覆盖
+		dest := filepath.Join(rulesDir, def.adapter().Paths(skillName)[0])
+		if owned, reason := a.checkOwned(skillName, dest); !owned {
+			errs = append(errs, fmt.Sprintf("%s: %s 已存在且%s，未覆盖", def.Name, dest, reason))
+			continue
 		}
-
-		if goruntime.GOOS == "windows" {
-			// Windows: copy file
-			if err := copyFile(skillMdPath, destFile); err != nil {
-				errs = append(errs, fmt.Sprintf("%s: %v", def.Name, err))
-			}
-		} else {
-			// macOS / Linux: symlink
-			if err := os.Symlink(skillMdPath, destFile); err != nil {
-				// fallback to copy
-				if err2 := copyFile(skillMdPath, destFile); err2 != nil {
-					errs = append(errs, fmt.Sprintf("%s: %v", def.Name, err2))
-				}
-			}
+		if err := def.adapter().Render(src, rulesDir, a.adapterOptions(def, projectRoot)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", def.Name, err))
+			continue
+		}
+		if err := a.recordPlaced(projectRoot, skillName, def); err != nil {
+			a.LogSystemError("SyncSkillToTools", fmt.Sprintf("Failed to record synced file of %s in manifest: %v", skillName, err))
+		}
+		if projectRoot == "" {
+			a.removeLegacySynced(skillName, def)
 		}
 	}
 
@@ -1346,30 +1217,48 @@ func (a *App) SyncSkillToTools(skillName string, toolIds []string) error {
 
 // UnsyncSkillFromTools removes the skill's synced file from each tool's rules dir
 func (a *App) UnsyncSkillFromTools(skillName string, toolIds []string) error {
-	defs := ideToolDefs(a.config.ToolPaths)
+	return a.unsyncSkillFromTools("", skillName, toolIds)
+}
+
+// unsyncSkillFromTools removes a skill from the tools' global rules dirs, or
+// from their project-relative rules dirs below projectRoot when it is set.
+// Files SkillUI did not create are kept and reported as an error.
+func (a *App) unsyncSkillFromTools(projectRoot, skillName string, toolIds []string) error {
+	report, err := a.unsyncSkill(projectRoot, skillName, toolIds)
+	if err != nil {
+		return err
+	}
+	if len(report.Refused) > 0 {
+		return fmt.Errorf("以下文件不是由 SkillUI 创建或已被修改，未删除: %s", report.refusedSummary())
+	}
+	return nil
+}
+
+// unsyncSkill removes the files SkillUI placed for a skill in the given tools
+func (a *App) unsyncSkill(projectRoot, skillName string, toolIds []string) (SyncRemovalReport, error) {
+	if err := validateSkillName(skillName); err != nil {
+		return SyncRemovalReport{}, err
+	}
+	defs := ideToolDefs(a.config.ToolPaths, a.config.CustomTools)
 	defMap := make(map[string]ideToolDef, len(defs))
 	for _, d := range defs {
 		defMap[d.ID] = d
 	}
 
+	report := newSyncRemovalReport()
 	var errs []string
 	for _, toolID := range toolIds {
 		def, ok := defMap[toolID]
 		if !ok {
 			continue
 		}
-		rulesDir := def.getRulesDir()
-		if rulesDir == "" {
-			continue
-		}
-		destFile := filepath.Join(rulesDir, skillName+".md")
-		if err := os.Remove(destFile); err != nil && !os.IsNotExist(err) {
+		if err := a.removeSynced(projectRoot, skillName, def, false, &report); err != nil {
 			errs = append(errs, fmt.Sprintf("%s: %v", def.Name, err))
 		}
 	}
 
 	if len(errs) > 0 {
-		return fmt.Errorf("部分工具取消同步失败: %s", strings.Join(errs, "; "))
+		return report, fmt.Errorf("部分工具取消同步失败: %s", strings.Join(errs, "; "))
 	}
-	return nil
+	return report, nil
 }
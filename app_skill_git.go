@@ -0,0 +1,527 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"skillui/internal/skill"
+)
+
+// Skill source types recorded in skillui.json
+const (
+	SourceTypeGit = "git"
+	SourceTypeURL = "url"
+)
+
+// SkillSource records where a skill was installed from, so it can be updated later
+type SkillSource struct {
+	Type string `json:"type"`
+	// RepoURL / Ref / Commit / Subpath / Path 仅对 git 来源有效
+	RepoURL string `json:"repoUrl,omitempty"`
+	// Ref 为安装时指定的分支、标签或提交，为空表示仓库默认分支
+	Ref string `json:"ref,omitempty"`
+	// Commit 为当前安装内容对应的提交
+	Commit string `json:"commit,omitempty"`
+	// Subpath 为安装时指定的仓库子目录
+	Subpath string `json:"subpath,omitempty"`
+	// Path 为该技能在仓库中的目录（相对仓库根目录）
+	Path string `json:"path,omitempty"`
+	// URL 仅对 URL 来源有效，为下载 ZIP 包的地址
+	URL string `json:"url,omitempty"`
+}
+
+// GitInstallOptions are the parameters of a git install
+type GitInstallOptions struct {
+	RepoURL string `json:"repoUrl"`
+	// Ref 为分支、标签或提交哈希，为空时使用仓库默认分支
+	Ref string `json:"ref"`
+	// Subpath 只扫描仓库中的该子目录
+	Subpath string `json:"subpath"`
+	// Policy 为同名技能冲突处理策略，为空时使用配置中的默认策略
+	Policy string `json:"policy"`
+}
+
+// GitSkillUpdate describes the update state of one git-installed skill
+type GitSkillUpdate struct {
+	Name          string `json:"name"`
+	RepoURL       string `json:"repoUrl"`
+	Ref           string `json:"ref"`
+	CurrentCommit string `json:"currentCommit"`
+	LatestCommit  string `json:"latestCommit"`
+	// Changed 表示上游该技能目录的内容有变化
+	Changed bool `json:"changed"`
+	// LocalModified 表示本地技能目录相对安装时已被修改，拉取更新会覆盖本地修改
+	LocalModified bool `json:"localModified"`
+	// Pinned 表示安装时指定的是提交哈希，不会随上游更新
+	Pinned bool `json:"pinned"`
+	// Status 仅在拉取更新时填写：updated / unchanged / failed
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// commitPattern matches a full or abbreviated git commit hash
+var commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// validateGitArgs rejects a repository URL or ref that git would parse as an
+// option (e.g. --upload-pack=...), which could run arbitrary commands
+func validateGitArgs(repoUrl, ref string) error {
+	if strings.HasPrefix(repoUrl, "-") {
+		return fmt.Errorf("非法的仓库地址: %s", repoUrl)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("非法的 git 引用: %s", ref)
+	}
+	return nil
+}
+
+// InstallSkillFromGit clones a git repository and installs all detected skills (directories containing SKILL.md)
+func (a *App) InstallSkillFromGit(repoUrl string) ([]string, error) {
+	results, err := a.InstallSkillsFromGit(GitInstallOptions{RepoURL: repoUrl})
+	if err != nil {
+		return nil, err
+	}
+	installed := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.err() == nil {
+			installed = append(installed, r.Name)
+		}
+	}
+	if len(installed) == 0 {
+		return nil, fmt.Errorf("未能从仓库安装任何技能: %s", summarizeInstallResults(results))
+	}
+	return installed, nil
+}
+
+// InstallSkillsFromGit checks out a git repository at the requested ref and
+// installs every skill found (optionally below Subpath), returning what
+// happened to each one. The repository, ref and commit are recorded in each
+// skill's skillui.json so PullGitSkillUpdates can update it later.
+func (a *App) InstallSkillsFromGit(opts GitInstallOptions) ([]InstallResult, error) {
+	policy, err := a.parseConflictPolicy(opts.Policy)
+	if err != nil {
+		return nil, err
+	}
+	repoUrl := strings.TrimSpace(opts.RepoURL)
+	if repoUrl == "" {
+		return nil, fmt.Errorf("仓库地址不能为空")
+	}
+	ref := strings.TrimSpace(opts.Ref)
+	if err := validateGitArgs(repoUrl, ref); err != nil {
+		return nil, err
+	}
+	subpath := strings.Trim(filepath.ToSlash(strings.TrimSpace(opts.Subpath)), "/")
+
+	tmpDir, commit, err := gitCheckout(repoUrl, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	scanRoot := tmpDir
+	if subpath != "" {
+		scanRoot = filepath.Join(tmpDir, filepath.FromSlash(subpath))
+		if !strings.HasPrefix(scanRoot, tmpDir+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("非法的子目录: %s", opts.Subpath)
+		}
+		if info, err := os.Stat(scanRoot); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("仓库中不存在子目录: %s", subpath)
+		}
+	}
+
+	// Recursively find all directories containing SKILL.md
+	skillPaths, err := findSkillDirs(scanRoot)
+	if err != nil {
+		return nil, fmt.Errorf("扫描仓库失败: %w", err)
+	}
+	if len(skillPaths) == 0 {
+		return nil, fmt.Errorf("未在仓库中找到任何有效技能（含 SKILL.md 的目录）")
+	}
+
+	owner := repoOwner(repoUrl)
+	results := make([]InstallResult, 0, len(skillPaths))
+	for _, subDir := range skillPaths {
+		name := filepath.Base(subDir)
+		// If the repo root itself is detected, use the repo name
+		if subDir == tmpDir {
+			name = repoName(repoUrl)
+		}
+		rel, _ := filepath.Rel(tmpDir, subDir)
+		source := SkillSource{
+			Type:    SourceTypeGit,
+			RepoURL: repoUrl,
+			Ref:     ref,
+			Commit:  commit,
+			Subpath: subpath,
+			Path:    filepath.ToSlash(rel),
+		}
+		result := a.installSkill(skillInstallRequest{
+			Name:   name,
+			Source: source.Path,
+			Owner:  owner,
+			Policy: policy,
+			Fill: func(staged string) error {
+				return stageGitSkill(subDir, staged, name, source)
+			},
+		})
+		if result.Status == InstallStatusFailed {
+			a.LogSystemError("InstallSkillsFromGit", fmt.Sprintf("Failed to install skill %s from %s: %s", name, repoUrl, result.Error))
+		} else if result.err() == nil {
+			// Auto-sync each installed skill; a failure is reported without undoing the install
+			if err := a.syncToInstalledTools(result.Name); err != nil {
+				a.LogSystemError("InstallSkillsFromGit", fmt.Sprintf("Failed to auto-sync skill %s: %v", result.Name, err))
+				result.Error = fmt.Sprintf("已安装，但自动同步失败: %v", err)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// stageGitSkill copies a skill out of a checkout (without VCS data) and writes
+// its skillui.json with the git source and content hashes
+func stageGitSkill(src, staged, name string, source SkillSource) error {
+	if err := copyDirFiltered(src, staged, func(rel string, d fs.DirEntry) bool {
+		return d.Name() == ".git"
+	}); err != nil {
+		return err
+	}
+	tree, err := skill.HashTree(staged)
+	if err != nil {
+		return fmt.Errorf("计算技能哈希失败: %w", err)
+	}
+	doc, _ := skill.ParseFile(filepath.Join(staged, "SKILL.md"))
+	return writeSkillUIJson(staged, skillUIJson{
+		Name:        name,
+		Owner:       doc.Metadata.Owner,
+		Version:     doc.Metadata.Version,
+		TreeHash:    tree.Hash,
+		Files:       tree.Files,
+		InstalledAt: time.Now().Format(skillUITimeLayout),
+		Source:      &source,
+	})
+}
+
+// gitCheckout fetches repoUrl at ref (branch, tag, commit or empty for the
+// default branch) into a new temp dir and returns the dir and resolved commit.
+// The caller removes the dir.
+func gitCheckout(repoUrl, ref string) (string, string, error) {
+	if err := validateGitArgs(repoUrl, ref); err != nil {
+		return "", "", err
+	}
+	// Verify git is available
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", "", fmt.Errorf("未找到 git 命令，请先安装 Git")
+	}
+
+	// Create temp dir for clone
+	tmpDir, err := os.MkdirTemp("", "skill-git-*")
+	if err != nil {
+		return "", "", err
+	}
+	fail := func(err error) (string, string, error) {
+		os.RemoveAll(tmpDir)
+		return "", "", err
+	}
+
+	if _, err := runGit(tmpDir, "init", "-q"); err != nil {
+		return fail(err)
+	}
+	if _, err := runGit(tmpDir, "remote", "add", "--end-of-options", "origin", repoUrl); err != nil {
+		return fail(err)
+	}
+	target := ref
+	if target == "" {
+		target = "HEAD"
+	}
+	// 浅拉取指定 ref；部分服务器不允许按提交哈希浅拉取，此时回退为完整拉取
+	rev := "FETCH_HEAD"
+	if _, err := runGit(tmpDir, "fetch", "-q", "--depth=1", "--end-of-options", "origin", target); err != nil {
+		if !commitPattern.MatchString(ref) {
+			return fail(fmt.Errorf("git fetch 失败: %w", err))
+		}
+		if _, err := runGit(tmpDir, "fetch", "-q", "--end-of-options", "origin"); err != nil {
+			return fail(fmt.Errorf("git fetch 失败: %w", err))
+		}
+		rev = ref
+	}
+	// 先解析为提交哈希再检出：git checkout 不支持 --end-of-options
+	commit, err := runGit(tmpDir, "rev-parse", "-q", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return fail(fmt.Errorf("检出提交 %s 失败: %w", target, err))
+	}
+	if _, err := runGit(tmpDir, "checkout", "-q", "--detach", commit, "--"); err != nil {
+		return fail(fmt.Errorf("git checkout 失败: %w", err))
+	}
+	return tmpDir, commit, nil
+}
+
+// gitRemoteCommit resolves ref on the remote without fetching any objects.
+// It returns "" when the ref cannot be resolved by name (e.g. a commit hash).
+func gitRemoteCommit(repoUrl, ref string) (string, error) {
+	if err := validateGitArgs(repoUrl, ref); err != nil {
+		return "", err
+	}
+	target := ref
+	if target == "" {
+		target = "HEAD"
+	}
+	out, err := runGit("", "ls-remote", "--end-of-options", repoUrl, target, "refs/tags/"+target+"^{}")
+	if err != nil {
+		return "", err
+	}
+	commit := ""
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		// 附注标签以 ^{} 结尾的行才是标签指向的提交
+		if strings.HasSuffix(fields[1], "^{}") {
+			return fields[0], nil
+		}
+		if commit == "" {
+			commit = fields[0]
+		}
+	}
+	return commit, nil
+}
+
+// runGit runs a git command and returns its trimmed stdout
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w\n%s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// gitSkill is an installed skill with a git source
+type gitSkill struct {
+	name string
+	dir  string
+	sj   skillUIJson
+}
+
+// listGitSkills returns installed skills linked to a git repository, limited
+// to names when it is not empty
+func (a *App) listGitSkills(names []string) []gitSkill {
+	wanted := make(map[string]bool, len(names))
+	for _, n := range names {
+		wanted[n] = true
+	}
+	skillDir := a.getSkillDir()
+	entries, err := os.ReadDir(skillDir)
+	if err != nil {
+		return nil
+	}
+	skills := make([]gitSkill, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[entry.Name()] {
+			continue
+		}
+		dir := filepath.Join(skillDir, entry.Name())
+		sj, err := readSkillUIJson(dir)
+		if err != nil || sj.Source == nil || sj.Source.Type != SourceTypeGit {
+			continue
+		}
+		skills = append(skills, gitSkill{name: entry.Name(), dir: dir, sj: sj})
+	}
+	return skills
+}
+
+// CheckGitSkillUpdates fetches the latest revision of every git-installed
+// skill's repository and reports which skills changed upstream
+func (a *App) CheckGitSkillUpdates() ([]GitSkillUpdate, error) {
+	return a.updateGitSkills(nil, false)
+}
+
+// PullGitSkillUpdates fetches the latest revision and reinstalls the skills
+// whose content changed upstream (all git skills when names is empty)
+func (a *App) PullGitSkillUpdates(names []string) ([]GitSkillUpdate, error) {
+	return a.updateGitSkills(names, true)
+}
+
+// updateGitSkills checks (and with apply, installs) upstream changes of git skills.
+// Skills are grouped by repository and ref so each revision is fetched once.
+func (a *App) updateGitSkills(names []string, apply bool) ([]GitSkillUpdate, error) {
+	skills := a.listGitSkills(names)
+	groups := make(map[string][]gitSkill)
+	keys := make([]string, 0)
+	for _, s := range skills {
+		key := s.sj.Source.RepoURL + "\x00" + s.sj.Source.Ref
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], s)
+	}
+	sort.Strings(keys)
+
+	updates := make([]GitSkillUpdate, 0, len(skills))
+	for _, key := range keys {
+		updates = append(updates, a.updateGitGroup(groups[key], apply)...)
+	}
+	return updates, nil
+}
+
+// updateGitGroup handles the skills installed from one repository and ref
+func (a *App) updateGitGroup(skills []gitSkill, apply bool) []GitSkillUpdate {
+	src := skills[0].sj.Source
+	// 先用 ls-remote 解析 ref：能按名称解析的是分支或标签（包括形如哈希的数字标签），
+	// 解析不到且形如哈希的才视为固定的提交
+	latest, lsErr := gitRemoteCommit(src.RepoURL, src.Ref)
+	pinned := commitPattern.MatchString(src.Ref) && (lsErr != nil || latest == "")
+	updates := make([]GitSkillUpdate, len(skills))
+	for i, s := range skills {
+		updates[i] = GitSkillUpdate{
+			Name:          s.name,
+			RepoURL:       src.RepoURL,
+			Ref:           src.Ref,
+			CurrentCommit: s.sj.Source.Commit,
+			Pinned:        pinned,
+		}
+		if local, err := skill.HashTree(s.dir); err == nil && s.sj.TreeHash != "" {
+			updates[i].LocalModified = local.Hash != s.sj.TreeHash
+		}
+	}
+	setAll := func(fn func(u *GitSkillUpdate)) []GitSkillUpdate {
+		for i := range updates {
+			fn(&updates[i])
+		}
+		return updates
+	}
+
+	// 固定在某个提交的技能不会有更新
+	if pinned {
+		return setAll(func(u *GitSkillUpdate) {
+			u.LatestCommit = u.CurrentCommit
+			if apply {
+				u.Status = InstallStatusUnchanged
+			}
+		})
+	}
+
+	// 远端提交未变化时无需完整拉取
+	if lsErr == nil && latest != "" {
+		upToDate := true
+		for _, s := range skills {
+			if s.sj.Source.Commit != latest {
+				upToDate = false
+			}
+		}
+		if upToDate {
+			return setAll(func(u *GitSkillUpdate) {
+				u.LatestCommit = latest
+				if apply {
+					u.Status = InstallStatusUnchanged
+				}
+			})
+		}
+	}
+
+	tmpDir, commit, err := gitCheckout(src.RepoURL, src.Ref)
+	if err != nil {
+		return setAll(func(u *GitSkillUpdate) {
+			u.Error = err.Error()
+			if apply {
+				u.Status = InstallStatusFailed
+			}
+		})
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for i, s := range skills {
+		u := &updates[i]
+		u.LatestCommit = commit
+		upstreamDir := filepath.Join(tmpDir, filepath.FromSlash(s.sj.Source.Path))
+		if _, err := os.Stat(filepath.Join(upstreamDir, "SKILL.md")); err != nil {
+			u.Error = fmt.Sprintf("上游仓库中已不存在该技能: %s", s.sj.Source.Path)
+			if apply {
+				u.Status = InstallStatusFailed
+			}
+			continue
+		}
+		upstream, err := skill.HashTree(upstreamDir)
+		if err != nil {
+			u.Error = err.Error()
+			if apply {
+				u.Status = InstallStatusFailed
+			}
+			continue
+		}
+		u.Changed = upstream.Hash != s.sj.TreeHash
+		if !apply {
+			continue
+		}
+
+		source := *s.sj.Source
+		source.Commit = commit
+		if !u.Changed {
+			// 内容未变化，只更新记录的提交
+			sj := s.sj
+			sj.Source = &source
+			if err := writeSkillUIJson(s.dir, sj); err != nil {
+				u.Status, u.Error = InstallStatusFailed, err.Error()
+			} else {
+				u.Status = InstallStatusUnchanged
+				u.CurrentCommit = commit
+			}
+			continue
+		}
+
+		name := s.name
+		synced := a.syncedScopes(name)
+		result := a.installSkill(skillInstallRequest{
+			Name:   name,
+			Source: source.Path,
+			Policy: ConflictOverwrite,
+			Fill: func(staged string) error {
+				return stageGitSkill(upstreamDir, staged, name, source)
+			},
+		})
+		if err := result.err(); err != nil {
+			u.Status, u.Error = InstallStatusFailed, err.Error()
+			continue
+		}
+		u.Status = InstallStatusUpdated
+		u.CurrentCommit = commit
+		if err := a.resyncScopes(name, synced); err != nil {
+			a.LogSystemError("PullGitSkillUpdates", fmt.Sprintf("Failed to re-sync skill %s after update: %v", name, err))
+			u.Error = fmt.Sprintf("已更新，但重新同步失败: %v", err)
+		}
+	}
+	return updates
+}
+
+// repoName returns the repository name of a git URL
+func repoName(repoUrl string) string {
+	return filepath.Base(strings.TrimSuffix(strings.TrimRight(repoUrl, "/"), ".git"))
+}
+
+// repoOwner returns the owner (user/organisation) part of a git URL such as
+// https://github.com/owner/repo.git or git@github.com:owner/repo.git
+func repoOwner(repoUrl string) string {
+	u := strings.TrimSuffix(strings.TrimRight(repoUrl, "/"), ".git")
+	if idx := strings.Index(u, "://"); idx >= 0 {
+		u = u[idx+3:]
+	} else if idx := strings.Index(u, ":"); idx >= 0 {
+		u = strings.Replace(u, ":", "/", 1)
+	}
+	parts := strings.Split(u, "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"skillui/internal/skill"
+)
+
+// 技能索引：技能目录监听运行时，已解析的 SkillMeta（含校验结果）保存在内存中，
+// ListLocalSkills 直接读取索引；目录变化（git pull、其他工具、编辑器、SkillUI 自身的安装与删除）
+// 按技能增量更新索引，并向前端发送 skill:added / skill:changed / skill:removed 事件。
+// 同步状态（SyncedTools）取决于工具目录而非技能目录，仍在读取时检测。
+
+// Skill index events
+const (
+	EventSkillAdded   = "skill:added"
+	EventSkillChanged = "skill:changed"
+	EventSkillRemoved = "skill:removed"
+)
+
+// SkillRemovedEvent is the payload of EventSkillRemoved
+type SkillRemovedEvent struct {
+	Name string `json:"name"`
+}
+
+// indexSkill parses one skill directory for the index (without SyncedTools)
+func indexSkill(dir string) SkillMeta {
+	meta := parseSkillMeta(dir)
+	meta.Diagnostics = skill.Validate(dir, skill.DefaultLimits)
+	return meta
+}
+
+// scanSkills parses every skill directory below skillDir, keyed by directory name
+func scanSkills(skillDir string) (map[string]SkillMeta, error) {
+	if err := os.MkdirAll(skillDir, 0755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(skillDir)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]SkillMeta, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		index[entry.Name()] = indexSkill(filepath.Join(skillDir, entry.Name()))
+	}
+	return index, nil
+}
+
+// loadSkillIndex (re)builds the in-memory index of the current skill directory
+func (a *App) loadSkillIndex() error {
+	skillDir := a.getSkillDir()
+	index, err := scanSkills(skillDir)
+	if err != nil {
+		return err
+	}
+	a.indexMu.Lock()
+	a.skillIndex, a.skillIndexDir = index, skillDir
+	a.indexMu.Unlock()
+	return nil
+}
+
+// indexedSkills returns the skills sorted by directory name: from the index
+// while the watcher keeps it current, otherwise from a fresh scan
+func (a *App) indexedSkills() ([]SkillMeta, error) {
+	skillDir := a.getSkillDir()
+	a.watchMu.Lock()
+	watching := a.skillWatcher != nil && a.skillWatcher.Root() == filepath.Clean(skillDir)
+	a.watchMu.Unlock()
+
+	a.indexMu.Lock()
+	index := a.skillIndex
+	if a.skillIndexDir != skillDir {
+		index = nil
+	}
+	a.indexMu.Unlock()
+
+	if !watching || index == nil {
+		var err error
+		if index, err = scanSkills(skillDir); err != nil {
+			return nil, err
+		}
+		if watching {
+			a.indexMu.Lock()
+			a.skillIndex, a.skillIndexDir = index, skillDir
+			a.indexMu.Unlock()
+		}
+	}
+
+	a.indexMu.Lock()
+	names := make([]string, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	skills := make([]SkillMeta, 0, len(names))
+	for _, name := range names {
+		skills = append(skills, index[name])
+	}
+	a.indexMu.Unlock()
+	return skills, nil
+}
+
+// reindexSkills re-parses the given skills (directory names) and emits an
+// event for each one that was added, changed or removed. It does nothing
+// before the index is loaded.
+func (a *App) reindexSkills(names []string) {
+	skillDir := a.getSkillDir()
+	for _, name := range names {
+		if name == "" || strings.HasPrefix(name, ".") {
+			continue
+		}
+		dir := filepath.Join(skillDir, name)
+		var meta SkillMeta
+		info, err := os.Stat(dir)
+		exists := err == nil && info.IsDir()
+		if exists {
+			meta = indexSkill(dir)
+		}
+
+		a.indexMu.Lock()
+		if a.skillIndex == nil || a.skillIndexDir != skillDir {
+			a.indexMu.Unlock()
+			return
+		}
+		old, had := a.skillIndex[name]
+		event := ""
+		switch {
+		case exists && !had:
+			event = EventSkillAdded
+		case exists && !reflect.DeepEqual(old, meta):
+			event = EventSkillChanged
+		case !exists && had:
+			event = EventSkillRemoved
+		}
+		if exists {
+			a.skillIndex[name] = meta
+		} else {
+			delete(a.skillIndex, name)
+		}
+		a.indexMu.Unlock()
+
+		switch event {
+		case EventSkillAdded, EventSkillChanged:
+			meta.SyncedTools = detectSyncedTools(name, a.config.ToolPaths, a.config.CustomTools)
+			a.emitEvent(event, meta)
+		case EventSkillRemoved:
+			a.emitEvent(event, SkillRemovedEvent{Name: name})
+		}
+		if event != "" {
+			a.notifySkillListChanged()
+		}
+	}
+}
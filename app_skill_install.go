@@ -0,0 +1,402 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"skillui/internal/filelock"
+	"skillui/internal/skill"
+)
+
+// 技能安装流程：先在技能目录内的临时目录 .staging-<name>-* 中准备完整的新版本，
+// 校验通过后将旧版本重命名为 .backup-<name>-*，再把新版本换入；
+// 换入或安装后同步失败时用备份恢复旧版本。以 . 开头的目录不会出现在技能列表中。
+// 安装期间持有数据目录下 skill-install.lock 的共享锁；启动时的清理需要独占锁，
+// 因此不会清掉其他实例（窗口或命令行）正在进行的安装。
+
+const (
+	stagingPrefix = ".staging-"
+	backupPrefix  = ".backup-"
+	// skillInstallLockFile is held shared by installs and exclusively by recovery
+	skillInstallLockFile = "skill-install.lock"
+)
+
+// skillInstall is a single staged installation of one skill
+type skillInstall struct {
+	skillDir  string
+	name      string
+	stageRoot string
+	// Staged is the directory the new version is written into before Commit
+	Staged string
+	backup string
+	// committed is true once Staged has been swapped into place
+	committed bool
+	lock      *filelock.Lock
+}
+
+// validateSkillName rejects names that cannot be used as a skill directory
+func validateSkillName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("技能名称不能为空")
+	}
+	if name == "." || name == ".." || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\:*?"<>|`) {
+		return fmt.Errorf("非法的技能名称: %s", name)
+	}
+	return nil
+}
+
+// beginSkillInstall creates the staging directory for a skill
+func (a *App) beginSkillInstall(name string) (*skillInstall, error) {
+	if err := validateSkillName(name); err != nil {
+		return nil, err
+	}
+	skillDir := a.getSkillDir()
+	if err := os.MkdirAll(skillDir, 0755); err != nil {
+		return nil, err
+	}
+	lock, err := filelock.Shared(filepath.Join(a.dataDir, skillInstallLockFile))
+	if err != nil {
+		return nil, err
+	}
+	stageRoot, err := os.MkdirTemp(skillDir, stagingPrefix+name+"-*")
+	if err != nil {
+		lock.Unlock()
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	return &skillInstall{
+		skillDir:  skillDir,
+		name:      name,
+		stageRoot: stageRoot,
+		Staged:    filepath.Join(stageRoot, name),
+		lock:      lock,
+	}, nil
+}
+
+// DestDir returns the final location of the skill
+func (t *skillInstall) DestDir() string {
+	return filepath.Join(t.skillDir, t.name)
+}
+
+// Commit validates the staged skill and swaps it into place, keeping the
+// previous version as a backup until Finish or Rollback.
+func (t *skillInstall) Commit() error {
+	if err := validateStagedSkill(t.Staged); err != nil {
+		return err
+	}
+	dest := t.DestDir()
+	if _, err := os.Lstat(dest); err == nil {
+		t.backup = filepath.Join(t.skillDir, fmt.Sprintf("%s%s-%d", backupPrefix, t.name, time.Now().UnixNano()))
+		if err := os.Rename(dest, t.backup); err != nil {
+			t.backup = ""
+			return fmt.Errorf("备份旧版本失败: %w", err)
+		}
+	}
+	if err := os.Rename(t.Staged, dest); err != nil {
+		if t.backup != "" {
+			_ = os.Rename(t.backup, dest)
+			t.backup = ""
+		}
+		return fmt.Errorf("替换技能目录失败: %w", err)
+	}
+	t.committed = true
+	return nil
+}
+
+// Rollback discards the staged or committed new version and restores the backup
+func (t *skillInstall) Rollback() error {
+	defer t.lock.Unlock()
+	defer os.RemoveAll(t.stageRoot)
+	if !t.committed {
+		return nil
+	}
+	dest := t.DestDir()
+	if err := os.RemoveAll(dest); err != nil {
+		return fmt.Errorf("回滚失败: %w", err)
+	}
+	if t.backup != "" {
+		if err := os.Rename(t.backup, dest); err != nil {
+			return fmt.Errorf("恢复旧版本失败（备份保留在 %s）: %w", t.backup, err)
+		}
+		t.backup = ""
+	}
+	t.committed = false
+	return nil
+}
+
+// Finish removes the staging directory and the backup of the old version
+func (t *skillInstall) Finish() {
+	os.RemoveAll(t.stageRoot)
+	if t.backup != "" {
+		os.RemoveAll(t.backup)
+		t.backup = ""
+	}
+	t.lock.Unlock()
+}
+
+// validateStagedSkill performs the structural checks required before a skill
+// replaces an installed one. Content issues are left to the diagnostics.
+func validateStagedSkill(dir string) error {
+	for _, d := range skill.Validate(dir, skill.Limits{}) {
+		if d.Code == skill.CodeMissingSkillFile || d.Code == skill.CodeInvalidFrontmatter {
+			return fmt.Errorf("技能校验未通过: %s", d.Message)
+		}
+	}
+	return nil
+}
+
+// ConflictPolicy decides what an install does when a skill with the same name already exists
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing skill and skips the install
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the existing skill
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename installs under the first free name-2, name-3, ...
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictNamespace installs under owner__name
+	ConflictNamespace ConflictPolicy = "namespace"
+)
+
+// Install result statuses
+const (
+	InstallStatusInstalled   = "installed"
+	InstallStatusOverwritten = "overwritten"
+	InstallStatusRenamed     = "renamed"
+	InstallStatusNamespaced  = "namespaced"
+	InstallStatusSkipped     = "skipped"
+	InstallStatusFailed      = "failed"
+	// InstallStatusUpdated / InstallStatusUnchanged are reported when pulling updates of installed skills
+	InstallStatusUpdated   = "updated"
+	InstallStatusUnchanged = "unchanged"
+)
+
+// InstallResult reports what happened to one skill of an install
+type InstallResult struct {
+	// Source 为技能来源（仓库内路径、URL 或本地路径）
+	Source        string `json:"source"`
+	RequestedName string `json:"requestedName"`
+	// Name 为最终安装的技能名称（重命名/命名空间后可能与 RequestedName 不同）
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Error 为失败原因；安装成功但自动同步失败时为同步错误（Status 仍为成功状态）
+	Error string `json:"error,omitempty"`
+}
+
+// err converts a failed or skipped result into an error for the single-skill install methods
+func (r InstallResult) err() error {
+	switch r.Status {
+	case InstallStatusFailed:
+		return errors.New(r.Error)
+	case InstallStatusSkipped:
+		return fmt.Errorf("技能 %s 已存在，已跳过安装", r.RequestedName)
+	}
+	return nil
+}
+
+// parseConflictPolicy validates a policy string, empty means the configured default
+func (a *App) parseConflictPolicy(policy string) (ConflictPolicy, error) {
+	if policy == "" {
+		policy = a.config.InstallConflictPolicy
+	}
+	switch p := ConflictPolicy(policy); p {
+	case "":
+		return ConflictOverwrite, nil
+	case ConflictSkip, ConflictOverwrite, ConflictRename, ConflictNamespace:
+		return p, nil
+	default:
+		return "", fmt.Errorf("未知的冲突处理策略: %s", policy)
+	}
+}
+
+// skillInstallRequest describes one skill to install
+type skillInstallRequest struct {
+	Name   string
+	Source string
+	// Owner is the namespace used by ConflictNamespace; when empty the owner
+	// field of the staged SKILL.md is used
+	Owner  string
+	Policy ConflictPolicy
+	// Fill writes the new version into the staging dir
+	Fill func(staged string) error
+	// AfterCommit (optional) runs with the new version in place under its final
+	// name; an error from it rolls the install back. Only the skill dir is
+	// restored, so steps that write into tool directories run after the
+	// install instead.
+	AfterCommit func(name string) error
+}
+
+// installSkill stages, validates, resolves name conflicts and swaps a skill into place
+func (a *App) installSkill(req skillInstallRequest) (result InstallResult) {
+	result = InstallResult{Source: req.Source, RequestedName: req.Name, Name: req.Name}
+	if req.Policy == "" {
+		req.Policy = ConflictOverwrite
+	}
+	fail := func(err error) InstallResult {
+		result.Status = InstallStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	txn, err := a.beginSkillInstall(req.Name)
+	if err != nil {
+		return fail(err)
+	}
+	defer func() {
+		if result.Status != InstallStatusFailed && result.Status != InstallStatusSkipped {
+			txn.Finish()
+			a.reindexSkills([]string{txn.name})
+			return
+		}
+		if rbErr := txn.Rollback(); rbErr != nil {
+			a.LogSystemError("installSkill", fmt.Sprintf("Failed to roll back skill %s: %v", txn.name, rbErr))
+			result.Error = errors.Join(errors.New(result.Error), rbErr).Error()
+		}
+	}()
+
+	if err := req.Fill(txn.Staged); err != nil {
+		return fail(err)
+	}
+
+	result.Status = InstallStatusInstalled
+	if _, err := os.Lstat(txn.DestDir()); err == nil {
+		switch req.Policy {
+		case ConflictSkip:
+			result.Status = InstallStatusSkipped
+			return result
+		case ConflictOverwrite:
+			result.Status = InstallStatusOverwritten
+		case ConflictRename:
+			txn.name = a.freeSkillName(req.Name)
+			result.Status = InstallStatusRenamed
+		case ConflictNamespace:
+			owner := req.Owner
+			if owner == "" {
+				if doc, err := skill.ParseFile(filepath.Join(txn.Staged, "SKILL.md")); err == nil {
+					owner = doc.Metadata.Owner
+				}
+			}
+			owner = sanitizeSkillName(owner)
+			if owner == "" {
+				return fail(fmt.Errorf("技能 %s 已存在，且无法确定命名空间（owner）", req.Name))
+			}
+			txn.name = a.freeSkillName(owner + "__" + req.Name)
+			result.Status = InstallStatusNamespaced
+		}
+		result.Name = txn.name
+		// skillui.json 中记录的名称与改名后的目录保持一致
+		if sj, err := readSkillUIJson(txn.Staged); err == nil && sj.Name != txn.name {
+			sj.Name = txn.name
+			if err := writeSkillUIJson(txn.Staged, sj); err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	if err := txn.Commit(); err != nil {
+		return fail(err)
+	}
+	if req.AfterCommit != nil {
+		if err := req.AfterCommit(txn.name); err != nil {
+			return fail(err)
+		}
+	}
+	return result
+}
+
+// summarizeInstallResults joins the failures/skips of an install into one message
+func summarizeInstallResults(results []InstallResult) string {
+	msgs := make([]string, 0, len(results))
+	for _, r := range results {
+		if err := r.err(); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", r.RequestedName, err))
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// freeSkillName returns name itself if unused, otherwise the first unused name-N
+func (a *App) freeSkillName(name string) string {
+	skillDir := a.getSkillDir()
+	candidate := name
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(filepath.Join(skillDir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+// sanitizeSkillName turns an arbitrary string (owner, repo name) into a safe
+// directory name component
+func sanitizeSkillName(name string) string {
+	name = strings.TrimSpace(name)
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>| `, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	return strings.TrimLeft(name, ".-")
+}
+
+// recoverSkillInstalls cleans up after an install that was interrupted
+// (crash, power loss): leftover staging dirs are removed and a backup is
+// restored when its skill directory is missing. It is skipped while another
+// instance holds the install lock, i.e. is in the middle of an install.
+func (a *App) recoverSkillInstalls() {
+	lock, err := filelock.TryExclusive(filepath.Join(a.dataDir, skillInstallLockFile))
+	if err != nil || lock == nil {
+		return
+	}
+	defer lock.Unlock()
+	skillDir := a.getSkillDir()
+	entries, err := os.ReadDir(skillDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(skillDir, name)
+		switch {
+		case strings.HasPrefix(name, stagingPrefix):
+			os.RemoveAll(path)
+		case strings.HasPrefix(name, backupPrefix):
+			skillName := strings.TrimPrefix(name, backupPrefix)
+			if idx := strings.LastIndex(skillName, "-"); idx > 0 {
+				skillName = skillName[:idx]
+			}
+			dest := filepath.Join(skillDir, skillName)
+			if _, err := os.Stat(dest); os.IsNotExist(err) {
+				if err := os.Rename(path, dest); err == nil {
+					a.LogSystemError("recoverSkillInstalls", "Restored skill "+skillName+" from interrupted install backup")
+					continue
+				}
+			}
+			os.RemoveAll(path)
+		}
+	}
+}
+
+// GetInstallConflictPolicy returns the default conflict policy used by installs
+func (a *App) GetInstallConflictPolicy() string {
+	p, _ := a.parseConflictPolicy("")
+	return string(p)
+}
+
+// SetInstallConflictPolicy saves the default conflict policy used by installs
+func (a *App) SetInstallConflictPolicy(policy string) error {
+	if policy == "" {
+		return fmt.Errorf("冲突处理策略不能为空")
+	}
+	p, err := a.parseConflictPolicy(policy)
+	if err != nil {
+		return err
+	}
+	a.config.InstallConflictPolicy = string(p)
+	return a.store.Save(a.config)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSkillMdV2 = "---\nname: demo\ndescription: Formats Go code and explains every change it makes\n---\n# Demo v2\n"
+
+// fillSkill returns a Fill that stages a skill with the given SKILL.md
+func fillSkill(content string) func(string) error {
+	return func(staged string) error {
+		if err := os.MkdirAll(staged, 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(staged, "SKILL.md"), []byte(content), 0o644)
+	}
+}
+
+// assertSkillDirClean fails when staging or backup dirs are left behind
+func assertSkillDirClean(t *testing.T, a *App) {
+	t.Helper()
+	entries, err := os.ReadDir(a.getSkillDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), stagingPrefix) || strings.HasPrefix(e.Name(), backupPrefix) {
+			t.Errorf("left behind: %s", e.Name())
+		}
+	}
+}
+
+func readSkillMd(t *testing.T, a *App, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(a.getSkillDir(), name, "SKILL.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestInstallSkillOverwrite(t *testing.T) {
+	a, _ := newTestApp(t)
+	writeTestSkill(t, a, "demo")
+	var seen string
+	result := a.installSkill(skillInstallRequest{
+		Name: "demo",
+		Fill: fillSkill(testSkillMdV2),
+		AfterCommit: func(name string) error {
+			// 新版本已就位，旧版本仍在备份中
+			seen = readSkillMd(t, a, name)
+			return nil
+		},
+	})
+	if err := result.err(); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != InstallStatusOverwritten {
+		t.Errorf("Status = %s, want %s", result.Status, InstallStatusOverwritten)
+	}
+	if seen != testSkillMdV2 {
+		t.Errorf("AfterCommit saw %q", seen)
+	}
+	if got := readSkillMd(t, a, "demo"); got != testSkillMdV2 {
+		t.Errorf("SKILL.md = %q, want the new version", got)
+	}
+	assertSkillDirClean(t, a)
+}
+
+func TestInstallSkillRollback(t *testing.T) {
+	tests := []struct {
+		name string
+		req  skillInstallRequest
+	}{
+		{
+			name: "AfterCommit fails",
+			req: skillInstallRequest{
+				Fill:        fillSkill(testSkillMdV2),
+				AfterCommit: func(string) error { return errors.New("sync failed") },
+			},
+		},
+		{
+			name: "Fill fails",
+			req: skillInstallRequest{
+				Fill: func(staged string) error {
+					if err := fillSkill(testSkillMdV2)(staged); err != nil {
+						return err
+					}
+					return errors.New("download interrupted")
+				},
+			},
+		},
+		{
+			name: "staged skill without SKILL.md",
+			req: skillInstallRequest{
+				Fill: func(staged string) error { return os.MkdirAll(staged, 0o755) },
+			},
+		},
+		{
+			name: "staged skill with broken frontmatter",
+			req: skillInstallRequest{
+				Fill: fillSkill("---\nname: [demo\n---\n"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newTestApp(t)
+			writeTestSkill(t, a, "demo")
+			writeTestFile(t, filepath.Join(a.getSkillDir(), "demo", "notes.md"), "mine")
+			tt.req.Name = "demo"
+			result := a.installSkill(tt.req)
+			if result.Status != InstallStatusFailed || result.Error == "" {
+				t.Fatalf("result = %+v, want a failure", result)
+			}
+			// 旧版本（含其中的其他文件）原样恢复
+			if got := readSkillMd(t, a, "demo"); got != testSkillMd {
+				t.Errorf("SKILL.md = %q, want the old version", got)
+			}
+			if _, err := os.Stat(filepath.Join(a.getSkillDir(), "demo", "notes.md")); err != nil {
+				t.Errorf("notes.md of the old version lost: %v", err)
+			}
+			assertSkillDirClean(t, a)
+		})
+	}
+}
+
+func TestInstallSkillRollbackNewSkill(t *testing.T) {
+	a, _ := newTestApp(t)
+	result := a.installSkill(skillInstallRequest{
+		Name:        "demo",
+		Fill:        fillSkill(testSkillMd),
+		AfterCommit: func(string) error { return errors.New("sync failed") },
+	})
+	if result.Status != InstallStatusFailed {
+		t.Fatalf("result = %+v, want a failure", result)
+	}
+	if _, err := os.Lstat(filepath.Join(a.getSkillDir(), "demo")); !os.IsNotExist(err) {
+		t.Error("failed install of a new skill left its directory")
+	}
+	assertSkillDirClean(t, a)
+}
+
+func TestInstallSkillConflictPolicies(t *testing.T) {
+	tests := []struct {
+		policy     ConflictPolicy
+		wantStatus string
+		wantName   string
+	}{
+		{ConflictSkip, InstallStatusSkipped, "demo"},
+		{ConflictRename, InstallStatusRenamed, "demo-2"},
+		{ConflictNamespace, InstallStatusNamespaced, "acme__demo"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			a, _ := newTestApp(t)
+			writeTestSkill(t, a, "demo")
+			result := a.installSkill(skillInstallRequest{
+				Name:   "demo",
+				Owner:  "acme",
+				Policy: tt.policy,
+				Fill:   fillSkill(testSkillMdV2),
+			})
+			if result.Status != tt.wantStatus || result.Name != tt.wantName {
+				t.Fatalf("result = %+v, want %s as %s", result, tt.wantStatus, tt.wantName)
+			}
+			// 已有技能不受影响
+			if got := readSkillMd(t, a, "demo"); got != testSkillMd {
+				t.Errorf("existing SKILL.md = %q", got)
+			}
+			if tt.policy != ConflictSkip {
+				if got := readSkillMd(t, a, tt.wantName); got != testSkillMdV2 {
+					t.Errorf("%s/SKILL.md = %q, want the new version", tt.wantName, got)
+				}
+			}
+			assertSkillDirClean(t, a)
+		})
+	}
+}
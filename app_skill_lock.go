@@ -0,0 +1,608 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"skillui/internal/config"
+	"skillui/internal/lockfile"
+	"skillui/internal/skill"
+)
+
+// 锁定文件：skills.lock.json 记录每个技能的精确来源（市场 ID + 版本、git 仓库 + 提交 + 子目录、URL + 哈希）、
+// 同步到的工具以及全局激活的集合。团队将其提交到仓库后，在每台机器上“应用锁定文件”即可安装、升级
+// 技能并同步到对应工具，直到本机与锁定文件一致；锁定文件中没有的技能与集合仅在显式指定 Prune 时删除。
+// 本地技能无法从锁定文件还原，只检查是否已安装，不比较内容哈希。项目级同步与项目集合依赖本机路径，不写入锁定文件。
+
+// Lockfile actions
+const (
+	LockActionInstall    = "install"
+	LockActionUpdate     = "update"
+	LockActionRemove     = "remove"
+	LockActionSync       = "sync"
+	LockActionUnsync     = "unsync"
+	LockActionCreate     = "create"
+	LockActionActivate   = "activate"
+	LockActionDeactivate = "deactivate"
+	LockActionSkip       = "skip"
+)
+
+// LockAction is one step needed to make this machine match a lockfile
+type LockAction struct {
+	Skill      string `json:"skill,omitempty"`
+	Collection string `json:"collection,omitempty"`
+	ToolID     string `json:"toolId,omitempty"`
+	// Action: install / update / remove / sync / unsync / create / activate / deactivate / skip
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+	// Applied 表示操作已执行（预览模式下始终为 false）
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+// LockApplyOptions controls ApplyLockfile
+type LockApplyOptions struct {
+	// Path 为锁定文件路径，为空时使用数据目录下的 skills.lock.json
+	Path string `json:"path"`
+	// DryRun 只返回将要执行的操作，不修改任何文件
+	DryRun bool `json:"dryRun"`
+	// Prune 删除锁定文件中没有的技能与集合，默认保留
+	Prune bool `json:"prune"`
+}
+
+// LockApplyResult is the outcome of ApplyLockfile
+type LockApplyResult struct {
+	Path    string       `json:"path"`
+	Actions []LockAction `json:"actions"`
+	// InSync 表示本机与锁定文件一致：无需操作，或所有操作均已成功执行
+	InSync bool `json:"inSync"`
+}
+
+// lockfilePath resolves the lockfile path (default: skills.lock.json in the data dir)
+func (a *App) lockfilePath(path string) string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return filepath.Join(a.dataDir, lockfile.FileName)
+	}
+	return expandHome(path)
+}
+
+// ExportLockfile writes the installed skills, their sources and synced tools
+// and the globally active collections to a lockfile and returns its path
+func (a *App) ExportLockfile(path string) (string, error) {
+	path = a.lockfilePath(path)
+	lock, err := a.buildLockfile()
+	if err != nil {
+		return "", err
+	}
+	if err := lockfile.Write(path, lock); err != nil {
+		return "", fmt.Errorf("写入锁定文件失败: %w", err)
+	}
+	return path, nil
+}
+
+// buildLockfile describes the current machine as a lockfile
+func (a *App) buildLockfile() (lockfile.File, error) {
+	names, err := a.syncableSkillNames(nil)
+	if err != nil {
+		return lockfile.File{}, err
+	}
+	lock := lockfile.File{Version: lockfile.Version, Skills: make([]lockfile.Skill, 0, len(names))}
+	for _, name := range names {
+		dir := filepath.Join(a.getSkillDir(), name)
+		sj, _ := readSkillUIJson(dir)
+		source := lockedSource(sj)
+		// 优先使用安装时的内容哈希，这样本地修改会在应用锁定文件时被发现并还原；
+		// 本地技能无法从锁定文件还原，不记录哈希
+		treeHash := sj.TreeHash
+		if source.Type == lockfile.SourceLocal {
+			treeHash = ""
+		} else if treeHash == "" {
+			if tree, err := skill.HashTree(dir); err == nil {
+				treeHash = tree.Hash
+			}
+		}
+		lock.Skills = append(lock.Skills, lockfile.Skill{
+			Name:     name,
+			Source:   source,
+			TreeHash: treeHash,
+			Tools:    a.syncedToolIDs(name),
+		})
+	}
+	for _, c := range a.config.Collections {
+		locked := lockfile.Collection{Name: c.Name, Description: c.Description, Skills: append([]string{}, c.Skills...)}
+		for _, act := range a.config.ActiveCollections {
+			if act.CollectionID == c.ID && act.ProjectID == "" {
+				locked.Tools = append(locked.Tools, act.ToolID)
+			}
+		}
+		lock.Collections = append(lock.Collections, locked)
+	}
+	return lock, nil
+}
+
+// lockedSource converts the skillui.json of an installed skill to a lockfile source
+func lockedSource(sj skillUIJson) lockfile.Source {
+	switch {
+	case sj.MarketID > 0:
+		return lockfile.Source{Type: lockfile.SourceMarket, MarketID: sj.MarketID, Version: sj.Version, Hash: sj.Md5}
+	case sj.Source != nil && sj.Source.Type == SourceTypeGit:
+		return lockfile.Source{Type: lockfile.SourceGit, RepoURL: sj.Source.RepoURL, Ref: sj.Source.Ref, Commit: sj.Source.Commit, Subpath: sj.Source.Path}
+	case sj.Source != nil && sj.Source.Type == SourceTypeURL:
+		return lockfile.Source{Type: lockfile.SourceURL, URL: sj.Source.URL, Hash: sj.Md5}
+	}
+	return lockfile.Source{Type: lockfile.SourceLocal}
+}
+
+// sameLockedSource reports whether an installed source satisfies a locked one
+func sameLockedSource(installed, locked lockfile.Source) bool {
+	if installed.Type != locked.Type {
+		return false
+	}
+	sameHash := locked.Hash == "" || strings.EqualFold(installed.Hash, locked.Hash)
+	switch locked.Type {
+	case lockfile.SourceMarket:
+		return installed.MarketID == locked.MarketID && sameHash
+	case lockfile.SourceGit:
+		if installed.RepoURL != locked.RepoURL || installed.Subpath != locked.Subpath {
+			return false
+		}
+		if locked.Commit == "" {
+			return installed.Ref == locked.Ref
+		}
+		// 锁定文件中的提交可能是缩写
+		return installed.Commit != "" && (strings.HasPrefix(installed.Commit, locked.Commit) || strings.HasPrefix(locked.Commit, installed.Commit))
+	case lockfile.SourceURL:
+		return installed.URL == locked.URL && sameHash
+	}
+	return true
+}
+
+// describeLockedSource formats a source for action reasons
+func describeLockedSource(s lockfile.Source) string {
+	switch s.Type {
+	case lockfile.SourceMarket:
+		if s.Version != "" {
+			return fmt.Sprintf("市场 #%d v%s", s.MarketID, s.Version)
+		}
+		return fmt.Sprintf("市场 #%d", s.MarketID)
+	case lockfile.SourceGit:
+		commit := s.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		if commit == "" {
+			commit = s.Ref
+		}
+		return fmt.Sprintf("%s@%s:%s", s.RepoURL, commit, s.Subpath)
+	case lockfile.SourceURL:
+		return s.URL
+	}
+	return "本地"
+}
+
+// lockedSkillAction compares an installed skill with its locked entry and
+// returns the action needed (empty when it matches) and why
+func (a *App) lockedSkillAction(s lockfile.Skill) (string, string) {
+	dir := filepath.Join(a.getSkillDir(), s.Name)
+	if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err != nil {
+		return LockActionInstall, "未安装"
+	}
+	sj, _ := readSkillUIJson(dir)
+	if installed := lockedSource(sj); !sameLockedSource(installed, s.Source) {
+		return LockActionUpdate, fmt.Sprintf("来源不一致: 本机 %s，锁定 %s", describeLockedSource(installed), describeLockedSource(s.Source))
+	}
+	// 本地技能只要已安装即视为一致，其内容随本机修改
+	if s.TreeHash != "" && s.Source.Type != lockfile.SourceLocal {
+		if tree, err := skill.HashTree(dir); err == nil && tree.Hash != s.TreeHash {
+			return LockActionUpdate, "技能内容与锁定版本不一致"
+		}
+	}
+	return "", ""
+}
+
+// ApplyLockfile installs and updates skills, syncs them to tools and applies
+// collections until this machine matches the lockfile. Skills and collections
+// missing from the lockfile are only removed with Prune. With DryRun the
+// planned actions are returned without changing anything.
+func (a *App) ApplyLockfile(opts LockApplyOptions) (LockApplyResult, error) {
+	path := a.lockfilePath(opts.Path)
+	lock, err := lockfile.Read(path)
+	if err != nil {
+		return LockApplyResult{}, fmt.Errorf("读取锁定文件失败: %w", err)
+	}
+	for _, s := range lock.Skills {
+		if err := validateSkillName(s.Name); err != nil {
+			return LockApplyResult{}, err
+		}
+	}
+	installedNames, err := a.syncableSkillNames(nil)
+	if err != nil {
+		return LockApplyResult{}, err
+	}
+	isInstalled := make(map[string]bool, len(installedNames))
+	for _, name := range installedNames {
+		isInstalled[name] = true
+	}
+	tools, err := a.ScanIDETools()
+	if err != nil {
+		return LockApplyResult{}, err
+	}
+	toolInstalled := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		toolInstalled[t.ID] = t.Installed
+	}
+
+	result := LockApplyResult{Path: path, Actions: []LockAction{}}
+	// record adds an action and runs it unless this is a dry run; it reports
+	// whether the action succeeded (or would be run)
+	record := func(act LockAction, run func() error) bool {
+		ok := act.Error == ""
+		if ok && !opts.DryRun && run != nil {
+			if err := run(); err != nil {
+				act.Error = err.Error()
+				ok = false
+			} else {
+				act.Applied = true
+			}
+		}
+		result.Actions = append(result.Actions, act)
+		return ok
+	}
+	// checkTool returns the reason a tool cannot be a sync target here, if any
+	checkTool := func(toolID string) (string, string) {
+		installed, known := toolInstalled[toolID]
+		switch {
+		case !known:
+			return "", fmt.Sprintf("未知的工具: %s", toolID)
+		case !installed:
+			return "本机未安装该工具", ""
+		}
+		return "", ""
+	}
+
+	locked := make(map[string]bool, len(lock.Skills))
+	for _, s := range lock.Skills {
+		s := s
+		locked[s.Name] = true
+		present := isInstalled[s.Name]
+		changed := false
+		var projectScopes map[string][]string
+		if action, reason := a.lockedSkillAction(s); action != "" {
+			if present {
+				// 项目级同步不在锁定文件中，内容更新后按更新前的同步状态重新同步
+				projectScopes = a.syncedScopes(s.Name)
+				delete(projectScopes, "")
+			}
+			act := LockAction{Skill: s.Name, Action: action, Reason: reason}
+			if s.Source.Type == lockfile.SourceLocal {
+				act.Action = LockActionSkip
+				act.Error = "本地技能无法从锁定文件安装，请手动复制到技能目录"
+				if action == LockActionUpdate {
+					act.Error = "本地技能无法从锁定文件还原，请手动处理"
+				}
+			}
+			if record(act, func() error { return a.installLockedSkill(s) }) {
+				present, changed = true, true
+			}
+		}
+		if !present {
+			continue
+		}
+		if changed && !opts.DryRun && len(projectScopes) > 0 {
+			if err := a.resyncScopes(s.Name, projectScopes); err != nil {
+				record(LockAction{Skill: s.Name, Action: LockActionSync, Reason: "更新后重新同步项目", Error: err.Error()}, nil)
+			}
+		}
+
+		var synced []string
+		if isInstalled[s.Name] {
+			synced = a.syncedToolIDs(s.Name)
+		}
+		isSynced := make(map[string]bool, len(synced))
+		for _, toolID := range synced {
+			isSynced[toolID] = true
+		}
+		wanted := make(map[string]bool, len(s.Tools))
+		for _, toolID := range s.Tools {
+			wanted[toolID] = true
+			if isSynced[toolID] {
+				// 技能内容更新后重新同步，保持工具中的副本一致
+				if changed && !opts.DryRun {
+					if err := a.syncSkillToTools("", s.Name, []string{toolID}); err != nil {
+						record(LockAction{Skill: s.Name, ToolID: toolID, Action: LockActionSync, Reason: "更新后重新同步", Error: err.Error()}, nil)
+					}
+				}
+				continue
+			}
+			act := LockAction{Skill: s.Name, ToolID: toolID, Action: LockActionSync}
+			if reason, errMsg := checkTool(toolID); reason != "" || errMsg != "" {
+				act.Action, act.Reason, act.Error = LockActionSkip, reason, errMsg
+				if errMsg == "" {
+					// 未安装的工具不视为不一致
+					continue
+				}
+			}
+			record(act, func() error { return a.syncSkillToTools("", s.Name, []string{toolID}) })
+		}
+		for _, toolID := range synced {
+			if wanted[toolID] {
+				continue
+			}
+			toolID := toolID
+			record(LockAction{Skill: s.Name, ToolID: toolID, Action: LockActionUnsync}, func() error {
+				report, err := a.unsyncSkill("", s.Name, []string{toolID})
+				if err == nil && len(report.Refused) > 0 {
+					err = fmt.Errorf("保留了不是由 SkillUI 创建或已被修改的文件: %s", report.refusedSummary())
+				}
+				return err
+			})
+		}
+	}
+
+	if opts.Prune {
+		for _, name := range installedNames {
+			if locked[name] {
+				continue
+			}
+			name := name
+			record(LockAction{Skill: name, Action: LockActionRemove, Reason: "不在锁定文件中"}, func() error {
+				report, err := a.DeleteSkillWithReport(name)
+				if err == nil && len(report.Refused) > 0 {
+					err = fmt.Errorf("保留了不是由 SkillUI 创建或已被修改的文件: %s", report.refusedSummary())
+				}
+				return err
+			})
+		}
+	}
+
+	a.applyLockedCollections(lock.Collections, opts, record, checkTool)
+
+	result.InSync = true
+	for _, act := range result.Actions {
+		if !act.Applied || act.Error != "" {
+			result.InSync = false
+		}
+	}
+	return result, nil
+}
+
+// applyLockedCollections creates or updates the locked collections and makes
+// them active on exactly the locked tools (globally)
+func (a *App) applyLockedCollections(collections []lockfile.Collection, opts LockApplyOptions,
+	record func(LockAction, func() error) bool, checkTool func(string) (string, string)) {
+	findByName := func(name string) int {
+		for i, c := range a.config.Collections {
+			if strings.EqualFold(c.Name, name) {
+				return i
+			}
+		}
+		return -1
+	}
+	// collectionID looks the collection up when the action runs, since it may
+	// have been created by an earlier action
+	collectionID := func(name string) (string, error) {
+		idx := findByName(name)
+		if idx < 0 {
+			return "", fmt.Errorf("集合不存在: %s", name)
+		}
+		return a.config.Collections[idx].ID, nil
+	}
+
+	listed := map[string]bool{}
+	for _, lc := range collections {
+		lc := lc
+		listed[strings.ToLower(strings.TrimSpace(lc.Name))] = true
+		idx := findByName(lc.Name)
+		existingID := ""
+		switch {
+		case idx < 0:
+			record(LockAction{Collection: lc.Name, Action: LockActionCreate}, func() error {
+				_, err := a.AddCollection(lc.Name, lc.Description, lc.Skills)
+				return err
+			})
+		case a.config.Collections[idx].Description != lc.Description || !sameStrings(a.config.Collections[idx].Skills, lc.Skills):
+			existingID = a.config.Collections[idx].ID
+			updated := a.config.Collections[idx]
+			updated.Description, updated.Skills = lc.Description, lc.Skills
+			record(LockAction{Collection: lc.Name, Action: LockActionUpdate, Reason: "集合内容与锁定文件不一致"}, func() error {
+				results, err := a.UpdateCollection(updated)
+				if err != nil {
+					return err
+				}
+				return collectionResultsErr(results)
+			})
+		default:
+			existingID = a.config.Collections[idx].ID
+		}
+
+		wanted := map[string]bool{}
+		for _, toolID := range lc.Tools {
+			wanted[toolID] = true
+			active := false
+			for _, act := range a.config.ActiveCollections {
+				if act.ProjectID == "" && act.ToolID == toolID && act.CollectionID == existingID && existingID != "" {
+					active = true
+				}
+			}
+			if active {
+				continue
+			}
+			act := LockAction{Collection: lc.Name, ToolID: toolID, Action: LockActionActivate}
+			if reason, errMsg := checkTool(toolID); reason != "" || errMsg != "" {
+				if errMsg == "" {
+					continue
+				}
+				act.Action, act.Error = LockActionSkip, errMsg
+			}
+			toolID := toolID
+			record(act, func() error {
+				id, err := collectionID(lc.Name)
+				if err != nil {
+					return err
+				}
+				results, err := a.ActivateCollection(id, "", []string{toolID})
+				if err != nil {
+					return err
+				}
+				return collectionResultsErr(results)
+			})
+		}
+		if existingID == "" {
+			continue
+		}
+		for _, act := range a.config.ActiveCollections {
+			if act.ProjectID != "" || act.CollectionID != existingID || wanted[act.ToolID] {
+				continue
+			}
+			toolID := act.ToolID
+			record(LockAction{Collection: lc.Name, ToolID: toolID, Action: LockActionDeactivate}, func() error {
+				return a.DeactivateCollection("", toolID)
+			})
+		}
+	}
+
+	if !opts.Prune {
+		return
+	}
+	// 先复制一份，删除操作会修改配置中的集合列表
+	configured := append([]config.Collection{}, a.config.Collections...)
+	for _, c := range configured {
+		if listed[strings.ToLower(c.Name)] {
+			continue
+		}
+		id := c.ID
+		record(LockAction{Collection: c.Name, Action: LockActionRemove, Reason: "不在锁定文件中"}, func() error {
+			return a.RemoveCollection(id)
+		})
+	}
+}
+
+// collectionResultsErr joins the errors reported while applying a collection
+func collectionResultsErr(results []CollectionApplyResult) error {
+	var errs []string
+	for _, r := range results {
+		errs = append(errs, r.Errors...)
+		if len(r.Missing) > 0 {
+			errs = append(errs, fmt.Sprintf("%s: 未安装的技能 %s", r.ToolID, strings.Join(r.Missing, ", ")))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// installLockedSkill installs the exact locked version of a skill under its
+// locked name, replacing what is installed there
+func (a *App) installLockedSkill(s lockfile.Skill) error {
+	src := s.Source
+	switch src.Type {
+	case lockfile.SourceMarket:
+		download, err := fetchMarketDownload(src.MarketID)
+		if err != nil {
+			return fmt.Errorf("获取下载地址失败: %w", err)
+		}
+		// 市场只提供最新版本，与锁定的哈希不一致时无法安装锁定的版本
+		if src.Hash != "" && !strings.EqualFold(download.Md5, src.Hash) {
+			return fmt.Errorf("市场当前版本与锁定版本 %s 不一致，请更新锁定文件", src.Version)
+		}
+		record, err := fetchMarketSkill(src.MarketID)
+		if err != nil {
+			return fmt.Errorf("获取市场技能信息失败: %w", err)
+		}
+		old, _ := readSkillUIJson(filepath.Join(a.getSkillDir(), s.Name))
+		req := skillInstallRequest{Name: s.Name, Source: download.Url, Owner: record.Owner, Policy: ConflictOverwrite}
+		return a.installSkillFromUrl(download.Url, download.Md5, req, func(staged, archiveMd5 string) error {
+			tree, err := skill.HashTree(staged)
+			if err != nil {
+				return fmt.Errorf("计算技能哈希失败: %w", err)
+			}
+			now := time.Now().Format(skillUITimeLayout)
+			sj := skillUIJson{
+				MarketID:    src.MarketID,
+				Name:        s.Name,
+				TitleEn:     record.TitleEn,
+				TitleZh:     record.TitleZh,
+				DescEn:      record.DescEn,
+				DescZh:      record.DescZh,
+				Owner:       record.Owner,
+				Version:     record.Version,
+				Md5:         archiveMd5,
+				TreeHash:    tree.Hash,
+				Files:       tree.Files,
+				InstalledAt: now,
+			}
+			if old.MarketID == src.MarketID && old.Md5 != archiveMd5 {
+				sj.History = append(old.History, skillUIHistory{
+					Version:     old.Version,
+					Md5:         old.Md5,
+					InstalledAt: old.InstalledAt,
+					ReplacedAt:  now,
+				})
+			}
+			return writeSkillUIJson(staged, sj)
+		}).err()
+
+	case lockfile.SourceGit:
+		ref := src.Commit
+		if ref == "" {
+			ref = src.Ref
+		}
+		tmpDir, commit, err := gitCheckout(src.RepoURL, ref)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		if src.Commit != "" && !strings.HasPrefix(commit, src.Commit) {
+			return fmt.Errorf("检出的提交 %s 与锁定的提交 %s 不一致", commit, src.Commit)
+		}
+		subDir := filepath.Join(tmpDir, filepath.FromSlash(src.Subpath))
+		if subDir != tmpDir && !strings.HasPrefix(subDir, tmpDir+string(os.PathSeparator)) {
+			return fmt.Errorf("非法的子目录: %s", src.Subpath)
+		}
+		if _, err := os.Stat(filepath.Join(subDir, "SKILL.md")); err != nil {
+			return fmt.Errorf("仓库中不存在该技能: %s", src.Subpath)
+		}
+		source := SkillSource{
+			Type:    SourceTypeGit,
+			RepoURL: src.RepoURL,
+			Ref:     src.Ref,
+			Commit:  commit,
+			Subpath: src.Subpath,
+			Path:    src.Subpath,
+		}
+		return a.installSkill(skillInstallRequest{
+			Name:   s.Name,
+			Source: src.Subpath,
+			Owner:  repoOwner(src.RepoURL),
+			Policy: ConflictOverwrite,
+			Fill: func(staged string) error {
+				return stageGitSkill(subDir, staged, s.Name, source)
+			},
+		}).err()
+
+	case lockfile.SourceURL:
+		req := skillInstallRequest{Name: s.Name, Source: src.URL, Policy: ConflictOverwrite}
+		return a.installSkillFromUrl(src.URL, src.Hash, req, func(staged, archiveMd5 string) error {
+			return writeURLSkillUIJson(staged, s.Name, src.URL, archiveMd5)
+		}).err()
+	}
+	return fmt.Errorf("本地技能无法从锁定文件安装，请手动复制到技能目录")
+}
+
+// sameStrings reports whether two string lists have the same items in the same order
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skillui/internal/adapter"
+	"skillui/internal/manifest"
+	"skillui/internal/skill"
+)
+
+// 同步清单：记录 SkillUI 放入工具规则目录的每个文件 / 目录 / 链接（工具、路径、链接目标、内容哈希、时间）。
+// 取消同步与删除技能只处理清单中且未被修改的条目，不是 SkillUI 创建的同名文件一律保留并在报告中列出。
+// 旧版本创建、指向技能目录的符号链接会在启动时被收编进清单。
+
+// syncManifestFile is the manifest file name inside the data dir
+const syncManifestFile = "sync_manifest.json"
+
+// RefusedFile is a file in a tool directory that SkillUI left untouched
+// because it did not create it (or it was modified after syncing)
+type RefusedFile struct {
+	Skill  string `json:"skill"`
+	ToolID string `json:"toolId"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// SyncRemovalReport lists what an unsync or delete removed and what it refused to touch
+type SyncRemovalReport struct {
+	Removed []string      `json:"removed"`
+	Refused []RefusedFile `json:"refused"`
+}
+
+func newSyncRemovalReport() SyncRemovalReport {
+	return SyncRemovalReport{Removed: []string{}, Refused: []RefusedFile{}}
+}
+
+// refusedSummary joins the refused paths into one message
+func (r SyncRemovalReport) refusedSummary() string {
+	msgs := make([]string, 0, len(r.Refused))
+	for _, f := range r.Refused {
+		msgs = append(msgs, fmt.Sprintf("%s（%s）", f.Path, f.Reason))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// syncManifest returns the sync manifest, loading it on first use
+func (a *App) syncManifest() *manifest.Manifest {
+	a.manifestOnce.Do(func() {
+		a.manifest = manifest.Load(filepath.Join(a.dataDir, syncManifestFile))
+	})
+	return a.manifest
+}
+
+// ListSyncManifest returns every entry SkillUI has placed into tool directories
+func (a *App) ListSyncManifest() []manifest.Entry {
+	return a.syncManifest().Entries(nil)
+}
+
+// recordPlaced records the primary entry just rendered for a skill in a tool
+func (a *App) recordPlaced(projectRoot, skillName string, def ideToolDef) error {
+	path := filepath.Join(def.rulesDirIn(projectRoot), def.adapter().Paths(skillName)[0])
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	entry := manifest.Entry{Skill: skillName, ToolID: def.ID, Project: projectRoot, Path: path}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		entry.Kind = manifest.KindLink
+		entry.Target, _ = os.Readlink(path)
+	case info.IsDir():
+		entry.Kind = manifest.KindDir
+	default:
+		entry.Kind = manifest.KindFile
+	}
+	if entry.Kind != manifest.KindLink {
+		if entry.Hash, err = adapter.HashPlaced(path); err != nil {
+			return err
+		}
+	}
+	return a.syncManifest().Put(entry)
+}
+
+// checkOwned decides whether the entry at path belongs to SkillUI: it is in
+// the manifest and unchanged since, or it is a legacy symlink to (or copy of)
+// the skill.
+// A missing path counts as owned. The reason explains a refusal.
+func (a *App) checkOwned(skillName, path string) (bool, string) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return true, ""
+	}
+	isLink := info.Mode()&os.ModeSymlink != 0
+	entry, ok := a.syncManifest().Get(path)
+	if !ok {
+		if isLink && a.isLegacySkillLink(skillName, path) {
+			return true, ""
+		}
+		if info.Mode().IsRegular() && a.isLegacySkillCopy(skillName, path) {
+			return true, ""
+		}
+		return false, "不是由 SkillUI 创建"
+	}
+	if entry.Kind == manifest.KindLink {
+		if !isLink {
+			return false, "同步的链接已被替换为普通文件"
+		}
+		// 链接被改为指向其他位置时交还给用户
+		if target, err := os.Readlink(path); err != nil || (entry.Target != "" && target != entry.Target) {
+			return false, "同步的链接已被改为指向其他位置"
+		}
+		return true, ""
+	}
+	if isLink {
+		return false, "同步的文件已被替换为链接"
+	}
+	if hash, err := adapter.HashPlaced(path); err != nil || hash != entry.Hash {
+		return false, "同步后已被手动修改"
+	}
+	return true, ""
+}
+
+// isLegacySkillLink reports whether a symlink was created by an older
+// version: it points into the skill directory, or it is dangling and points
+// at a path that ends in the skill's own directory / SKILL.md (a skill
+// directory that has since moved). A live link elsewhere is the user's own.
+func (a *App) isLegacySkillLink(skillName, path string) bool {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	target = filepath.Clean(target)
+	skillDir := filepath.Join(a.getSkillDir(), skillName)
+	if target == skillDir || strings.HasPrefix(target, skillDir+string(os.PathSeparator)) {
+		return true
+	}
+	if _, err := os.Stat(path); err == nil {
+		return false
+	}
+	suffix := string(os.PathSeparator) + skillName
+	return strings.HasSuffix(target, suffix) || strings.HasSuffix(target, suffix+string(os.PathSeparator)+"SKILL.md")
+}
+
+// isLegacySkillCopy reports whether a file is an unmodified copy of the
+// skill's SKILL.md, as placed by older versions on Windows
+func (a *App) isLegacySkillCopy(skillName, path string) bool {
+	want, err := skill.HashFile(filepath.Join(a.getSkillDir(), skillName, "SKILL.md"))
+	if err != nil {
+		return false
+	}
+	got, err := skill.HashFile(path)
+	return err == nil && got == want
+}
+
+// removeSynced removes what is placed for a skill in one tool, limited to
+// entries SkillUI owns unless force is set; refusals are added to report
+func (a *App) removeSynced(projectRoot, skillName string, def ideToolDef, force bool, report *SyncRemovalReport) error {
+	m := a.syncManifest()
+	for _, path := range def.placedPaths(projectRoot, skillName) {
+		if _, err := os.Lstat(path); err != nil {
+			m.Delete(path)
+			continue
+		}
+		if owned, reason := a.checkOwned(skillName, path); !owned && !force {
+			report.Refused = append(report.Refused, RefusedFile{Skill: skillName, ToolID: def.ID, Path: path, Reason: reason})
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		m.Delete(path)
+		report.Removed = append(report.Removed, path)
+	}
+	return nil
+}
+
+// removeLegacySynced removes what older versions placed for a skill in a
+// tool's former rules dir once it is synced to the current one, so the tool
+// does not load it twice. Files SkillUI does not own are left alone.
+func (a *App) removeLegacySynced(skillName string, def ideToolDef) {
+	m := a.syncManifest()
+	for _, path := range def.legacyPaths(skillName) {
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+		if owned, _ := a.checkOwned(skillName, path); !owned {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			a.LogSystemError("SyncSkillToTools", fmt.Sprintf("Failed to remove legacy synced file %s: %v", path, err))
+			continue
+		}
+		m.Delete(path)
+	}
+}
+
+// adoptLegacySyncLinks records symlinks created by versions without a
+// manifest, so they can still be unsynced and deleted safely
+func (a *App) adoptLegacySyncLinks() {
+	names, err := a.syncableSkillNames(nil)
+	if err != nil || len(names) == 0 {
+		return
+	}
+	m := a.syncManifest()
+	roots := []string{""}
+	for _, p := range a.config.Projects {
+		roots = append(roots, p.Path)
+	}
+	for _, root := range roots {
+		for _, def := range ideToolDefs(a.config.ToolPaths, a.config.CustomTools) {
+			for _, name := range names {
+				for _, path := range def.placedPaths(root, name) {
+					info, err := os.Lstat(path)
+					if err != nil || info.Mode()&os.ModeSymlink == 0 {
+						continue
+					}
+					if _, ok := m.Get(path); ok || !a.isLegacySkillLink(name, path) {
+						continue
+					}
+					target, _ := os.Readlink(path)
+					m.Put(manifest.Entry{Skill: name, ToolID: def.ID, Project: root, Path: path, Kind: manifest.KindLink, Target: target})
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"skillui/internal/config"
+	"skillui/internal/manifest"
+)
+
+const testSkillMd = "---\nname: demo\ndescription: Formats Go code and explains every change it makes\n---\n# Demo\n"
+
+// newTestApp returns an App whose data dir, home and rules dir of the trae
+// tool (markdown format) are temp dirs
+func newTestApp(t *testing.T) (*App, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	a := &App{dataDir: t.TempDir(), config: config.DefaultConfig()}
+	if err := a.loadToolRegistry(); err != nil {
+		t.Fatal(err)
+	}
+	rulesDir := filepath.Join(t.TempDir(), "rules")
+	a.config.ToolPaths["trae"] = rulesDir
+	return a, rulesDir
+}
+
+// writeTestSkill creates a skill in the app's skill dir
+func writeTestSkill(t *testing.T, a *App, name string) string {
+	t.Helper()
+	dir := filepath.Join(a.getSkillDir(), name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(testSkillMd), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func testToolDef(t *testing.T, a *App, id string) ideToolDef {
+	t.Helper()
+	for _, def := range ideToolDefs(a.config.ToolPaths, a.config.CustomTools) {
+		if def.ID == id {
+			return def
+		}
+	}
+	t.Fatalf("tool %s not in the registry", id)
+	return ideToolDef{}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testSymlink(t *testing.T, target, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		t.Skipf("symlinks not available: %v", err)
+	}
+}
+
+func TestCheckOwned(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup creates what sits at path
+		setup func(t *testing.T, a *App, skillDir, path string)
+		want  bool
+	}{
+		{
+			name:  "missing path",
+			setup: func(t *testing.T, a *App, skillDir, path string) {},
+			want:  true,
+		},
+		{
+			name: "foreign file",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				writeTestFile(t, path, "# my own rules\n")
+			},
+		},
+		{
+			name: "legacy copy of SKILL.md",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				writeTestFile(t, path, testSkillMd)
+			},
+			want: true,
+		},
+		{
+			name: "recorded copy",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				writeTestFile(t, path, testSkillMd+"rendered\n")
+				recordTestEntry(t, a, path)
+			},
+			want: true,
+		},
+		{
+			name: "recorded copy modified afterwards",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				writeTestFile(t, path, testSkillMd+"rendered\n")
+				recordTestEntry(t, a, path)
+				writeTestFile(t, path, testSkillMd+"edited by hand\n")
+			},
+		},
+		{
+			name: "recorded copy replaced by a link",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				writeTestFile(t, path, testSkillMd)
+				recordTestEntry(t, a, path)
+				os.Remove(path)
+				testSymlink(t, filepath.Join(skillDir, "SKILL.md"), path)
+			},
+		},
+		{
+			name: "recorded link",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				testSymlink(t, filepath.Join(skillDir, "SKILL.md"), path)
+				recordTestEntry(t, a, path)
+			},
+			want: true,
+		},
+		{
+			name: "recorded link pointed elsewhere",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				testSymlink(t, filepath.Join(skillDir, "SKILL.md"), path)
+				recordTestEntry(t, a, path)
+				other := filepath.Join(t.TempDir(), "other.md")
+				writeTestFile(t, other, "other")
+				os.Remove(path)
+				testSymlink(t, other, path)
+			},
+		},
+		{
+			name: "recorded link replaced by a file",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				testSymlink(t, filepath.Join(skillDir, "SKILL.md"), path)
+				recordTestEntry(t, a, path)
+				os.Remove(path)
+				writeTestFile(t, path, testSkillMd)
+			},
+		},
+		{
+			name: "legacy link into the skill",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				testSymlink(t, filepath.Join(skillDir, "SKILL.md"), path)
+			},
+			want: true,
+		},
+		{
+			name: "dangling legacy link to a moved skill",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				testSymlink(t, filepath.Join(t.TempDir(), "old-skills", "demo", "SKILL.md"), path)
+			},
+			want: true,
+		},
+		{
+			name: "live foreign link",
+			setup: func(t *testing.T, a *App, skillDir, path string) {
+				other := filepath.Join(t.TempDir(), "demo", "SKILL.md")
+				writeTestFile(t, other, testSkillMd)
+				testSymlink(t, other, path)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, rulesDir := newTestApp(t)
+			skillDir := writeTestSkill(t, a, "demo")
+			path := filepath.Join(rulesDir, "demo.md")
+			tt.setup(t, a, skillDir, path)
+			owned, reason := a.checkOwned("demo", path)
+			if owned != tt.want {
+				t.Fatalf("checkOwned = %v (%s), want %v", owned, reason, tt.want)
+			}
+			if !owned && reason == "" {
+				t.Error("refusal without a reason")
+			}
+		})
+	}
+}
+
+// recordTestEntry records what is at path in the manifest as placed for trae
+func recordTestEntry(t *testing.T, a *App, path string) {
+	t.Helper()
+	if err := a.recordPlaced("", "demo", testToolDef(t, a, "trae")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.syncManifest().Get(path); !ok {
+		t.Fatalf("%s not recorded", path)
+	}
+}
+
+func TestSyncRecordsAndUnsyncRemoves(t *testing.T) {
+	a, rulesDir := newTestApp(t)
+	writeTestSkill(t, a, "demo")
+	project := t.TempDir()
+	for _, root := range []string{"", project} {
+		if err := a.syncSkillToTools(root, "demo", []string{"trae"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	global := filepath.Join(rulesDir, "demo.md")
+	inProject := filepath.Join(project, ".trae", "rules", "demo.md")
+	if e, ok := a.syncManifest().Get(global); !ok || e.Kind != manifest.KindLink || e.Target == "" {
+		t.Errorf("global entry = %+v, %v; want a link", e, ok)
+	}
+	// 项目内同步为复制，记录内容哈希
+	if e, ok := a.syncManifest().Get(inProject); !ok || e.Kind != manifest.KindFile || e.Hash == "" || e.Project != project {
+		t.Errorf("project entry = %+v, %v; want a file with hash", e, ok)
+	}
+
+	for _, root := range []string{"", project} {
+		report, err := a.unsyncSkill(root, "demo", []string{"trae"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Refused) != 0 || len(report.Removed) != 1 {
+			t.Errorf("unsync %q: report = %+v", root, report)
+		}
+	}
+	for _, p := range []string{global, inProject} {
+		if _, err := os.Lstat(p); !os.IsNotExist(err) {
+			t.Errorf("%s not removed", p)
+		}
+	}
+	if n := len(a.ListSyncManifest()); n != 0 {
+		t.Errorf("%d manifest entries left after unsync", n)
+	}
+}
+
+func TestUnsyncKeepsForeignAndModifiedFiles(t *testing.T) {
+	a, rulesDir := newTestApp(t)
+	writeTestSkill(t, a, "demo")
+	project := t.TempDir()
+	if err := a.syncSkillToTools(project, "demo", []string{"trae"}); err != nil {
+		t.Fatal(err)
+	}
+	modified := filepath.Join(project, ".trae", "rules", "demo.md")
+	writeTestFile(t, modified, testSkillMd+"edited by hand\n")
+	// 全局规则目录中同名的用户文件（回退路径 demo/ 也是）
+	foreign := filepath.Join(rulesDir, "demo.md")
+	writeTestFile(t, foreign, "# my own rules\n")
+	foreignDir := filepath.Join(rulesDir, "demo", "notes.md")
+	writeTestFile(t, foreignDir, "notes")
+
+	def := testToolDef(t, a, "trae")
+	var report SyncRemovalReport
+	for _, root := range []string{"", project} {
+		if err := a.removeSynced(root, "demo", def, false, &report); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(report.Removed) != 0 || len(report.Refused) != 3 {
+		t.Fatalf("report = %+v; want 3 refused, none removed", report)
+	}
+	for _, p := range []string{modified, foreign, foreignDir} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s was removed", p)
+		}
+	}
+	if _, ok := a.syncManifest().Get(modified); !ok {
+		t.Error("entry of the refused file dropped from the manifest")
+	}
+	// 同步也不覆盖这些文件
+	if err := a.syncSkillToTools("", "demo", []string{"trae"}); err == nil {
+		t.Error("sync overwrote a foreign file")
+	}
+	if data, _ := os.ReadFile(foreign); string(data) != "# my own rules\n" {
+		t.Errorf("foreign file changed to %q", data)
+	}
+
+	// 强制删除时一并移除
+	report = SyncRemovalReport{}
+	if err := a.removeSynced(project, "demo", def, true, &report); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(modified); !os.IsNotExist(err) {
+		t.Error("forced removal kept the modified file")
+	}
+}
+
+func TestAdoptLegacySyncLinks(t *testing.T) {
+	a, rulesDir := newTestApp(t)
+	skillDir := writeTestSkill(t, a, "demo")
+	writeTestSkill(t, a, "other")
+	legacy := filepath.Join(rulesDir, "demo.md")
+	testSymlink(t, filepath.Join(skillDir, "SKILL.md"), legacy)
+	// 指向别处的有效链接属于用户
+	own := filepath.Join(t.TempDir(), "other", "SKILL.md")
+	writeTestFile(t, own, testSkillMd)
+	foreign := filepath.Join(rulesDir, "other.md")
+	testSymlink(t, own, foreign)
+
+	a.adoptLegacySyncLinks()
+	e, ok := a.syncManifest().Get(legacy)
+	if !ok || e.Kind != manifest.KindLink || e.Skill != "demo" || e.ToolID != "trae" {
+		t.Errorf("legacy link entry = %+v, %v", e, ok)
+	}
+	if _, ok := a.syncManifest().Get(foreign); ok {
+		t.Error("foreign link adopted")
+	}
+	if owned, _ := a.checkOwned("other", foreign); owned {
+		t.Error("foreign link counted as owned")
+	}
+}
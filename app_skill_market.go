@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"skillui/internal/manifest"
+	"skillui/internal/skill"
+)
+
+// skillUITimeLayout is the timestamp layout used inside skillui.json
+const skillUITimeLayout = "2006-01-02T15:04:05Z"
+
+// SkillUpdateInfo describes the update state of a market-installed skill
+type SkillUpdateInfo struct {
+	Name           string `json:"name"`
+	MarketID       int    `json:"marketId"`
+	CurrentVersion string `json:"currentVersion"`
+	LatestVersion  string `json:"latestVersion"`
+	HasUpdate      bool   `json:"hasUpdate"`
+	// Error 记录查询该技能时的错误，不影响其他技能的检查结果
+	Error string `json:"error,omitempty"`
+}
+
+// marketResponse is the common {code, data} envelope of the marketplace API
+type marketResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"msg"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// marketSkillRecord is a skill record returned by /skill_ui/get
+type marketSkillRecord struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	TitleEn string `json:"titleEn"`
+	TitleZh string `json:"titleZh"`
+	DescEn  string `json:"descEn"`
+	DescZh  string `json:"descZh"`
+	Owner   string `json:"owner"`
+	Version string `json:"version"`
+}
+
+// marketDownload is the payload returned by /skill_ui/download
+type marketDownload struct {
+	Url string `json:"url"`
+	Md5 string `json:"md5"`
+}
+
+// marketPost calls a marketplace API endpoint and decodes its data payload into out
+func marketPost(path string, body interface{}, out interface{}) error {
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequest("POST", appConfig.ApiBaseUrl+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP error: status %d", resp.StatusCode)
+	}
+
+	var result marketResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Code != 0 {
+		if result.Message != "" {
+			return fmt.Errorf("API error: %s", result.Message)
+		}
+		return fmt.Errorf("API error: code %d", result.Code)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+// fetchMarketSkill returns the latest marketplace record of a skill
+func fetchMarketSkill(marketID int) (marketSkillRecord, error) {
+	var data struct {
+		Record marketSkillRecord `json:"record"`
+	}
+	if err := marketPost("/skill_ui/get", map[string]interface{}{"id": marketID}, &data); err != nil {
+		return marketSkillRecord{}, err
+	}
+	return data.Record, nil
+}
+
+// fetchMarketDownload returns the download info of the latest version of a skill
+func fetchMarketDownload(marketID int) (marketDownload, error) {
+	var data marketDownload
+	if err := marketPost("/skill_ui/download", map[string]interface{}{"id": marketID}, &data); err != nil {
+		return marketDownload{}, err
+	}
+	if data.Url == "" {
+		return marketDownload{}, fmt.Errorf("市场未返回下载地址")
+	}
+	return data, nil
+}
+
+// readSkillUIJson reads skillui.json from a skill directory
+func readSkillUIJson(dir string) (skillUIJson, error) {
+	var sj skillUIJson
+	data, err := os.ReadFile(filepath.Join(dir, "skillui.json"))
+	if err != nil {
+		return sj, err
+	}
+	err = json.Unmarshal(data, &sj)
+	return sj, err
+}
+
+// writeSkillUIJson writes skillui.json into a skill directory
+func writeSkillUIJson(dir string, sj skillUIJson) error {
+	data, err := json.MarshalIndent(sj, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "skillui.json"), data, 0644)
+}
+
+// compareVersions compares two dotted version strings (an optional leading "v"
+// is ignored). Numeric segments compare numerically, others lexically; a
+// version with a pre-release suffix (1.2.0-beta) sorts before the release.
+func compareVersions(a, b string) int {
+	split := func(v string) ([]string, string) {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+		pre := ""
+		if idx := strings.IndexAny(v, "-+"); idx >= 0 {
+			pre = v[idx+1:]
+			v = v[:idx]
+		}
+		return strings.Split(v, "."), pre
+	}
+	pa, preA := split(a)
+	pb, preB := split(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		sa, sb := "0", "0"
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+		na, errA := strconv.Atoi(sa)
+		nb, errB := strconv.Atoi(sb)
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case sa != sb:
+			if sa < sb {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	case preA < preB:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// CheckSkillUpdates queries the marketplace for every market-installed skill
+// and reports which ones have a newer version available.
+func (a *App) CheckSkillUpdates() ([]SkillUpdateInfo, error) {
+	skills, err := a.ListLocalSkills()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SkillUpdateInfo, 0)
+	for _, s := range skills {
+		if !s.IsMarket || s.MarketID <= 0 {
+			continue
+		}
+		result = append(result, SkillUpdateInfo{
+			Name:           filepath.Base(s.Location),
+			MarketID:       s.MarketID,
+			CurrentVersion: s.Version,
+		})
+	}
+
+	// 限制并发，避免一次性向市场发起过多请求
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 4)
+	for i := range result {
+		wg.Add(1)
+		go func(info *SkillUpdateInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			record, err := fetchMarketSkill(info.MarketID)
+			if err != nil {
+				info.Error = err.Error()
+				return
+			}
+			info.LatestVersion = record.Version
+			info.HasUpdate = record.Version != "" && compareVersions(info.CurrentVersion, record.Version) < 0
+		}(&result[i])
+	}
+	wg.Wait()
+	return result, nil
+}
+
+// UpgradeSkill downloads the latest marketplace version of a skill, replaces
+// the installed directory atomically, keeps the skillui.json history and
+// re-syncs the skill to the tools it was synced to before.
+func (a *App) UpgradeSkill(name string) error {
+	old, err := readSkillUIJson(filepath.Join(a.getSkillDir(), name))
+	if err != nil || old.MarketID <= 0 {
+		return fmt.Errorf("技能 %s 不是从市场安装的，无法升级", name)
+	}
+
+	record, err := fetchMarketSkill(old.MarketID)
+	if err != nil {
+		return fmt.Errorf("获取市场技能信息失败: %w", err)
+	}
+	download, err := fetchMarketDownload(old.MarketID)
+	if err != nil {
+		return fmt.Errorf("获取下载地址失败: %w", err)
+	}
+
+	// 全局与各项目中已同步的工具，升级后全部重新同步
+	synced := a.syncedScopes(name)
+
+	req := skillInstallRequest{
+		Name:   name,
+		Source: download.Url,
+		Policy: ConflictOverwrite,
+	}
+	result := a.installSkillFromUrl(download.Url, download.Md5, req, func(staged, archiveMd5 string) error {
+		tree, err := skill.HashTree(staged)
+		if err != nil {
+			return fmt.Errorf("计算技能哈希失败: %w", err)
+		}
+		now := time.Now().Format(skillUITimeLayout)
+		return writeSkillUIJson(staged, skillUIJson{
+			MarketID:    old.MarketID,
+			Name:        old.Name,
+			TitleEn:     record.TitleEn,
+			TitleZh:     record.TitleZh,
+			DescEn:      record.DescEn,
+			DescZh:      record.DescZh,
+			Owner:       record.Owner,
+			Version:     record.Version,
+			Md5:         archiveMd5,
+			TreeHash:    tree.Hash,
+			Files:       tree.Files,
+			InstalledAt: now,
+			History: append(old.History, skillUIHistory{
+				Version:     old.Version,
+				Md5:         old.Md5,
+				InstalledAt: old.InstalledAt,
+				ReplacedAt:  now,
+			}),
+		})
+	})
+	if err := result.err(); err != nil {
+		return err
+	}
+	if err := a.resyncScopes(name, synced); err != nil {
+		a.LogSystemError("UpgradeSkill", fmt.Sprintf("Failed to re-sync skill %s after upgrade: %v", name, err))
+		return fmt.Errorf("技能已升级，但重新同步失败: %w", err)
+	}
+	return nil
+}
+
+// SkillIntegrityReport is the result of comparing a market skill against the
+// file hashes recorded in its skillui.json at install time
+type SkillIntegrityReport struct {
+	Name string `json:"name"`
+	// Verifiable 为 false 表示安装时未记录文件哈希（旧版本安装），无法校验
+	Verifiable       bool     `json:"verifiable"`
+	Intact           bool     `json:"intact"`
+	RecordedTreeHash string   `json:"recordedTreeHash"`
+	CurrentTreeHash  string   `json:"currentTreeHash"`
+	Modified         []string `json:"modified"`
+	Added            []string `json:"added"`
+	Removed          []string `json:"removed"`
+}
+
+// VerifySkillIntegrity detects local modifications to a market-installed skill
+// and reports which files were modified, added or removed.
+func (a *App) VerifySkillIntegrity(name string) (SkillIntegrityReport, error) {
+	dir := filepath.Join(a.getSkillDir(), name)
+	report := SkillIntegrityReport{Name: name, Modified: []string{}, Added: []string{}, Removed: []string{}}
+	sj, err := readSkillUIJson(dir)
+	if err != nil {
+		return report, fmt.Errorf("技能 %s 没有 skillui.json，无法校验", name)
+	}
+	current, err := skill.HashTree(dir)
+	if err != nil {
+		return report, fmt.Errorf("计算技能哈希失败: %w", err)
+	}
+	report.CurrentTreeHash = current.Hash
+	report.RecordedTreeHash = sj.TreeHash
+	if len(sj.Files) == 0 {
+		return report, nil
+	}
+	report.Verifiable = true
+	diff := skill.DiffTree(sj.Files, current.Files)
+	report.Modified = diff.Modified
+	report.Added = diff.Added
+	report.Removed = diff.Removed
+	report.Intact = diff.Empty()
+	return report, nil
+}
+
+// VerifyAllSkillsIntegrity runs VerifySkillIntegrity on every skill that has a skillui.json
+func (a *App) VerifyAllSkillsIntegrity() ([]SkillIntegrityReport, error) {
+	skillDir := a.getSkillDir()
+	entries, err := os.ReadDir(skillDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []SkillIntegrityReport{}, nil
+		}
+		return nil, err
+	}
+	reports := make([]SkillIntegrityReport, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(skillDir, entry.Name(), "skillui.json")); err != nil {
+			continue
+		}
+		report, err := a.VerifySkillIntegrity(entry.Name())
+		if err != nil {
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// syncedToolIDs returns the IDs of the tools a skill is currently synced to
+// in their global rules dirs
+func (a *App) syncedToolIDs(skillName string) []string {
+	if ids := a.syncedScopes(skillName)[""]; ids != nil {
+		return ids
+	}
+	return []string{}
+}
+
+// syncedScopes returns the IDs of the tools a skill is synced to per sync
+// scope (project root, "" for global): the placed entries recorded in the
+// sync manifest plus the tools whose rules dir globally or in a registered
+// project holds the skill
+func (a *App) syncedScopes(skillName string) map[string][]string {
+	defs := ideToolDefs(a.config.ToolPaths, a.config.CustomTools)
+	found := map[string]map[string]bool{}
+	mark := func(root, toolID string) {
+		if found[root] == nil {
+			found[root] = map[string]bool{}
+		}
+		found[root][toolID] = true
+	}
+	roots := []string{""}
+	for _, p := range a.config.Projects {
+		roots = append(roots, p.Path)
+	}
+	for _, root := range roots {
+		for _, def := range syncedToolDefs(defs, root, skillName) {
+			mark(root, def.ID)
+		}
+	}
+	for _, e := range a.syncManifest().Entries(func(e manifest.Entry) bool {
+		return e.Skill == skillName && e.Kind != manifest.KindMCPServer
+	}) {
+		if _, err := os.Lstat(e.Path); err == nil {
+			mark(e.Project, e.ToolID)
+		}
+	}
+	scopes := make(map[string][]string, len(found))
+	for root, ids := range found {
+		for _, def := range defs {
+			if ids[def.ID] {
+				scopes[root] = append(scopes[root], def.ID)
+			}
+		}
+	}
+	return scopes
+}
+
+// resyncScopes syncs a skill again to the tools it was synced to in each
+// scope (see syncedScopes), e.g. after its content was replaced
+func (a *App) resyncScopes(skillName string, scopes map[string][]string) error {
+	var errs []string
+	for root, ids := range scopes {
+		if err := a.syncSkillToTools(root, skillName, ids); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+
+	"skillui/internal/adapter"
+)
+
+// Reconcile actions
+const (
+	ReconcileNone      = "none"
+	ReconcileResync    = "resync"
+	ReconcileOverwrite = "overwrite"
+	ReconcileSkip      = "skip"
+)
+
+// SyncDriftEntry is the sync state of one skill in one tool's rules dir
+type SyncDriftEntry struct {
+	Skill    string `json:"skill"`
+	ToolID   string `json:"toolId"`
+	ToolName string `json:"toolName"`
+	// Path 为检查的文件或目录（绝对路径）
+	Path string `json:"path"`
+	// State: in-sync / stale / broken-link / foreign-file / missing
+	State string `json:"state"`
+	// Action 为修复时采取的操作：none / resync / overwrite / skip
+	Action string `json:"action"`
+	// Applied 表示修复已执行（预览模式下始终为 false）
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReconcileOptions controls ReconcileSync
+type ReconcileOptions struct {
+	// ProjectID 为空表示全局规则目录
+	ProjectID string `json:"projectId"`
+	// Skills 限定要处理的技能，为空表示全部
+	Skills []string `json:"skills"`
+	// DryRun 只返回将要执行的操作，不修改任何文件
+	DryRun bool `json:"dryRun"`
+	// Force 同时覆盖被外部修改的文件（foreign-file），默认跳过
+	Force bool `json:"force"`
+}
+
+// GetSyncDriftReport classifies every skill × tool pair of a scope (global
+// when projectID is empty). Pairs where nothing is placed are only reported
+// as missing when the skill is expected there (active collections, otherwise
+// auto-sync tools).
+func (a *App) GetSyncDriftReport(projectID string) ([]SyncDriftEntry, error) {
+	return a.ReconcileSync(ReconcileOptions{ProjectID: projectID, DryRun: true})
+}
+
+// ReconcileSync repairs sync drift in one pass: stale copies, broken links,
+// legacy layouts and missing auto-sync targets are re-rendered; hand-edited
+// (foreign) files are left alone unless Force is set. With DryRun the planned
+// actions are returned without touching any file.
+func (a *App) ReconcileSync(opts ReconcileOptions) ([]SyncDriftEntry, error) {
+	root, err := a.projectRoot(opts.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := a.syncableSkillNames(opts.Skills)
+	if err != nil {
+		return nil, err
+	}
+	expected := a.expectedSync(opts.ProjectID, root)
+	defs := ideToolDefs(a.config.ToolPaths, a.config.CustomTools)
+
+	entries := make([]SyncDriftEntry, 0)
+	for _, name := range names {
+		dir := filepath.Join(a.getSkillDir(), name)
+		src, srcErr := adapter.LoadSource(name, dir)
+		validErr := checkSkillValid(dir)
+		for _, def := range defs {
+			rulesDir := def.rulesDirIn(root)
+			if rulesDir == "" {
+				continue
+			}
+			entry := SyncDriftEntry{Skill: name, ToolID: def.ID, ToolName: def.Name, Action: ReconcileNone}
+			if srcErr != nil {
+				if !adapter.Detect(def.adapter(), rulesDir, name) {
+					continue
+				}
+				entry.State = string(adapter.StateStale)
+				entry.Path = filepath.Join(rulesDir, def.adapter().Paths(name)[0])
+				entry.Action, entry.Error = ReconcileSkip, srcErr.Error()
+				entries = append(entries, entry)
+				continue
+			}
+
+			state, rel, err := adapter.Check(def.adapter(), src, rulesDir, a.adapterOptions(def, root))
+			entry.Path = filepath.Join(rulesDir, rel)
+			entry.State = string(state)
+			if err != nil {
+				entry.Action, entry.Error = ReconcileSkip, err.Error()
+				entries = append(entries, entry)
+				continue
+			}
+			if state == adapter.StateMissing && !expected(def.ID, name) {
+				continue
+			}
+			// 以同步清单为准区分过期副本与外部文件：清单中未被修改的（或旧版本创建的）条目属于 SkillUI
+			if state != adapter.StateInSync && state != adapter.StateMissing {
+				if owned, _ := a.checkOwned(name, entry.Path); !owned {
+					state = adapter.StateForeign
+				} else if state == adapter.StateForeign {
+					state = adapter.StateStale
+				}
+				entry.State = string(state)
+			}
+			// 内容一致但清单中没有记录（旧版本同步的副本）时收编进清单
+			if state == adapter.StateInSync && !opts.DryRun {
+				if _, ok := a.syncManifest().Get(entry.Path); !ok {
+					a.recordPlaced(root, name, def)
+				}
+			}
+
+			switch state {
+			case adapter.StateStale, adapter.StateBrokenLink, adapter.StateMissing:
+				entry.Action = ReconcileResync
+			case adapter.StateForeign:
+				entry.Action = ReconcileSkip
+				if opts.Force {
+					entry.Action = ReconcileOverwrite
+				}
+			}
+			if entry.Action != ReconcileNone && entry.Action != ReconcileSkip && validErr != nil {
+				entry.Action, entry.Error = ReconcileSkip, validErr.Error()
+			}
+
+			if !opts.DryRun && (entry.Action == ReconcileResync || entry.Action == ReconcileOverwrite) {
+				if err := a.resyncSkillTool(root, name, def, entry.Action == ReconcileOverwrite); err != nil {
+					entry.Error = err.Error()
+				} else {
+					entry.Applied = true
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// resyncSkillTool removes what SkillUI placed for a skill in one tool (legacy
+// layouts included; with force also foreign files) and renders it again
+func (a *App) resyncSkillTool(projectRoot, name string, def ideToolDef, force bool) error {
+	report := newSyncRemovalReport()
+	if err := a.removeSynced(projectRoot, name, def, force, &report); err != nil {
+		return err
+	}
+	return a.syncSkillToTools(projectRoot, name, []string{def.ID})
+}
+
+// adapterOptions returns the render options of a tool in a sync scope
+func (a *App) adapterOptions(def ideToolDef, projectRoot string) adapter.Options {
+	return adapter.Options{
+		// Windows / 项目级同步复制文件（项目内文件随仓库提交，不能是指向本机的符号链接），其余使用符号链接
+		Link:      goruntime.GOOS != "windows" && projectRoot == "",
+		Resources: a.config.ToolResourceModes[def.ID],
+	}
+}
+
+// expectedSync returns whether a skill should be synced to a tool in a
+// scope: the skills of the collection active on the tool there (see
+// applyCollection), otherwise every skill for the installed auto-sync tools
+// of the global scope
+func (a *App) expectedSync(projectID, projectRoot string) func(toolID, skillName string) bool {
+	collections := map[string]map[string]bool{}
+	for _, act := range a.config.ActiveCollections {
+		if act.ProjectID != projectID {
+			continue
+		}
+		idx := a.collectionIndex(act.CollectionID)
+		if idx < 0 {
+			continue
+		}
+		skills := map[string]bool{}
+		for _, name := range a.config.Collections[idx].Skills {
+			skills[name] = true
+		}
+		collections[act.ToolID] = skills
+	}
+
+	auto := map[string]bool{}
+	if projectRoot == "" && len(a.config.AutoSyncToolIDs) > 0 {
+		autoIDs := map[string]bool{}
+		for _, id := range a.config.AutoSyncToolIDs {
+			autoIDs[id] = true
+		}
+		if tools, err := a.ScanIDETools(); err == nil {
+			for _, t := range tools {
+				if t.Installed && autoIDs[t.ID] {
+					auto[t.ID] = true
+				}
+			}
+		}
+	}
+	return func(toolID, skillName string) bool {
+		if skills, ok := collections[toolID]; ok {
+			return skills[skillName]
+		}
+		return auto[toolID]
+	}
+}
+
+// syncableSkillNames lists installed skills (directories with SKILL.md),
+// limited to names when it is not empty
+func (a *App) syncableSkillNames(names []string) ([]string, error) {
+	skillDir := a.getSkillDir()
+	if len(names) > 0 {
+		for _, n := range names {
+			if err := validateSkillName(n); err != nil {
+				return nil, err
+			}
+			if _, err := os.Stat(filepath.Join(skillDir, n, "SKILL.md")); err != nil {
+				return nil, fmt.Errorf("技能不存在: %s", n)
+			}
+		}
+		return names, nil
+	}
+	entries, err := os.ReadDir(skillDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(skillDir, entry.Name(), "SKILL.md")); err == nil {
+			result = append(result, entry.Name())
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skillui/internal/skill"
+)
+
+// ValidateSkill runs the lint checks on a single installed skill
+func (a *App) ValidateSkill(name string) ([]skill.Diagnostic, error) {
+	dir := filepath.Join(a.getSkillDir(), name)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("技能不存在: %s", name)
+	}
+	return skill.Validate(dir, skill.DefaultLimits), nil
+}
+
+// ValidateSkills runs the lint checks on every installed skill (skill name -> diagnostics)
+func (a *App) ValidateSkills() (map[string][]skill.Diagnostic, error) {
+	skillDir := a.getSkillDir()
+	entries, err := os.ReadDir(skillDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string][]skill.Diagnostic{}, nil
+		}
+		return nil, err
+	}
+	result := make(map[string][]skill.Diagnostic, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		result[entry.Name()] = skill.Validate(filepath.Join(skillDir, entry.Name()), skill.DefaultLimits)
+	}
+	return result, nil
+}
+
+// checkSkillValid returns an error listing the error-level diagnostics of a skill,
+// used to stop broken skills from being synced into tool directories.
+func checkSkillValid(dir string) error {
+	diags := skill.Validate(dir, skill.DefaultLimits)
+	if !skill.HasErrors(diags) {
+		return nil
+	}
+	msgs := make([]string, 0, len(diags))
+	for _, d := range diags {
+		if d.Severity == skill.SeverityError {
+			msgs = append(msgs, d.Message)
+		}
+	}
+	return fmt.Errorf("技能校验未通过: %s", strings.Join(msgs, "; "))
+}
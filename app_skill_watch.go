@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"skillui/internal/adapter"
+	"skillui/internal/manifest"
+	"skillui/internal/watcher"
+)
+
+// 技能目录监听：更新内存中的技能索引（见 app_skill_index.go）；SKILL.md 或资源文件被编辑 / 升级后，自动重新渲染该技能在各工具中的同步副本
+// （以同步清单为准，全局与项目级都包括），并通过 skill:sync-refreshed 事件通知前端。
+// 每个智能体都会启动自己的 skillui mcp 进程，这些进程只更新索引（watchIndexOnly），刷新同步副本只由窗口实例负责。
+
+// EventSkillSyncRefreshed is emitted after the synced copies of a changed skill were refreshed
+const EventSkillSyncRefreshed = "skill:sync-refreshed"
+
+// SkillSyncRefresh is the payload of EventSkillSyncRefreshed
+type SkillSyncRefresh struct {
+	Skill string `json:"skill"`
+	// Refreshed 为重新渲染的文件或目录（绝对路径）
+	Refreshed []string `json:"refreshed"`
+	// Failed 为未能刷新的条目（如同步后被手动修改），Reason 为原因
+	Failed []RefusedFile `json:"failed"`
+}
+
+// startSkillWatcher (re)starts watching the current skill directory
+func (a *App) startSkillWatcher() {
+	a.stopSkillWatcher()
+	w, err := watcher.New(a.getSkillDir(), watcher.DefaultDebounce, a.onSkillsChanged)
+	if err != nil {
+		a.LogSystemError("startSkillWatcher", fmt.Sprintf("Failed to watch skill directory: %v", err))
+		return
+	}
+	a.watchMu.Lock()
+	a.skillWatcher = w
+	a.watchMu.Unlock()
+	if err := a.loadSkillIndex(); err != nil {
+		a.LogSystemError("startSkillWatcher", fmt.Sprintf("Failed to index skills: %v", err))
+	}
+}
+
+// stopSkillWatcher stops the skill directory watcher if it runs
+func (a *App) stopSkillWatcher() {
+	a.watchMu.Lock()
+	defer a.watchMu.Unlock()
+	if a.skillWatcher != nil {
+		a.skillWatcher.Close()
+		a.skillWatcher = nil
+	}
+}
+
+// onSkillsChanged is called by the watcher with the skills that changed
+func (a *App) onSkillsChanged(names []string) {
+	a.reindexSkills(names)
+	if a.watchIndexOnly {
+		return
+	}
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(a.getSkillDir(), name, "SKILL.md")); err != nil {
+			continue
+		}
+		refresh := a.refreshSyncedCopies(name)
+		if len(refresh.Refreshed) == 0 && len(refresh.Failed) == 0 {
+			continue
+		}
+		for _, f := range refresh.Failed {
+			a.LogSystemError("refreshSyncedCopies", fmt.Sprintf("Skip refreshing %s for %s: %s", f.Path, f.ToolID, f.Reason))
+		}
+		a.emitEvent(EventSkillSyncRefreshed, refresh)
+	}
+}
+
+// refreshSyncedCopies re-renders a skill in every tool it is synced to (per
+// the sync manifest) whose placed files no longer match the skill
+func (a *App) refreshSyncedCopies(name string) SkillSyncRefresh {
+	refresh := SkillSyncRefresh{Skill: name, Refreshed: []string{}, Failed: []RefusedFile{}}
+	entries := a.syncManifest().Entries(func(e manifest.Entry) bool { return e.Skill == name })
+	if len(entries) == 0 {
+		return refresh
+	}
+	src, err := adapter.LoadSource(name, filepath.Join(a.getSkillDir(), name))
+	if err != nil {
+		for _, e := range entries {
+			refresh.Failed = append(refresh.Failed, RefusedFile{Skill: name, ToolID: e.ToolID, Path: e.Path, Reason: err.Error()})
+		}
+		return refresh
+	}
+	defs := ideToolDefs(a.config.ToolPaths, a.config.CustomTools)
+	defMap := make(map[string]ideToolDef, len(defs))
+	for _, d := range defs {
+		defMap[d.ID] = d
+	}
+	for _, e := range entries {
+		def, ok := defMap[e.ToolID]
+		if !ok {
+			continue
+		}
+		rulesDir := def.rulesDirIn(e.Project)
+		if rulesDir == "" {
+			continue
+		}
+		state, _, err := adapter.Check(def.adapter(), src, rulesDir, a.adapterOptions(def, e.Project))
+		if err == nil && (state == adapter.StateInSync || state == adapter.StateMissing) {
+			continue
+		}
+		if err == nil {
+			err = a.syncSkillToTools(e.Project, name, []string{def.ID})
+		}
+		if err != nil {
+			refresh.Failed = append(refresh.Failed, RefusedFile{Skill: name, ToolID: def.ID, Path: e.Path, Reason: err.Error()})
+			continue
+		}
+		refresh.Refreshed = append(refresh.Refreshed, e.Path)
+	}
+	return refresh
+}
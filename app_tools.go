@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"skillui/internal/tools"
+)
+
+// 工具注册表：工具定义（检测路径、命令名、规则目录模板、输出格式）来自内置的 internal/tools/tools.json，
+// 依次被从市场刷新的副本（数据目录下 tools_market.json）和用户文件（数据目录下 tools.json）覆盖或扩展。
+
+const (
+	// toolsUserFile is the user's registry override file in the data dir
+	toolsUserFile = "tools.json"
+	// toolsMarketFile is the registry copy refreshed from the marketplace
+	toolsMarketFile = "tools_market.json"
+)
+
+// toolRegistry holds the loaded tool definitions; until loadToolRegistry runs
+// the bundled definitions are used
+var toolRegistry struct {
+	sync.RWMutex
+	defs []tools.Def
+	err  error
+}
+
+// ToolRegistryInfo describes where the tool definitions come from
+type ToolRegistryInfo struct {
+	// Count 为当前生效的工具数量
+	Count          int `json:"count"`
+	BundledVersion int `json:"bundledVersion"`
+	// MarketVersion 为从市场刷新的注册表版本，0 表示未刷新过
+	MarketVersion int    `json:"marketVersion"`
+	MarketFile    string `json:"marketFile"`
+	UserFile      string `json:"userFile"`
+	// Error 为加载覆盖文件时的错误（出错的文件被忽略）
+	Error string `json:"error,omitempty"`
+}
+
+// registryTools returns the current tool definitions
+func registryTools() []tools.Def {
+	toolRegistry.RLock()
+	defs := toolRegistry.defs
+	toolRegistry.RUnlock()
+	if defs != nil {
+		return defs
+	}
+	defs, err := tools.Bundled()
+	if err != nil {
+		panic(fmt.Sprintf("invalid bundled tools.json: %v", err))
+	}
+	toolRegistry.Lock()
+	if toolRegistry.defs == nil {
+		toolRegistry.defs = defs
+	}
+	toolRegistry.Unlock()
+	return defs
+}
+
+// loadToolRegistry loads the bundled registry with the marketplace and user overrides
+func (a *App) loadToolRegistry() error {
+	files := []string{filepath.Join(a.dataDir, toolsUserFile)}
+	// 市场副本旧于内置版本（应用已升级）时不再使用
+	marketFile := filepath.Join(a.dataDir, toolsMarketFile)
+	if data, err := os.ReadFile(marketFile); err == nil {
+		if v, _ := tools.FileVersion(data); v > tools.BundledVersion() {
+			files = append([]string{marketFile}, files...)
+		}
+	}
+	defs, err := tools.Load(files...)
+	toolRegistry.Lock()
+	toolRegistry.defs, toolRegistry.err = defs, err
+	toolRegistry.Unlock()
+	if err != nil {
+		a.LogSystemError("loadToolRegistry", fmt.Sprintf("Ignored broken tool registry file: %v", err))
+	}
+	return err
+}
+
+// GetToolRegistryInfo returns the sources and state of the tool registry
+func (a *App) GetToolRegistryInfo() ToolRegistryInfo {
+	info := ToolRegistryInfo{
+		Count:          len(registryTools()),
+		BundledVersion: tools.BundledVersion(),
+		MarketFile:     filepath.Join(a.dataDir, toolsMarketFile),
+		UserFile:       filepath.Join(a.dataDir, toolsUserFile),
+	}
+	if data, err := os.ReadFile(info.MarketFile); err == nil {
+		info.MarketVersion, _ = tools.FileVersion(data)
+	}
+	toolRegistry.RLock()
+	if toolRegistry.err != nil {
+		info.Error = toolRegistry.err.Error()
+	}
+	toolRegistry.RUnlock()
+	return info
+}
+
+// ReloadToolRegistry re-reads the override files, e.g. after the user edited tools.json
+func (a *App) ReloadToolRegistry() (ToolRegistryInfo, error) {
+	if err := a.loadToolRegistry(); err != nil {
+		return a.GetToolRegistryInfo(), fmt.Errorf("工具注册表文件有误，已忽略: %w", err)
+	}
+	return a.GetToolRegistryInfo(), nil
+}
+
+// RefreshToolRegistry downloads the latest tool registry from the marketplace
+// when it is newer than the bundled and cached ones, then reloads
+func (a *App) RefreshToolRegistry() (ToolRegistryInfo, error) {
+	current := a.GetToolRegistryInfo()
+	known := current.BundledVersion
+	if current.MarketVersion > known {
+		known = current.MarketVersion
+	}
+	var payload tools.File
+	if err := marketPost("/skill_ui/tools", map[string]interface{}{"version": known}, &payload); err != nil {
+		return current, fmt.Errorf("获取工具注册表失败: %w", err)
+	}
+	if payload.Version <= known || len(payload.Tools) == 0 {
+		return current, nil
+	}
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return current, err
+	}
+	if _, err := tools.Merge(nil, data); err != nil {
+		return current, fmt.Errorf("市场返回的工具注册表无效: %w", err)
+	}
+	if err := os.MkdirAll(a.dataDir, 0o755); err != nil {
+		return current, err
+	}
+	if err := os.WriteFile(filepath.Join(a.dataDir, toolsMarketFile), data, 0o644); err != nil {
+		return current, fmt.Errorf("保存工具注册表失败: %w", err)
+	}
+	return a.ReloadToolRegistry()
+}
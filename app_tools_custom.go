@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"skillui/internal/adapter"
+	"skillui/internal/config"
+	"skillui/internal/manifest"
+	"skillui/internal/tools"
+
+	"github.com/google/uuid"
+)
+
+// 自定义工具：用户为内部 Agent、自研分支等添加的同步目标（ID、名称、规则目录、文件名模式、输出格式），
+// 保存在配置中，与内置工具一样出现在 ScanIDETools 中，支持手动同步、项目级同步与自动同步。
+
+// customToolIDPattern limits custom tool ids to what is safe in config keys and file names
+var customToolIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// customToolDef converts a custom tool into a tool definition. It counts as
+// installed when its rules dir exists.
+func customToolDef(c config.CustomTool) ideToolDef {
+	rulesDir := tools.Expand(expandHome(c.RulesDir))
+	// 自定义工具的能力由其输出格式决定
+	capabilities := map[string]string{}
+	if capability := formatCapability(c.Format); capability != "" {
+		capabilities[capability] = ""
+	}
+	return ideToolDef{
+		ID:              c.ID,
+		Name:            c.Name,
+		CheckPathsMac:   []string{rulesDir},
+		CheckPathsWin:   []string{rulesDir},
+		CheckPathsLin:   []string{rulesDir},
+		RulesDirMac:     rulesDir,
+		RulesDirWin:     rulesDir,
+		RulesDirLin:     rulesDir,
+		Format:          c.Format,
+		ProjectRulesDir: c.ProjectRulesDir,
+		FileName:        c.FileName,
+		Custom:          true,
+		Capabilities:    capabilities,
+	}
+}
+
+// ListCustomTools returns the user-defined tools
+func (a *App) ListCustomTools() []config.CustomTool {
+	if a.config.CustomTools == nil {
+		return []config.CustomTool{}
+	}
+	return a.config.CustomTools
+}
+
+// AddCustomTool adds a user-defined tool; an empty ID is generated
+func (a *App) AddCustomTool(t config.CustomTool) (config.CustomTool, error) {
+	if strings.TrimSpace(t.ID) == "" {
+		t.ID = "custom-" + uuid.NewString()[:8]
+	}
+	t, err := a.normalizeCustomTool(t)
+	if err != nil {
+		return config.CustomTool{}, err
+	}
+	for _, def := range ideToolDefs(a.config.ToolPaths, a.config.CustomTools) {
+		if def.ID == t.ID {
+			return config.CustomTool{}, fmt.Errorf("工具 ID 已存在: %s", t.ID)
+		}
+	}
+	a.config.CustomTools = append(a.config.CustomTools, t)
+	if err := a.store.Save(a.config); err != nil {
+		return config.CustomTool{}, err
+	}
+	return t, nil
+}
+
+// UpdateCustomTool changes a user-defined tool. When its rules dir, file name
+// or format changes, the skills synced to it are moved to the new layout.
+func (a *App) UpdateCustomTool(t config.CustomTool) error {
+	t, err := a.normalizeCustomTool(t)
+	if err != nil {
+		return err
+	}
+	idx := a.customToolIndex(t.ID)
+	if idx < 0 {
+		return fmt.Errorf("自定义工具不存在: %s", t.ID)
+	}
+	old := a.config.CustomTools[idx]
+	relayout := old.RulesDir != t.RulesDir || old.ProjectRulesDir != t.ProjectRulesDir ||
+		old.FileName != t.FileName || old.Format != t.Format
+
+	var placed []manifest.Entry
+	if relayout {
+		var report SyncRemovalReport
+		if placed, report, err = a.removeCustomToolFiles(old); err != nil {
+			return err
+		}
+		if len(report.Refused) > 0 {
+			a.LogSystemError("UpdateCustomTool", fmt.Sprintf("Kept files not created by SkillUI for tool %s: %s", old.ID, report.refusedSummary()))
+		}
+	}
+
+	a.config.CustomTools[idx] = t
+	if err := a.store.Save(a.config); err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, e := range placed {
+		if err := a.syncSkillToTools(e.Project, e.Skill, []string{t.ID}); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", e.Skill, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("部分技能重新同步失败: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// RemoveCustomTool removes a user-defined tool together with the files
+// SkillUI synced to it (files it did not create are kept)
+func (a *App) RemoveCustomTool(id string) error {
+	idx := a.customToolIndex(id)
+	if idx < 0 {
+		return fmt.Errorf("自定义工具不存在: %s", id)
+	}
+	_, report, err := a.removeCustomToolFiles(a.config.CustomTools[idx])
+	if err != nil {
+		return err
+	}
+	if len(report.Refused) > 0 {
+		a.LogSystemError("RemoveCustomTool", fmt.Sprintf("Kept files not created by SkillUI for tool %s: %s", id, report.refusedSummary()))
+	}
+
+	a.config.CustomTools = append(a.config.CustomTools[:idx:idx], a.config.CustomTools[idx+1:]...)
+	autoIDs := make([]string, 0, len(a.config.AutoSyncToolIDs))
+	for _, toolID := range a.config.AutoSyncToolIDs {
+		if toolID != id {
+			autoIDs = append(autoIDs, toolID)
+		}
+	}
+	a.config.AutoSyncToolIDs = autoIDs
+	active := make([]config.CollectionActivation, 0, len(a.config.ActiveCollections))
+	for _, act := range a.config.ActiveCollections {
+		if act.ToolID != id {
+			active = append(active, act)
+		}
+	}
+	a.config.ActiveCollections = active
+	delete(a.config.ToolPaths, id)
+	delete(a.config.ToolResourceModes, id)
+	return a.store.Save(a.config)
+}
+
+// removeCustomToolFiles removes what SkillUI placed for a custom tool (per the
+// sync manifest) and returns the entries that were placed
+func (a *App) removeCustomToolFiles(t config.CustomTool) ([]manifest.Entry, SyncRemovalReport, error) {
+	report := newSyncRemovalReport()
+	def := customToolDef(t)
+	if p := a.config.ToolPaths[t.ID]; p != "" {
+		def.ManualRulesDir = expandHome(p)
+	}
+	placed := a.syncManifest().Entries(func(e manifest.Entry) bool { return e.ToolID == t.ID })
+	for _, e := range placed {
+		if err := a.removeSynced(e.Project, e.Skill, def, false, &report); err != nil {
+			return nil, report, err
+		}
+	}
+	return placed, report, nil
+}
+
+// normalizeCustomTool trims and validates a custom tool
+func (a *App) normalizeCustomTool(t config.CustomTool) (config.CustomTool, error) {
+	t.ID = strings.TrimSpace(t.ID)
+	t.Name = strings.TrimSpace(t.Name)
+	t.RulesDir = strings.TrimSpace(t.RulesDir)
+	t.ProjectRulesDir = strings.Trim(filepath.ToSlash(strings.TrimSpace(t.ProjectRulesDir)), "/")
+	t.FileName = strings.TrimSpace(t.FileName)
+	t.Format = strings.TrimSpace(t.Format)
+
+	if !customToolIDPattern.MatchString(t.ID) {
+		return t, fmt.Errorf("工具 ID 只能包含小写字母、数字、- 和 _: %s", t.ID)
+	}
+	if t.Name == "" {
+		return t, fmt.Errorf("工具名称不能为空")
+	}
+	if t.RulesDir == "" {
+		return t, fmt.Errorf("规则目录不能为空")
+	}
+	if dir := tools.Expand(expandHome(t.RulesDir)); dir == "" || !filepath.IsAbs(dir) {
+		return t, fmt.Errorf("规则目录必须是绝对路径: %s", t.RulesDir)
+	}
+	if t.ProjectRulesDir != "" && !isProjectRelative(t.ProjectRulesDir) {
+		return t, fmt.Errorf("项目级规则目录必须是项目内的相对路径: %s", t.ProjectRulesDir)
+	}
+	if t.FileName != "" {
+		if err := adapter.ValidateNaming(t.FileName); err != nil {
+			return t, err
+		}
+	}
+	if t.Format == "" {
+		t.Format = adapter.FormatMarkdown
+	}
+	known := false
+	for _, f := range adapter.Formats() {
+		if f == t.Format {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return t, fmt.Errorf("未知的输出格式: %s", t.Format)
+	}
+	return t, nil
+}
+
+// isProjectRelative reports whether a slash path stays inside the project root
+func isProjectRelative(p string) bool {
+	if filepath.IsAbs(p) || strings.Contains(p, ":") {
+		return false
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// customToolIndex returns the index of a custom tool in the config, or -1
+func (a *App) customToolIndex(id string) int {
+	for i, t := range a.config.CustomTools {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"skillui/internal/adapter"
+	"skillui/internal/tools"
+)
+
+// 工具详细检测：除是否安装外，还检测已安装版本（CLI 工具运行 --version，桌面应用读取 Info.plist / package.json），
+// 实际读取规则 / 技能的目录，以及是否支持技能文件夹与 frontmatter（按注册表声明的最低版本判断）。
+// 已安装版本不支持工具输出格式所需的能力时，同步降级为 Markdown 文件。
+
+// Version sources
+const (
+	VersionFromBinary   = "binary"
+	VersionFromMetadata = "metadata"
+)
+
+// ToolCapabilities describes what the installed version of a tool supports
+type ToolCapabilities struct {
+	// FolderSkills 为支持技能文件夹（<name>/SKILL.md 及资源文件）
+	FolderSkills bool `json:"folderSkills"`
+	// Frontmatter 为读取规则 frontmatter（description、globs 等）
+	Frontmatter bool `json:"frontmatter"`
+}
+
+// toolVersions remembers the versions found by the last scan, so sync uses
+// the format the installed version supports
+var toolVersions struct {
+	sync.RWMutex
+	byID map[string]string
+}
+
+// binaryVersions caches `--version` output per binary path and modification time
+var binaryVersions struct {
+	sync.Mutex
+	byPath map[string]binaryVersion
+}
+
+type binaryVersion struct {
+	modTime time.Time
+	version string
+}
+
+// detectedToolVersion returns the version of a tool found by the last scan
+func detectedToolVersion(id string) string {
+	toolVersions.RLock()
+	defer toolVersions.RUnlock()
+	return toolVersions.byID[id]
+}
+
+func rememberToolVersion(id, version string) {
+	toolVersions.Lock()
+	defer toolVersions.Unlock()
+	if toolVersions.byID == nil {
+		toolVersions.byID = map[string]string{}
+	}
+	if version == "" {
+		delete(toolVersions.byID, id)
+		return
+	}
+	toolVersions.byID[id] = version
+}
+
+// formatCapability returns the capability an output format relies on
+func formatCapability(format string) string {
+	switch format {
+	case adapter.FormatClaudeSkill:
+		return tools.CapFolderSkills
+	case adapter.FormatCursorMDC, adapter.FormatCopilotInstructions, adapter.FormatWindsurfRule:
+		return tools.CapFrontmatter
+	}
+	return ""
+}
+
+// withVersion returns the definition as it applies to an installed version:
+// when the version is older than the tool's format needs, it falls back to markdown
+func (def ideToolDef) withVersion(version string) ideToolDef {
+	if def.FallbackFrom != "" {
+		def.Format, def.FallbackFrom, def.FallbackMinVersion = def.FallbackFrom, "", ""
+	}
+	capability := formatCapability(def.format())
+	min := def.Capabilities[capability]
+	if capability == "" || min == "" || version == "" || tools.CompareVersions(version, min) >= 0 {
+		return def
+	}
+	def.FallbackFrom, def.FallbackMinVersion = def.format(), min
+	def.Format = adapter.FormatMarkdown
+	return def
+}
+
+// capabilities reports the capabilities the tool declares and the version supports
+func (def ideToolDef) capabilities(version string) ToolCapabilities {
+	supports := func(capability string) bool {
+		min, ok := def.Capabilities[capability]
+		if !ok || formatCapability(def.FallbackFrom) == capability {
+			return false
+		}
+		return min == "" || version == "" || tools.CompareVersions(version, min) >= 0
+	}
+	return ToolCapabilities{
+		FolderSkills: supports(tools.CapFolderSkills),
+		Frontmatter:  supports(tools.CapFrontmatter),
+	}
+}
+
+// readDirs returns the existing directories the tool reads rules or skills from
+func (def ideToolDef) readDirs() []string {
+	dirs := make([]string, 0, 1+len(def.ReadDirs))
+	seen := map[string]bool{}
+	for _, dir := range append([]string{def.getRulesDir()}, def.ReadDirs...) {
+		if dir == "" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// detectToolVersion asks the binary for its version, falling back to the
+// product metadata files of desktop apps
+func detectToolVersion(def ideToolDef, binary string) (string, string) {
+	if binary != "" {
+		if v := cachedBinaryVersion(binary, def.VersionArgs); v != "" {
+			return v, VersionFromBinary
+		}
+	}
+	for _, file := range def.VersionFiles {
+		if v := tools.MetadataVersion(file); v != "" {
+			return v, VersionFromMetadata
+		}
+	}
+	return "", ""
+}
+
+func cachedBinaryVersion(path string, args []string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	binaryVersions.Lock()
+	if cached, ok := binaryVersions.byPath[path]; ok && cached.modTime.Equal(info.ModTime()) {
+		binaryVersions.Unlock()
+		return cached.version
+	}
+	binaryVersions.Unlock()
+
+	version := tools.BinaryVersion(path, args)
+	binaryVersions.Lock()
+	if binaryVersions.byPath == nil {
+		binaryVersions.byPath = map[string]binaryVersion{}
+	}
+	binaryVersions.byPath[path] = binaryVersion{modTime: info.ModTime(), version: version}
+	binaryVersions.Unlock()
+	return version
+}
+
+// describeTool fills the version, read dirs, capabilities and hints of a
+// scanned tool; binary is the tool's command found in PATH, if any
+func describeTool(tool *IDEToolInfo, def ideToolDef, binary string) {
+	if tool.Installed {
+		tool.Version, tool.VersionSource = detectToolVersion(def, binary)
+		rememberToolVersion(def.ID, tool.Version)
+	}
+	def = def.withVersion(tool.Version)
+	tool.Format = def.format()
+	tool.ReadDirs = def.readDirs()
+	tool.Capabilities = def.capabilities(tool.Version)
+	tool.Hints = []string{}
+	if def.FallbackFrom != "" {
+		tool.Hints = append(tool.Hints, fmt.Sprintf("已安装版本 %s 低于 %s 格式所需的 %s，技能将以 Markdown 文件同步", tool.Version, def.FallbackFrom, def.FallbackMinVersion))
+	}
+	if tool.Installed && tool.SkillRulesDir != "" {
+		if _, err := os.Stat(tool.SkillRulesDir); err != nil {
+			tool.Hints = append(tool.Hints, "规则目录尚不存在，首次同步时将自动创建")
+		}
+	}
+}
@@ -0,0 +1,864 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"skillui/internal/api"
+	"skillui/internal/logging"
+	"skillui/internal/process"
+)
+
+// 命令行模式：`skillui <命令>` 不打开窗口，直接调用与界面相同的 App 方法并使用同一数据目录，
+// 便于在 CI、dotfiles 与 SSH 中编写脚本；加 --json 输出 JSON。
+// proc start 在前台运行进程（Ctrl+C 停止）；proc stop 通过 PID 文件停止由任意 SkillUI 实例运行的进程。
+// mcp 以 MCP 服务提供已安装的技能（见 app_mcp.go）。
+
+const cliUsage = `Usage: skillui <command> [flags] [--json]
+
+Skills:
+  skill list                               List installed skills
+  skill install <source> [--name N] [--policy P] [--from git|url|path] [--ref R] [--subpath S]
+                                           Install from a git repository, a zip URL or a local path
+  skill remove <name>...                   Delete skills and the files synced from them
+  skill sync <name>... --tools a,b [--project ID]
+                                           Sync skills to tools (globally or into a project)
+  skill unsync <name>... --tools a,b [--project ID]
+                                           Remove synced skills from tools
+
+Tools:
+  tool scan                                Detect installed AI tools
+  tool set-path <id> <dir> | --clear       Set or clear the rules dir of a tool
+
+Processes:
+  proc list                                List processes and their status
+  proc start <id>...                       Run processes in the foreground (Ctrl+C stops them)
+  proc stop <id>...                        Stop processes run by any SkillUI instance
+  proc logs <id> [-n N] [-f]               Print (and follow) the persisted logs of a process
+
+Lockfile:
+  lock export [path]                       Write skills.lock.json (default: data dir)
+  lock apply [path] [--dry-run] [--prune]
+                                           Make this machine match a lockfile (exit 1 if it does not);
+                                           --prune also removes skills and collections not in it
+
+MCP:
+  mcp [--http] [--port N]                  Serve installed skills to agents over MCP (stdio, or
+                                           streamable HTTP on 127.0.0.1 with the API token)
+
+Flags:
+  --json                                   Print JSON instead of tables
+`
+
+// cliCommands are the first arguments that run the command line instead of the window
+var cliCommands = map[string]bool{
+	"skill": true, "tool": true, "proc": true, "lock": true, "mcp": true,
+	"help": true, "-h": true, "--help": true, "version": true, "--version": true,
+}
+
+// isCLICommand reports whether the program was started as a command
+// (the first argument other than --json names one)
+func isCLICommand(args []string) bool {
+	for _, arg := range args {
+		if arg != "--json" {
+			return cliCommands[arg]
+		}
+	}
+	return false
+}
+
+// cliUsageError is a wrong invocation; it exits with status 2
+type cliUsageError struct {
+	msg string
+}
+
+func (e cliUsageError) Error() string { return e.msg }
+
+func usageErrorf(format string, args ...interface{}) error {
+	return cliUsageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errNotInSync makes a command exit with status 1 after printing its result
+var errNotInSync = errors.New("本机与锁定文件不一致")
+
+// cli runs one command against an App
+type cli struct {
+	app    *App
+	json   bool
+	out    io.Writer
+	errOut io.Writer
+}
+
+// runCLI runs a command and returns the process exit code
+func runCLI(args []string) int {
+	attachConsole()
+	c := &cli{out: os.Stdout, errOut: os.Stderr}
+
+	// --json 可以出现在任意位置
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--json" {
+			c.json = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+
+	if len(rest) == 0 {
+		fmt.Fprint(c.errOut, cliUsage)
+		return 2
+	}
+	switch rest[0] {
+	case "help", "-h", "--help":
+		fmt.Fprint(c.out, cliUsage)
+		return 0
+	case "version", "--version":
+		c.print(map[string]string{"version": appConfig.Version}, func(w io.Writer) {
+			fmt.Fprintln(w, appConfig.Version)
+		})
+		return 0
+	}
+
+	c.app = NewApp()
+	c.app.load()
+	err := c.run(rest)
+	if err == nil {
+		return 0
+	}
+	var usageErr cliUsageError
+	if errors.As(err, &usageErr) {
+		fmt.Fprintf(c.errOut, "skillui: %v\n\n%s", err, cliUsage)
+		return 2
+	}
+	if err != errNotInSync && c.json {
+		c.printJSON(map[string]string{"error": err.Error()})
+	}
+	fmt.Fprintf(c.errOut, "skillui: %v\n", err)
+	return 1
+}
+
+// run dispatches "<group> <command> args..."
+func (c *cli) run(args []string) error {
+	if args[0] == "mcp" {
+		return c.mcpServe(args[1:])
+	}
+	if len(args) < 2 {
+		return usageErrorf("missing command for %q", args[0])
+	}
+	commands := map[string]func([]string) error{
+		"skill list":    c.skillList,
+		"skill install": c.skillInstall,
+		"skill remove":  c.skillRemove,
+		"skill sync":    func(args []string) error { return c.skillSync(args, true) },
+		"skill unsync":  func(args []string) error { return c.skillSync(args, false) },
+		"tool scan":     c.toolScan,
+		"tool set-path": c.toolSetPath,
+		"proc list":     c.procList,
+		"proc start":    c.procStart,
+		"proc stop":     c.procStop,
+		"proc logs":     c.procLogs,
+		"lock export":   c.lockExport,
+		"lock apply":    c.lockApply,
+	}
+	cmd, ok := commands[args[0]+" "+args[1]]
+	if !ok {
+		return usageErrorf("unknown command %q", args[0]+" "+args[1])
+	}
+	return cmd(args[2:])
+}
+
+// print writes v as JSON, or the human readable form
+func (c *cli) print(v interface{}, human func(w io.Writer)) {
+	if c.json {
+		c.printJSON(v)
+		return
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	human(tw)
+	tw.Flush()
+}
+
+func (c *cli) printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintf(c.errOut, "skillui: %v\n", err)
+		return
+	}
+	fmt.Fprintln(c.out, string(data))
+}
+
+// parseFlags parses flags that may appear before, between or after the
+// positional arguments and returns the positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	positional := make([]string, 0)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, usageErrorf("%v", err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// splitList splits a comma separated flag value
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ---- skill ----
+
+func (c *cli) skillList(args []string) error {
+	if _, err := parseFlags(flag.NewFlagSet("skill list", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	skills, err := c.app.ListLocalSkills()
+	if err != nil {
+		return err
+	}
+	c.print(skills, func(w io.Writer) {
+		fmt.Fprintln(w, "NAME\tVERSION\tSOURCE\tSYNCED")
+		for _, s := range skills {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", filepath.Base(s.Location), s.Version, skillSourceType(s), strings.Join(s.SyncedTools, ","))
+		}
+	})
+	return nil
+}
+
+// skillSourceType names where an installed skill came from
+func skillSourceType(s SkillMeta) string {
+	switch {
+	case s.IsMarket:
+		return "market"
+	case s.Source != nil:
+		return s.Source.Type
+	}
+	return "local"
+}
+
+func (c *cli) skillInstall(args []string) error {
+	fs := flag.NewFlagSet("skill install", flag.ContinueOnError)
+	name := fs.String("name", "", "")
+	policy := fs.String("policy", "", "")
+	from := fs.String("from", "", "")
+	ref := fs.String("ref", "", "")
+	subpath := fs.String("subpath", "", "")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("skill install takes one source")
+	}
+	results, err := c.app.InstallSkillFromSource(InstallSourceOptions{
+		Source: positional[0], From: *from, Name: *name, Policy: *policy, Ref: *ref, Subpath: *subpath,
+	})
+	if err != nil {
+		return err
+	}
+	c.print(results, func(w io.Writer) {
+		fmt.Fprintln(w, "NAME\tSTATUS\tERROR")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.Name, r.Status, r.Error)
+		}
+	})
+	for _, r := range results {
+		if r.err() != nil {
+			return fmt.Errorf("部分技能安装失败: %s", summarizeInstallResults(results))
+		}
+	}
+	return nil
+}
+
+func (c *cli) skillRemove(args []string) error {
+	names, err := parseFlags(flag.NewFlagSet("skill remove", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return usageErrorf("skill remove needs at least one skill name")
+	}
+	reports := make(map[string]SyncRemovalReport, len(names))
+	var errs []string
+	for _, name := range names {
+		report, err := c.app.DeleteSkillWithReport(name)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		reports[name] = report
+	}
+	c.print(reports, func(w io.Writer) {
+		for _, name := range names {
+			report, ok := reports[name]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(w, "removed %s\n", name)
+			for _, f := range report.Refused {
+				fmt.Fprintf(w, "  kept %s\t%s\n", f.Path, f.Reason)
+			}
+		}
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *cli) skillSync(args []string, sync bool) error {
+	cmd := "skill unsync"
+	if sync {
+		cmd = "skill sync"
+	}
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	tools := fs.String("tools", "", "")
+	project := fs.String("project", "", "")
+	names, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	toolIDs := splitList(*tools)
+	if len(names) == 0 || len(toolIDs) == 0 {
+		return usageErrorf("%s needs skill names and --tools", cmd)
+	}
+	type syncResult struct {
+		Skill string   `json:"skill"`
+		Tools []string `json:"tools"`
+		Error string   `json:"error,omitempty"`
+	}
+	results := make([]syncResult, 0, len(names))
+	failed := false
+	for _, name := range names {
+		var err error
+		switch {
+		case sync && *project != "":
+			err = c.app.SyncSkillToProject(*project, name, toolIDs)
+		case sync:
+			err = c.app.SyncSkillToTools(name, toolIDs)
+		case *project != "":
+			err = c.app.UnsyncSkillFromProject(*project, name, toolIDs)
+		default:
+			err = c.app.UnsyncSkillFromTools(name, toolIDs)
+		}
+		r := syncResult{Skill: name, Tools: toolIDs}
+		if err != nil {
+			r.Error, failed = err.Error(), true
+		}
+		results = append(results, r)
+	}
+	c.print(results, func(w io.Writer) {
+		fmt.Fprintln(w, "SKILL\tTOOLS\tERROR")
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", r.Skill, strings.Join(r.Tools, ","), r.Error)
+		}
+	})
+	if failed {
+		return fmt.Errorf("部分技能处理失败")
+	}
+	return nil
+}
+
+// ---- tool ----
+
+func (c *cli) toolScan(args []string) error {
+	if _, err := parseFlags(flag.NewFlagSet("tool scan", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	tools, err := c.app.ScanIDETools()
+	if err != nil {
+		return err
+	}
+	c.print(tools, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tINSTALLED\tVERSION\tFORMAT\tRULES DIR")
+		for _, t := range tools {
+			fmt.Fprintf(w, "%s\t%s\t%v\t%s\t%s\t%s\n", t.ID, t.Name, t.Installed, t.Version, t.Format, t.SkillRulesDir)
+		}
+	})
+	return nil
+}
+
+func (c *cli) toolSetPath(args []string) error {
+	fs := flag.NewFlagSet("tool set-path", flag.ContinueOnError)
+	clear := fs.Bool("clear", false, "")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *clear && len(positional) == 1:
+		err = c.app.ClearToolPath(positional[0])
+	case !*clear && len(positional) == 2:
+		err = c.app.SetToolPath(positional[0], positional[1])
+	default:
+		return usageErrorf("usage: tool set-path <id> <dir> | tool set-path <id> --clear")
+	}
+	if err != nil {
+		return err
+	}
+	paths := c.app.GetToolPaths()
+	c.print(map[string]string{"id": positional[0], "path": paths[positional[0]]}, func(w io.Writer) {
+		if p := paths[positional[0]]; p != "" {
+			fmt.Fprintf(w, "%s\t%s\n", positional[0], p)
+		} else {
+			fmt.Fprintf(w, "%s\t(detected)\n", positional[0])
+		}
+	})
+	return nil
+}
+
+// ---- proc ----
+
+func (c *cli) procList(args []string) error {
+	if _, err := parseFlags(flag.NewFlagSet("proc list", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	snapshots := c.app.ListProcesses()
+	// 按配置中的顺序输出
+	order := make(map[string]int, len(c.app.config.Processes))
+	for i, def := range c.app.config.Processes {
+		order[def.ID] = i
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return order[snapshots[i].Definition.ID] < order[snapshots[j].Definition.ID]
+	})
+	c.print(snapshots, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tSTATUS\tPID\tCOMMAND")
+		for _, s := range snapshots {
+			pid := ""
+			if s.PID > 0 {
+				pid = fmt.Sprint(s.PID)
+			}
+			status := string(s.Status)
+			if s.Readiness != "" {
+				status += " (" + string(s.Readiness) + ")"
+			}
+			command := strings.TrimSpace(s.Definition.Command + " " + strings.Join(s.Definition.Args, " "))
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Definition.ID, s.Definition.Name, status, pid, command)
+		}
+	})
+	return nil
+}
+
+// cliLogLine is one process output line printed by proc start / proc logs with --json
+type cliLogLine struct {
+	Process string `json:"process"`
+	logging.Entry
+}
+
+// procStart runs processes in the foreground until they exit for good or the
+// command receives an interrupt, printing their output as it arrives
+func (c *cli) procStart(args []string) error {
+	ids, err := parseFlags(flag.NewFlagSet("proc start", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return usageErrorf("proc start needs at least one process id")
+	}
+	for _, id := range ids {
+		if _, err := c.app.pm.Get(id); err != nil {
+			return fmt.Errorf("进程不存在: %s", id)
+		}
+	}
+
+	lines := make(chan cliLogLine, 256)
+	c.app.pm.SetLogCallback(func(processID, stream, line string) {
+		entry := logging.Entry{Timestamp: time.Now(), Stream: stream, Line: line}
+		c.app.recordProcessLog(processID, entry)
+		lines <- cliLogLine{Process: processID, Entry: entry}
+	})
+	for _, id := range ids {
+		if err := c.app.StartProcess(id); err != nil {
+			c.stopAllDraining(lines)
+			return err
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	interrupted := false
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case l := <-lines:
+			c.printLogLine(l)
+		case <-signals:
+			interrupted = true
+			c.stopAllDraining(lines)
+		case <-ticker.C:
+			active := false
+			for _, id := range ids {
+				if c.app.pm.Active(id) {
+					active = true
+				}
+			}
+			if active {
+				continue
+			}
+			// 输出可能晚于进程退出到达，稍等片刻再打印剩余的行
+			drain := time.After(100 * time.Millisecond)
+			for done := false; !done; {
+				select {
+				case l := <-lines:
+					c.printLogLine(l)
+				case <-drain:
+					done = true
+				}
+			}
+			// 被 Ctrl+C 或 proc stop 停止的不算失败
+			var failed []string
+			for _, id := range ids {
+				snap, err := c.app.pm.Get(id)
+				if err == nil && snap.LastError != "" && !interrupted {
+					reason := snap.LastError
+					if snap.StatusReason != "" {
+						reason += " (" + snap.StatusReason + ")"
+					}
+					failed = append(failed, fmt.Sprintf("%s: %s", id, reason))
+				}
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("进程异常退出: %s", strings.Join(failed, "; "))
+			}
+			return nil
+		}
+	}
+}
+
+// stopAllDraining stops every process while still printing their output:
+// the log callback blocks once lines is full, which would keep a process
+// that logs while shutting down from exiting until it is killed
+func (c *cli) stopAllDraining(lines <-chan cliLogLine) {
+	done := make(chan struct{})
+	go func() {
+		c.app.pm.StopAll()
+		close(done)
+	}()
+	for {
+		select {
+		case l := <-lines:
+			c.printLogLine(l)
+		case <-done:
+			return
+		}
+	}
+}
+
+func (c *cli) printLogLine(l cliLogLine) {
+	if c.json {
+		data, _ := json.Marshal(l)
+		fmt.Fprintln(c.out, string(data))
+		return
+	}
+	w := c.out
+	if l.Stream == "stderr" {
+		w = c.errOut
+	}
+	fmt.Fprintf(w, "[%s] %s\n", l.Process, l.Line)
+}
+
+func (c *cli) procStop(args []string) error {
+	ids, err := parseFlags(flag.NewFlagSet("proc stop", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return usageErrorf("proc stop needs at least one process id")
+	}
+	type stopResult struct {
+		ID      string `json:"id"`
+		Stopped bool   `json:"stopped"`
+		Error   string `json:"error,omitempty"`
+	}
+	results := make([]stopResult, 0, len(ids))
+	failed := false
+	for _, id := range ids {
+		r := stopResult{ID: id}
+		if _, err := c.app.pm.Get(id); err != nil {
+			r.Error = fmt.Sprintf("进程不存在: %s", id)
+		} else if process.RunningPID(c.app.processRunDir(), id) == 0 {
+			r.Error = "进程未运行"
+		} else if err := c.app.StopProcess(id); err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Stopped = true
+		}
+		failed = failed || r.Error != ""
+		results = append(results, r)
+	}
+	c.print(results, func(w io.Writer) {
+		for _, r := range results {
+			if r.Stopped {
+				fmt.Fprintf(w, "stopped %s\n", r.ID)
+			} else {
+				fmt.Fprintf(w, "%s\t%s\n", r.ID, r.Error)
+			}
+		}
+	})
+	if failed {
+		return fmt.Errorf("部分进程未能停止")
+	}
+	return nil
+}
+
+func (c *cli) procLogs(args []string) error {
+	fs := flag.NewFlagSet("proc logs", flag.ContinueOnError)
+	n := fs.Int("n", 100, "")
+	follow := fs.Bool("f", false, "")
+	ids, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		return usageErrorf("proc logs takes one process id")
+	}
+	id := ids[0]
+	if _, err := c.app.pm.Get(id); err != nil {
+		return fmt.Errorf("进程不存在: %s", id)
+	}
+	dir := c.app.processLogDir(id)
+	entries, err := logging.ReadTail(dir, *n)
+	if err != nil {
+		return err
+	}
+	if !*follow {
+		if c.json {
+			c.printJSON(entries)
+			return nil
+		}
+		for _, e := range entries {
+			c.printLogLine(cliLogLine{Process: id, Entry: e})
+		}
+		return nil
+	}
+
+	// 跟随模式：逐行输出（--json 时为 JSON Lines），直到收到中断信号
+	for _, e := range entries {
+		c.printLogLine(cliLogLine{Process: id, Entry: e})
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	tail := newLogFollower(dir)
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-time.After(500 * time.Millisecond):
+			for _, e := range tail.next() {
+				c.printLogLine(cliLogLine{Process: id, Entry: e})
+			}
+		}
+	}
+}
+
+// logFollower reads the lines appended to a rolling log directory since it
+// was created, following rotation to new files
+type logFollower struct {
+	dir    string
+	file   string
+	offset int64
+}
+
+func newLogFollower(dir string) *logFollower {
+	f := &logFollower{dir: dir}
+	if files, err := logging.LogFiles(dir); err == nil && len(files) > 0 {
+		f.file = files[len(files)-1]
+		if info, err := os.Stat(f.file); err == nil {
+			f.offset = info.Size()
+		}
+	}
+	return f
+}
+
+// next returns the entries written since the last call
+func (f *logFollower) next() []logging.Entry {
+	files, err := logging.LogFiles(f.dir)
+	if err != nil {
+		return nil
+	}
+	entries := make([]logging.Entry, 0)
+	for _, file := range files {
+		if file < f.file {
+			continue
+		}
+		if file != f.file {
+			f.file, f.offset = file, 0
+		}
+		entries = append(entries, f.readFrom(file)...)
+	}
+	return entries
+}
+
+func (f *logFollower) readFrom(file string) []logging.Entry {
+	fh, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer fh.Close()
+	if _, err := fh.Seek(f.offset, io.SeekStart); err != nil {
+		return nil
+	}
+	data, err := io.ReadAll(fh)
+	if err != nil {
+		return nil
+	}
+	// 只消费完整的行，写到一半的行留到下次
+	end := strings.LastIndexByte(string(data), '\n')
+	if end < 0 {
+		return nil
+	}
+	f.offset += int64(end + 1)
+	entries := make([]logging.Entry, 0)
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		if e, ok := logging.ParseLine(line); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ---- lock ----
+
+func (c *cli) lockExport(args []string) error {
+	positional, err := parseFlags(flag.NewFlagSet("lock export", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return usageErrorf("lock export takes at most one path")
+	}
+	path, err := c.app.ExportLockfile(strings.Join(positional, ""))
+	if err != nil {
+		return err
+	}
+	c.print(map[string]string{"path": path}, func(w io.Writer) {
+		fmt.Fprintf(w, "wrote %s\n", path)
+	})
+	return nil
+}
+
+func (c *cli) lockApply(args []string) error {
+	fs := flag.NewFlagSet("lock apply", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "")
+	prune := fs.Bool("prune", false, "")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return usageErrorf("lock apply takes at most one path")
+	}
+	result, err := c.app.ApplyLockfile(LockApplyOptions{Path: strings.Join(positional, ""), DryRun: *dryRun, Prune: *prune})
+	if err != nil {
+		return err
+	}
+	c.print(result, func(w io.Writer) {
+		if len(result.Actions) == 0 {
+			fmt.Fprintln(w, "in sync with", result.Path)
+			return
+		}
+		fmt.Fprintln(w, "ACTION\tSKILL/COLLECTION\tTOOL\tRESULT")
+		for _, act := range result.Actions {
+			target := act.Skill
+			if target == "" {
+				target = act.Collection
+			}
+			status := "planned"
+			switch {
+			case act.Error != "":
+				status = act.Error
+			case act.Applied:
+				status = "done"
+			case act.Reason != "":
+				status = act.Reason
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", act.Action, target, act.ToolID, status)
+		}
+	})
+	if !result.InSync {
+		return errNotInSync
+	}
+	return nil
+}
+
+// ---- mcp ----
+
+// mcpServe serves the installed skills over MCP until stdin is closed (stdio)
+// or the command receives an interrupt. The skill directory is watched so
+// clients are told when skills are added, changed or removed.
+func (c *cli) mcpServe(args []string) error {
+	fs := flag.NewFlagSet("mcp", flag.ContinueOnError)
+	httpMode := fs.Bool("http", false, "")
+	port := fs.Int("port", defaultMCPPort, "")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usageErrorf("unexpected argument %q", rest[0])
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// 只需要技能索引；同步副本由窗口实例刷新，避免多个 mcp 进程同时改写
+	c.app.watchIndexOnly = true
+	c.app.startSkillWatcher()
+	defer c.app.stopSkillWatcher()
+
+	if !*httpMode {
+		return c.app.mcpServer().ServeStdio(ctx, os.Stdin, c.out)
+	}
+
+	if err := c.app.ensureAPIToken(); err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(*port)))
+	if err != nil {
+		return fmt.Errorf("启动 MCP 服务失败: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(mcpPath, api.RequireToken(c.app.apiToken, c.app.mcpServer().HTTPHandler()))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", *port, mcpPath)
+	c.print(map[string]string{"url": url, "token": c.app.apiToken()}, func(w io.Writer) {
+		fmt.Fprintf(w, "MCP server listening on %s\n", url)
+		fmt.Fprintf(w, "Authorization: Bearer %s\n", c.app.apiToken())
+	})
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
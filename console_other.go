@@ -0,0 +1,6 @@
+//go:build !windows
+
+package main
+
+// attachConsole is only needed on Windows, where the program has no console of its own
+func attachConsole() {}
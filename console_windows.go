@@ -0,0 +1,29 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// attachConsole connects the command line output to the console that started
+// the program: Windows builds are GUI applications without a console of their own
+func attachConsole() {
+	const attachParentProcess = ^uintptr(0) // (DWORD)-1
+	attach := syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+	if r, _, _ := attach.Call(attachParentProcess); r == 0 {
+		return
+	}
+	// 输出被重定向到文件或管道时保持不变
+	if _, err := os.Stdout.Stat(); err != nil {
+		if f, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+			os.Stdout = f
+		}
+	}
+	if _, err := os.Stderr.Stat(); err != nil {
+		if f, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+			os.Stderr = f
+		}
+	}
+}
@@ -38,7 +38,7 @@ export const skillUiPaginate = (params: PaginateParams) =>
   http.post<ApiResponse<PaginateResult>>('/skill_ui/paginate', params).then(r => r.data);
 
 export const skillUiDownload = (id: number) =>
-  http.post<ApiResponse<{ url: string }>>('/skill_ui/download', { id }).then(r => r.data);
+  http.post<ApiResponse<{ url: string; md5?: string }>>('/skill_ui/download', { id }).then(r => r.data);
 
 export const skillUiDetail = (id: number) =>
   http.post<ApiResponse<{ record: SkillItem & { _preview?: string } }>>('/skill_ui/get', { id }).then(r => r.data);
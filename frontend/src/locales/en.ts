@@ -106,6 +106,12 @@ export default {
       stopped: 'Stopped',
       errored: 'Errored',
       starting: 'Starting',
+      crash_loop: 'Crash loop',
+    },
+    readiness: {
+      starting: 'Checking',
+      healthy: 'Healthy',
+      unhealthy: 'Unhealthy',
     },
     empty: 'No processes found',
   },
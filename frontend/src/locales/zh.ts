@@ -124,6 +124,12 @@ export default {
       stopped: '已停止',
       errored: '失败',
       starting: '启动中',
+      crash_loop: '反复崩溃',
+    },
+    readiness: {
+      starting: '检查中',
+      healthy: '健康',
+      unhealthy: '不健康',
     },
     empty: '暂无进程',
   },
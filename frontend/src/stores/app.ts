@@ -5,7 +5,8 @@ import { process as ProcessModels } from '../../wailsjs/go/models'
 import { i18n } from '../plugins/i18n'
 import { trackError } from '../utils/analytics'
 
-export type ProcessStatus = 'running' | 'stopped' | 'errored' | 'starting'
+export type ProcessStatus = 'running' | 'stopped' | 'errored' | 'starting' | 'crash_loop'
+export type ProcessReadiness = '' | 'starting' | 'healthy' | 'unhealthy'
 
 export interface ProcessItem {
     id: string
@@ -22,6 +23,9 @@ export interface ProcessItem {
     pid: number
     restarts: number
     lastError: string
+    statusReason: string
+    readiness: ProcessReadiness
+    healthError: string
 }
 
 export const useAppStore = defineStore('app', () => {
@@ -72,6 +76,9 @@ export const useAppStore = defineStore('app', () => {
                 pid: snap.pid,
                 restarts: snap.restarts,
                 lastError: snap.lastError || '',
+                statusReason: snap.statusReason || '',
+                readiness: (snap.readiness || '') as ProcessReadiness,
+                healthError: snap.healthError || '',
             }))
         } catch (error) {
             const errorMsg = error instanceof Error ? error.message : String(error)
@@ -201,7 +208,7 @@ export const useAppStore = defineStore('app', () => {
 
     const runningCount = computed(() => processes.value.filter((item) => item.status === 'running').length)
     const stoppedCount = computed(() => processes.value.filter((item) => item.status === 'stopped').length)
-    const failedCount = computed(() => processes.value.filter((item) => item.status === 'errored').length)
+    const failedCount = computed(() => processes.value.filter((item) => item.status === 'errored' || item.status === 'crash_loop').length)
 
     // Initialize app settings from backend
     const initSettings = async () => {
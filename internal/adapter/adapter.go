@@ -0,0 +1,263 @@
+// Package adapter renders a skill into the layout a tool expects inside its
+// rules directory (plain markdown, Cursor .mdc, Claude skill folder, ...) and
+// detects / removes what it rendered.
+package adapter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"skillui/internal/skill"
+)
+
+// Output formats of the built-in adapters
+const (
+	// FormatMarkdown links or copies SKILL.md as <name>.md (the default)
+	FormatMarkdown = "markdown"
+	// FormatCursorMDC writes <name>.mdc with description/globs/alwaysApply frontmatter
+	FormatCursorMDC = "cursor-mdc"
+	// FormatClaudeSkill places the whole skill folder as <name>/SKILL.md
+	FormatClaudeSkill = "claude-skill"
+	// FormatCopilotInstructions writes <name>.instructions.md with applyTo frontmatter
+	FormatCopilotInstructions = "copilot-instructions"
+	// FormatWindsurfRule writes <name>.md with trigger/description/globs frontmatter
+	FormatWindsurfRule = "windsurf-rule"
+)
+
+// Source is the skill being rendered
+type Source struct {
+	// Name is the skill (directory) name, used for the output file names
+	Name string
+	// Dir is the skill directory containing SKILL.md
+	Dir string
+	// Doc is the parsed SKILL.md
+	Doc skill.Document
+	// Ignore holds the skill's .skilluiignore patterns
+	Ignore *skill.Ignore
+}
+
+// LoadSource parses the SKILL.md of a skill directory
+func LoadSource(name, dir string) (Source, error) {
+	doc, err := skill.ParseFile(filepath.Join(dir, "SKILL.md"))
+	if err != nil && err != skill.ErrNoFrontmatter {
+		return Source{}, fmt.Errorf("解析 SKILL.md 失败: %w", err)
+	}
+	ignore, err := skill.LoadIgnore(dir)
+	if err != nil {
+		return Source{}, fmt.Errorf("读取 %s 失败: %w", skill.IgnoreFile, err)
+	}
+	return Source{Name: name, Dir: dir, Doc: doc, Ignore: ignore}, nil
+}
+
+// Resources lists the files synced along with SKILL.md (scripts, templates,
+// reference docs), relative to the skill directory
+func (s Source) Resources() ([]string, error) {
+	files, err := s.Ignore.Files(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]string, 0, len(files))
+	for _, f := range files {
+		if f != "SKILL.md" {
+			resources = append(resources, f)
+		}
+	}
+	return resources, nil
+}
+
+// Resource handling modes of single-file formats
+const (
+	// ResourcesNone places SKILL.md only (the default)
+	ResourcesNone = "none"
+	// ResourcesInline appends the content of every text resource to the rendered file
+	ResourcesInline = "inline"
+	// ResourcesReference rewrites relative links to absolute paths inside the
+	// skill directory and appends a list of all resources
+	ResourcesReference = "reference"
+)
+
+// Options controls how a skill is placed
+type Options struct {
+	// Link places symlinks to the skill instead of copies where the format
+	// allows it; generated files (translated frontmatter) are always written.
+	Link bool
+	// Resources is how single-file formats carry the files next to SKILL.md;
+	// folder formats always place the whole (non-ignored) skill directory.
+	Resources string
+}
+
+// ValidResourceMode reports whether mode is one of the Resources* values ("" means none)
+func ValidResourceMode(mode string) bool {
+	switch mode {
+	case "", ResourcesNone, ResourcesInline, ResourcesReference:
+		return true
+	}
+	return false
+}
+
+// Adapter renders skills in one tool-native format
+type Adapter interface {
+	// Render writes the skill into rulesDir, replacing a previous rendering
+	Render(src Source, rulesDir string, opts Options) error
+	// Paths lists the entries (relative to rulesDir) that belong to a skill:
+	// the one Render creates first, followed by legacy layouts that are still
+	// recognised as synced and cleaned up on removal.
+	Paths(name string) []string
+}
+
+var (
+	mu       sync.RWMutex
+	adapters = map[string]Adapter{}
+)
+
+// Register makes an adapter available under a format name, replacing any
+// adapter previously registered for it
+func Register(format string, a Adapter) {
+	mu.Lock()
+	defer mu.Unlock()
+	adapters[format] = a
+}
+
+// Get returns the adapter of a format; unknown or empty formats fall back to FormatMarkdown
+func Get(format string) Adapter {
+	mu.RLock()
+	defer mu.RUnlock()
+	if a, ok := adapters[format]; ok {
+		return a
+	}
+	return adapters[FormatMarkdown]
+}
+
+// Formats returns the registered format names, sorted
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(adapters))
+	for name := range adapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Detect reports whether a skill is synced into rulesDir by the given adapter
+func Detect(a Adapter, rulesDir, name string) bool {
+	for _, p := range a.Paths(name) {
+		if _, err := os.Lstat(filepath.Join(rulesDir, p)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	Register(FormatMarkdown, markdownAdapter{})
+	Register(FormatCursorMDC, cursorAdapter{})
+	Register(FormatClaudeSkill, claudeSkillAdapter{})
+	Register(FormatCopilotInstructions, copilotAdapter{})
+	Register(FormatWindsurfRule, windsurfAdapter{})
+}
+
+// replaceWith removes dest (file, link or dir) and recreates it through place
+func replaceWith(dest string, place func() error) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(dest); err == nil {
+		if err := os.RemoveAll(dest); err != nil {
+			return err
+		}
+	}
+	return place()
+}
+
+// linkOrCopyFile symlinks src to dest when link is set, falling back to a copy
+func linkOrCopyFile(src, dest string, link bool) error {
+	return replaceWith(dest, func() error {
+		if link && os.Symlink(src, dest) == nil {
+			return nil
+		}
+		return copyFile(src, dest)
+	})
+}
+
+// writeFile writes generated content to dest, replacing a link left by an older sync
+func writeFile(dest string, content []byte) error {
+	return replaceWith(dest, func() error {
+		return os.WriteFile(dest, content, 0644)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// placeTree places the non-ignored files of a skill at dest: a symlink to
+// the whole directory when linking and nothing is ignored, otherwise a real
+// directory holding per-file links (link) or copies.
+func placeTree(src Source, dest string, link bool) error {
+	if link && linksWholeDir(src) && os.Symlink(src.Dir, dest) == nil {
+		return nil
+	}
+	files, err := src.Ignore.Files(src.Dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	for _, rel := range files {
+		from := filepath.Join(src.Dir, filepath.FromSlash(rel))
+		to := filepath.Join(dest, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return err
+		}
+		if link && os.Symlink(from, to) == nil {
+			continue
+		}
+		if err := copyFile(from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linksWholeDir reports whether the skill directory can be linked as a whole:
+// nothing in it is ignored, including the always-ignored entries (.git,
+// skillui.json) that git and market installs leave there
+func linksWholeDir(src Source) bool {
+	clean := true
+	err := filepath.WalkDir(src.Dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == src.Dir {
+			return nil
+		}
+		rel, _ := filepath.Rel(src.Dir, p)
+		if src.Ignore.Match(rel, d.IsDir()) {
+			clean = false
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return err == nil && clean
+}
@@ -0,0 +1,226 @@
+package adapter
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"skillui/internal/skill"
+)
+
+// State is the sync state of one skill in one tool's rules dir
+type State string
+
+const (
+	// StateInSync means the placed files match what Render would produce now
+	StateInSync State = "in-sync"
+	// StateStale means the placed files are an older rendering of the skill
+	// (copy made before the skill changed, link to an old location, legacy layout)
+	StateStale State = "stale"
+	// StateBrokenLink means a placed symlink points to a path that no longer exists
+	StateBrokenLink State = "broken-link"
+	// StateForeign means the entry differs from the skill and was changed after it
+	// (hand-edited copy or a file that was not placed by SkillUI)
+	StateForeign State = "foreign-file"
+	// StateMissing means nothing is placed for the skill
+	StateMissing State = "missing"
+)
+
+// Check classifies what the given adapter placed for a skill inside rulesDir.
+// It returns the state and the entry it looked at (relative to rulesDir).
+func Check(a Adapter, src Source, rulesDir string, opts Options) (State, string, error) {
+	paths := a.Paths(src.Name)
+	primary := paths[0]
+	dest := filepath.Join(rulesDir, primary)
+
+	info, err := os.Lstat(dest)
+	if err != nil {
+		for _, legacy := range paths[1:] {
+			if _, err := os.Lstat(filepath.Join(rulesDir, legacy)); err == nil {
+				return StateStale, legacy, nil
+			}
+		}
+		return StateMissing, primary, nil
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := filepath.EvalSymlinks(dest)
+		if err != nil {
+			return StateBrokenLink, primary, nil
+		}
+		// 链接到旧位置（如技能目录迁移后旧目录仍在）视为过期
+		if !linkPointsInto(target, src.Dir) {
+			return StateStale, primary, nil
+		}
+		// 整个目录的链接会带上被忽略的文件（旧版本在有 .git / skillui.json 时也这样同步）
+		if sameDir(target, src.Dir) && !linksWholeDir(src) {
+			return StateStale, primary, nil
+		}
+	}
+
+	// 与当前技能渲染出的内容比较（链接视为其指向的内容）
+	tmpDir, err := os.MkdirTemp("", "skill-check-*")
+	if err != nil {
+		return "", primary, err
+	}
+	defer os.RemoveAll(tmpDir)
+	expectOpts := opts
+	expectOpts.Link = false
+	if err := a.Render(src, tmpDir, expectOpts); err != nil {
+		return "", primary, err
+	}
+	want, _, err := hashEntry(filepath.Join(tmpDir, primary), src.Ignore)
+	if err != nil {
+		return "", primary, err
+	}
+	got, placedAt, err := hashEntry(dest, src.Ignore)
+	if err != nil {
+		// 目录内逐文件链接失效
+		return StateBrokenLink, primary, nil
+	}
+	if equalHashes(want, got) {
+		return StateInSync, primary, nil
+	}
+
+	// 内容不同：技能在放置之后被修改则为过期，放置的文件更新则视为外部修改
+	if _, skillAt, err := hashEntry(src.Dir, src.Ignore); err == nil && !placedAt.After(skillAt) {
+		return StateStale, primary, nil
+	}
+	return StateForeign, primary, nil
+}
+
+// linkPointsInto reports whether a resolved link target is the skill dir or inside it
+func linkPointsInto(target, skillDir string) bool {
+	resolved, err := filepath.EvalSymlinks(skillDir)
+	if err != nil {
+		resolved = skillDir
+	}
+	rel, err := filepath.Rel(resolved, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// hashEntry hashes a placed file or directory, following symlinks, and
+// returns the newest modification time among its entries. Inside
+// directories the skill's ignore rules apply.
+func hashEntry(path string, ignore *skill.Ignore) (map[string]string, time.Time, error) {
+	var newest time.Time
+	touch := func(p string) {
+		if info, err := os.Lstat(p); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	root, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, newest, err
+	}
+	touch(path)
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, newest, err
+	}
+	if !info.IsDir() {
+		sum, err := skill.HashFile(root)
+		if err != nil {
+			return nil, newest, err
+		}
+		return map[string]string{"": sum}, newest, nil
+	}
+
+	files := make(map[string]string)
+	err = filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		if ignore.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		touch(p)
+		sum, err := skill.HashFile(p) // os.Open follows per-file links
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	return files, newest, err
+}
+
+func equalHashes(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// HashPlaced returns a single content hash of a placed file or directory,
+// used to tell later whether it was modified. Symlinks inside a directory
+// are hashed by their target path, so a per-file link mirror keeps its hash
+// while the linked skill changes.
+func HashPlaced(path string) (string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(path, p)
+		if d.Type()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = "->" + target
+			return nil
+		}
+		sum, err := skill.HashFile(p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := md5.New()
+	for _, k := range keys {
+		io.WriteString(h, k+":"+files[k]+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sameDir reports whether a resolved link target is the skill dir itself
+func sameDir(target, skillDir string) bool {
+	resolved, err := filepath.EvalSymlinks(skillDir)
+	if err != nil {
+		resolved = skillDir
+	}
+	return filepath.Clean(target) == filepath.Clean(resolved)
+}
@@ -0,0 +1,227 @@
+package adapter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tool specific frontmatter keys (globs, alwaysApply, applyTo, trigger) are
+// read from the SKILL.md frontmatter extras. A nested block named after the
+// tool overrides the top-level value, e.g.
+//
+//	globs: "**/*.go"
+//	cursor:
+//	  alwaysApply: true
+//	copilot:
+//	  applyTo: "**/*.ts"
+
+// markdownAdapter places SKILL.md unchanged as <name>.md
+type markdownAdapter struct{}
+
+func (markdownAdapter) Render(src Source, rulesDir string, opts Options) error {
+	dest := filepath.Join(rulesDir, src.Name+".md")
+	if opts.Resources == "" || opts.Resources == ResourcesNone {
+		return linkOrCopyFile(filepath.Join(src.Dir, "SKILL.md"), dest, opts.Link)
+	}
+	body, err := renderBody(src, opts)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(src.Doc.Raw) == "" {
+		return writeFile(dest, []byte(body))
+	}
+	return writeFile(dest, renderDocument(strings.Split(src.Doc.Raw, "\n"), body))
+}
+
+func (markdownAdapter) Paths(name string) []string {
+	// <name> 目录为早期版本的回退同步路径
+	return []string{name + ".md", name}
+}
+
+// cursorAdapter writes a Cursor project rule (.mdc)
+type cursorAdapter struct{}
+
+func (cursorAdapter) Render(src Source, rulesDir string, opts Options) error {
+	meta := src.Doc.Metadata
+	body, err := renderBody(src, opts)
+	if err != nil {
+		return err
+	}
+	alwaysApply, _ := lookupBool(meta.Extras, "cursor", "alwaysApply")
+	fm := []string{
+		"description: " + yamlScalar(oneLine(meta.Description)),
+		"globs: " + lookupList(meta.Extras, "cursor", "globs"),
+		fmt.Sprintf("alwaysApply: %t", alwaysApply),
+	}
+	return writeFile(filepath.Join(rulesDir, src.Name+".mdc"), renderDocument(fm, body))
+}
+
+func (cursorAdapter) Paths(name string) []string {
+	return []string{name + ".mdc", name + ".md"}
+}
+
+// claudeSkillAdapter places the skill folder as <name>/ with SKILL.md and its resources
+type claudeSkillAdapter struct{}
+
+func (claudeSkillAdapter) Render(src Source, rulesDir string, opts Options) error {
+	dest := filepath.Join(rulesDir, src.Name)
+	return replaceWith(dest, func() error {
+		return placeTree(src, dest, opts.Link)
+	})
+}
+
+func (claudeSkillAdapter) Paths(name string) []string {
+	return []string{name, name + ".md"}
+}
+
+// copilotAdapter writes a GitHub Copilot custom instructions file
+type copilotAdapter struct{}
+
+func (copilotAdapter) Render(src Source, rulesDir string, opts Options) error {
+	meta := src.Doc.Metadata
+	body, err := renderBody(src, opts)
+	if err != nil {
+		return err
+	}
+	applyTo := lookupList(meta.Extras, "copilot", "applyTo")
+	if applyTo == "" {
+		applyTo = lookupList(meta.Extras, "copilot", "globs")
+	}
+	if applyTo == "" {
+		applyTo = "**"
+	}
+	fm := []string{
+		"description: " + yamlScalar(oneLine(meta.Description)),
+		"applyTo: " + strconv.Quote(applyTo),
+	}
+	return writeFile(filepath.Join(rulesDir, src.Name+".instructions.md"), renderDocument(fm, body))
+}
+
+func (copilotAdapter) Paths(name string) []string {
+	return []string{name + ".instructions.md", name + ".md"}
+}
+
+// windsurfAdapter writes a Windsurf workspace rule
+type windsurfAdapter struct{}
+
+// Windsurf rule activation modes
+const (
+	windsurfAlwaysOn      = "always_on"
+	windsurfModelDecision = "model_decision"
+	windsurfGlob          = "glob"
+	windsurfManual        = "manual"
+)
+
+func (windsurfAdapter) Render(src Source, rulesDir string, opts Options) error {
+	meta := src.Doc.Metadata
+	body, err := renderBody(src, opts)
+	if err != nil {
+		return err
+	}
+	globs := lookupList(meta.Extras, "windsurf", "globs")
+	trigger := lookupList(meta.Extras, "windsurf", "trigger")
+	switch trigger {
+	case windsurfAlwaysOn, windsurfModelDecision, windsurfGlob, windsurfManual:
+	default:
+		// 未指定 trigger 时按 alwaysApply / globs 推断
+		if always, _ := lookupBool(meta.Extras, "windsurf", "alwaysApply"); always {
+			trigger = windsurfAlwaysOn
+		} else if globs != "" {
+			trigger = windsurfGlob
+		} else {
+			trigger = windsurfModelDecision
+		}
+	}
+	fm := []string{
+		"trigger: " + trigger,
+		"description: " + yamlScalar(oneLine(meta.Description)),
+	}
+	if globs != "" {
+		fm = append(fm, "globs: "+globs)
+	}
+	return writeFile(filepath.Join(rulesDir, src.Name+".md"), renderDocument(fm, body))
+}
+
+func (windsurfAdapter) Paths(name string) []string {
+	return []string{name + ".md"}
+}
+
+// renderDocument joins frontmatter lines and a markdown body
+func renderDocument(frontmatter []string, body string) []byte {
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, line := range frontmatter {
+		b.WriteString(strings.TrimRight(line, " "))
+		b.WriteString("\n")
+	}
+	b.WriteString("---\n")
+	b.WriteString(strings.TrimLeft(body, "\n"))
+	return []byte(b.String())
+}
+
+// lookup returns extras[ns][key] if present, otherwise extras[key]
+func lookup(extras map[string]interface{}, ns, key string) (interface{}, bool) {
+	if nested, ok := extras[ns].(map[string]interface{}); ok {
+		if v, ok := nested[key]; ok {
+			return v, true
+		}
+	}
+	v, ok := extras[key]
+	return v, ok
+}
+
+// lookupList returns a string or list value as a comma separated string
+func lookupList(extras map[string]interface{}, ns, key string) string {
+	v, ok := lookup(extras, ns, key)
+	if !ok || v == nil {
+		return ""
+	}
+	switch val := v.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+				items = append(items, s)
+			}
+		}
+		return strings.Join(items, ",")
+	default:
+		return strings.TrimSpace(fmt.Sprint(val))
+	}
+}
+
+// lookupBool returns a boolean value; the second result is false when unset
+func lookupBool(extras map[string]interface{}, ns, key string) (bool, bool) {
+	v, ok := lookup(extras, ns, key)
+	if !ok {
+		return false, false
+	}
+	switch val := v.(type) {
+	case bool:
+		return val, true
+	case string:
+		return strings.EqualFold(val, "true"), true
+	}
+	return false, false
+}
+
+// oneLine collapses a (possibly multi-line) description into a single line
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// yamlScalar renders s as a YAML scalar, quoting only when needed
+func yamlScalar(s string) string {
+	if s == "" {
+		return ""
+	}
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimRight(string(out), "\n")
+}
@@ -0,0 +1,69 @@
+package adapter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// NamePlaceholder is replaced by the skill name in file name patterns
+const NamePlaceholder = "{name}"
+
+// ValidateNaming checks a file name pattern such as "{name}.prompt.md" or
+// "agent-{name}.md": it must contain {name} and stay inside the rules dir
+func ValidateNaming(pattern string) error {
+	if !strings.Contains(pattern, NamePlaceholder) {
+		return fmt.Errorf("文件名模式必须包含 %s", NamePlaceholder)
+	}
+	p := filepath.ToSlash(pattern)
+	if path.IsAbs(p) || filepath.IsAbs(pattern) {
+		return fmt.Errorf("文件名模式不能是绝对路径")
+	}
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." || part == "." || part == "" {
+			return fmt.Errorf("文件名模式无效: %s", pattern)
+		}
+	}
+	return nil
+}
+
+// WithNaming returns an adapter that renders in the base format but places
+// the result under a custom name (pattern with {name}, "/" separated). An
+// empty pattern returns base unchanged.
+func WithNaming(base Adapter, pattern string) Adapter {
+	if pattern == "" {
+		return base
+	}
+	return namedAdapter{base: base, pattern: pattern}
+}
+
+type namedAdapter struct {
+	base    Adapter
+	pattern string
+}
+
+func (n namedAdapter) Render(src Source, rulesDir string, opts Options) error {
+	if err := os.MkdirAll(rulesDir, 0755); err != nil {
+		return err
+	}
+	// 先按原格式渲染到规则目录内的临时目录（同一文件系统，可直接改名），再移动到自定义文件名
+	tmpDir, err := os.MkdirTemp(rulesDir, ".skillui-render-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := n.base.Render(src, tmpDir, opts); err != nil {
+		return err
+	}
+	rendered := filepath.Join(tmpDir, n.base.Paths(src.Name)[0])
+	dest := filepath.Join(rulesDir, n.Paths(src.Name)[0])
+	return replaceWith(dest, func() error {
+		return os.Rename(rendered, dest)
+	})
+}
+
+func (n namedAdapter) Paths(name string) []string {
+	return []string{filepath.FromSlash(strings.ReplaceAll(n.pattern, NamePlaceholder, name))}
+}
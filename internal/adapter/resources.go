@@ -0,0 +1,125 @@
+package adapter
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxInlineBytes is the largest resource inlined into a single-file rule;
+// larger or binary files are referenced by path instead
+const maxInlineBytes = 256 * 1024
+
+// linkPattern matches the target of markdown links [text](target) and images ![alt](target)
+var linkPattern = regexp.MustCompile(`(!?\[[^\]]*\]\(\s*<?)([^)\s>]+)`)
+
+// renderBody returns the SKILL.md body with the skill's resources carried
+// according to opts.Resources
+func renderBody(src Source, opts Options) (string, error) {
+	body := src.Doc.Body
+	switch opts.Resources {
+	case ResourcesInline:
+		return inlineResources(src, body)
+	case ResourcesReference:
+		return referenceResources(src, body)
+	default:
+		return body, nil
+	}
+}
+
+// inlineResources appends every text resource as a fenced section
+func inlineResources(src Source, body string) (string, error) {
+	resources, err := src.Resources()
+	if err != nil {
+		return "", err
+	}
+	if len(resources) == 0 {
+		return body, nil
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(body, "\n"))
+	b.WriteString("\n\n## Resources\n")
+	for _, rel := range resources {
+		abs := filepath.Join(src.Dir, filepath.FromSlash(rel))
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			return "", err
+		}
+		if len(data) > maxInlineBytes || !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+			fmt.Fprintf(&b, "\n### %s\n\n%s\n", rel, filepath.ToSlash(abs))
+			continue
+		}
+		fence := "```"
+		for strings.Contains(string(data), fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n%s%s\n%s\n%s\n", rel, fence, fenceLanguage(rel), strings.TrimRight(string(data), "\n"), fence)
+	}
+	return b.String(), nil
+}
+
+// referenceResources points relative links at the files in the skill
+// directory and appends a list of all resources with their absolute paths
+func referenceResources(src Source, body string) (string, error) {
+	resources, err := src.Resources()
+	if err != nil {
+		return "", err
+	}
+	body = linkPattern.ReplaceAllStringFunc(body, func(m string) string {
+		parts := linkPattern.FindStringSubmatch(m)
+		target := parts[2]
+		if u, err := url.Parse(target); err != nil || u.Scheme != "" || strings.HasPrefix(target, "#") || path.IsAbs(target) {
+			return m
+		}
+		rel, suffix := target, ""
+		if idx := strings.IndexAny(rel, "#?"); idx >= 0 {
+			rel, suffix = rel[:idx], rel[idx:]
+		}
+		if unescaped, err := url.PathUnescape(rel); err == nil {
+			rel = unescaped
+		}
+		abs := filepath.Join(src.Dir, filepath.FromSlash(rel))
+		if _, err := os.Stat(abs); err != nil {
+			return m
+		}
+		return parts[1] + filepath.ToSlash(abs) + suffix
+	})
+	if len(resources) == 0 {
+		return body, nil
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(body, "\n"))
+	b.WriteString("\n\n## Resources\n\n")
+	for _, rel := range resources {
+		fmt.Fprintf(&b, "- `%s`: %s\n", rel, filepath.ToSlash(filepath.Join(src.Dir, filepath.FromSlash(rel))))
+	}
+	return b.String(), nil
+}
+
+// fenceLanguage guesses the code fence language from a file extension
+func fenceLanguage(name string) string {
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".py":
+		return "python"
+	case ".sh", ".bash":
+		return "bash"
+	case ".js", ".mjs", ".cjs":
+		return "javascript"
+	case ".ts":
+		return "typescript"
+	case ".yml":
+		return "yaml"
+	case ".md":
+		return "markdown"
+	case "":
+		return ""
+	default:
+		return ext[1:]
+	}
+}
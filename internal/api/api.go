@@ -0,0 +1,100 @@
+// Package api holds the transport pieces of SkillUI's local HTTP API: token
+// authentication, JSON helpers, the server-sent-events broker and the
+// OpenAPI description. The routes themselves are bound to App methods in
+// package main (app_api.go).
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Spec is the OpenAPI description of the API
+//
+//go:embed openapi.yaml
+var Spec []byte
+
+// NewToken returns a random access token
+func NewToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// RequireToken rejects requests that do not carry the token, either as
+// "Authorization: Bearer <token>" or, for EventSource clients that cannot set
+// headers, as the token query parameter. Requests whose Host is not a
+// loopback name are rejected too, which defeats DNS rebinding from web pages.
+func RequireToken(token func() string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			WriteError(w, http.StatusForbidden, "host not allowed")
+			return
+		}
+		got := ""
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			got = strings.TrimPrefix(auth, "Bearer ")
+		} else {
+			got = r.URL.Query().Get("token")
+		}
+		want := token()
+		if want == "" || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="skillui"`)
+			WriteError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLoopbackHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// WriteJSON writes v as a JSON response
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// ErrorBody is the body of every error response
+type ErrorBody struct {
+	Error string `json:"error"`
+}
+
+// WriteError writes an error response
+func WriteError(w http.ResponseWriter, status int, msg string) {
+	WriteJSON(w, status, ErrorBody{Error: msg})
+}
+
+// DecodeJSON decodes a request body into v; an empty body leaves v unchanged
+func DecodeJSON(r *http.Request, v interface{}) error {
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
@@ -15,21 +15,112 @@ type AppConfig struct {
 	AutoSyncToolIDs []string `json:"autoSyncToolIDs"`
 	// ToolPaths 记录用户手动指定的工具规则目录（toolID -> 目录路径）。
 	// 自动扫描识别不到时，可手动指定以覆盖默认检测结果。
-	ToolPaths map[string]string    `json:"toolPaths"`
-	Processes []process.Definition `json:"processes"`
+	ToolPaths map[string]string `json:"toolPaths"`
+	// ToolResourceModes 记录单文件格式工具如何携带技能资源文件（toolID -> none / inline / reference），未设置为 none
+	ToolResourceModes map[string]string `json:"toolResourceModes"`
+	// InstallConflictPolicy 安装时遇到同名技能的默认处理策略：skip / overwrite / rename / namespace
+	InstallConflictPolicy string               `json:"installConflictPolicy"`
+	Processes             []process.Definition `json:"processes"`
+	// Projects 为已登记的项目根目录，技能可同步到项目内的工具规则目录（如 .cursor/rules）
+	Projects []Project `json:"projects"`
+	// CustomTools 为用户自定义的工具（内部 Agent、自研分支等），与内置工具一样参与扫描与同步
+	CustomTools []CustomTool `json:"customTools"`
+	// Collections 为命名的技能集合（如 backend、frontend-review），可在工具 / 项目上激活
+	Collections []Collection `json:"collections"`
+	// ActiveCollections 记录各工具（全局或项目内）当前激活的集合
+	ActiveCollections []CollectionActivation `json:"activeCollections"`
+	// API 为本机 HTTP API 设置（默认关闭）
+	API APIConfig `json:"api"`
+	// MCPServers 为 SkillUI 管理的 MCP 服务，可写入各工具的 MCP 配置文件
+	MCPServers []MCPServer `json:"mcpServers"`
+}
+
+// DefaultAPIPort is the default port of the local HTTP API
+const DefaultAPIPort = 17321
+
+// APIConfig configures the opt-in local HTTP API
+type APIConfig struct {
+	Enabled bool `json:"enabled"`
+	// Port 为监听端口（仅 127.0.0.1），为 0 时使用 DefaultAPIPort
+	Port int `json:"port"`
+	// Token 为访问令牌，首次启用时自动生成
+	Token string `json:"token"`
+}
+
+// Project is a registered project (repository) root for project-scoped skill sync
+type Project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// CustomTool is a user-defined sync target
+type CustomTool struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// RulesDir 为全局规则目录，支持 ~ 与 ${HOME} 等变量
+	RulesDir string `json:"rulesDir"`
+	// ProjectRulesDir 为项目级规则目录（相对项目根目录，/ 分隔），为空表示不支持项目级同步
+	ProjectRulesDir string `json:"projectRulesDir"`
+	// FileName 为文件名模式，{name} 替换为技能名（如 {name}.prompt.md），为空使用输出格式的默认文件名
+	FileName string `json:"fileName"`
+	// Format 为输出格式（见 internal/adapter），为空为 markdown
+	Format string `json:"format"`
+}
+
+// MCPServer is an MCP server SkillUI writes into the MCP config of tools
+type MCPServer struct {
+	ID string `json:"id"`
+	// Name 为写入工具配置时的服务名（配置文件中的键）
+	Name string `json:"name"`
+	// Transport 为 stdio（启动命令）或 http（streamable HTTP 地址）
+	Transport string `json:"transport"`
+	// Command / Args / Env 仅对 stdio 有效
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+	// URL / Headers 仅对 http 有效
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	// Tools 为启用了该服务的工具
+	Tools []string `json:"tools"`
+}
+
+// Collection is a named set of skills
+type Collection struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Skills      []string `json:"skills"`
+}
+
+// CollectionActivation is a collection active on a tool; ProjectID is empty
+// for the tool's global rules dir
+type CollectionActivation struct {
+	CollectionID string `json:"collectionId"`
+	ToolID       string `json:"toolId"`
+	ProjectID    string `json:"projectId"`
 }
 
 func DefaultConfig() AppConfig {
 	return AppConfig{
-		Locale:          "zh",
-		AutoStart:       false,
-		LogDir:          "logs",
-		MaxLogLines:     1000,
-		MaxLogFiles:     5,
-		MaxRestart:      5,
-		RestartPolicy:   "on_failure",
-		SkillDir:        "",
-		AutoSyncToolIDs: []string{},
-		ToolPaths:       map[string]string{},
+		Locale:            "zh",
+		AutoStart:         false,
+		LogDir:            "logs",
+		MaxLogLines:       1000,
+		MaxLogFiles:       5,
+		MaxRestart:        5,
+		RestartPolicy:     "on_failure",
+		SkillDir:          "",
+		AutoSyncToolIDs:   []string{},
+		ToolPaths:         map[string]string{},
+		ToolResourceModes: map[string]string{},
+		// 保持与旧版本一致的默认行为，可在设置中改为 skip / rename / namespace
+		InstallConflictPolicy: "overwrite",
+		Projects:              []Project{},
+		CustomTools:           []CustomTool{},
+		Collections:           []Collection{},
+		ActiveCollections:     []CollectionActivation{},
+		MCPServers:            []MCPServer{},
 	}
 }
@@ -0,0 +1,57 @@
+// Package filelock provides advisory file locks. The window and command-line
+// instances of SkillUI share one data directory; the locks keep them from
+// interleaving writes to the same files.
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Lock is a held lock; release it with Unlock
+type Lock struct {
+	f *os.File
+}
+
+// Exclusive blocks until it holds an exclusive lock on path (created if missing)
+func Exclusive(path string) (*Lock, error) {
+	return acquire(path, true, true)
+}
+
+// Shared blocks until it holds a shared lock on path (created if missing)
+func Shared(path string) (*Lock, error) {
+	return acquire(path, false, true)
+}
+
+// TryExclusive takes an exclusive lock on path without waiting; it returns
+// nil and no error when the lock is held elsewhere
+func TryExclusive(path string) (*Lock, error) {
+	return acquire(path, true, false)
+}
+
+func acquire(path string, exclusive, wait bool) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	held, err := lockFile(f, exclusive, wait)
+	if err != nil || !held {
+		f.Close()
+		return nil, err
+	}
+	return &Lock{f: f}, nil
+}
+
+// Unlock releases the lock; it is safe to call on a nil lock
+func (l *Lock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlockFile(l.f)
+	l.f.Close()
+	l.f = nil
+	return err
+}
@@ -0,0 +1,179 @@
+// Package lockfile reads and writes skills.lock.json, a portable description
+// of which skills (and from which exact source) a machine should have, which
+// tools each skill is synced to and which collections are active. A team can
+// commit it next to their code and apply it on every machine.
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FileName is the default lockfile name
+const FileName = "skills.lock.json"
+
+// commitPattern matches a full or abbreviated git commit hash
+var commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// Version is the lockfile format version written by this package
+const Version = 1
+
+// Source types
+const (
+	SourceMarket = "market"
+	SourceGit    = "git"
+	SourceURL    = "url"
+	// SourceLocal 为本地创建或导入的技能，无法从锁定文件自动安装
+	SourceLocal = "local"
+)
+
+// File is the content of skills.lock.json
+type File struct {
+	Version     int          `json:"version"`
+	Skills      []Skill      `json:"skills"`
+	Collections []Collection `json:"collections,omitempty"`
+}
+
+// Skill is one locked skill
+type Skill struct {
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+	// TreeHash 为安装时技能目录的内容哈希，用于发现本地修改
+	TreeHash string `json:"treeHash,omitempty"`
+	// Tools 为技能（全局）同步到的工具
+	Tools []string `json:"tools,omitempty"`
+}
+
+// Source pins where a skill comes from
+type Source struct {
+	Type string `json:"type"`
+	// MarketID / Version 仅对市场来源有效
+	MarketID int    `json:"marketId,omitempty"`
+	Version  string `json:"version,omitempty"`
+	// RepoURL / Ref / Commit / Subpath 仅对 git 来源有效，Subpath 为技能在仓库中的目录
+	RepoURL string `json:"repoUrl,omitempty"`
+	Ref     string `json:"ref,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Subpath string `json:"subpath,omitempty"`
+	// URL 仅对 URL 来源有效
+	URL string `json:"url,omitempty"`
+	// Hash 为下载的 ZIP 包 MD5（市场与 URL 来源）
+	Hash string `json:"hash,omitempty"`
+}
+
+// Collection is a locked skill collection and the tools it is active on (globally)
+type Collection struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Skills      []string `json:"skills"`
+	Tools       []string `json:"tools,omitempty"`
+}
+
+// Read parses a lockfile
+func Read(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return File{}, fmt.Errorf("invalid lockfile %s: %w", path, err)
+	}
+	if f.Version > Version {
+		return File{}, fmt.Errorf("lockfile %s has version %d, newer than supported %d", path, f.Version, Version)
+	}
+	if err := f.Validate(); err != nil {
+		return File{}, err
+	}
+	return f, nil
+}
+
+// Write saves a lockfile with skills, collections and tool lists sorted, so
+// that the file diffs cleanly under version control
+func Write(path string, f File) error {
+	f.Version = Version
+	f.Normalize()
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Normalize sorts the lockfile content
+func (f *File) Normalize() {
+	if f.Skills == nil {
+		f.Skills = []Skill{}
+	}
+	sort.Slice(f.Skills, func(i, j int) bool { return f.Skills[i].Name < f.Skills[j].Name })
+	for i := range f.Skills {
+		sort.Strings(f.Skills[i].Tools)
+	}
+	sort.Slice(f.Collections, func(i, j int) bool { return f.Collections[i].Name < f.Collections[j].Name })
+	for i := range f.Collections {
+		sort.Strings(f.Collections[i].Tools)
+	}
+}
+
+// Validate checks that names are unique and every source has what it needs to be installed
+func (f File) Validate() error {
+	seen := map[string]bool{}
+	for _, s := range f.Skills {
+		if strings.TrimSpace(s.Name) == "" {
+			return fmt.Errorf("lockfile skill without name")
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("lockfile lists skill %s twice", s.Name)
+		}
+		seen[s.Name] = true
+		src := s.Source
+		switch src.Type {
+		case SourceMarket:
+			if src.MarketID <= 0 {
+				return fmt.Errorf("skill %s: market source without marketId", s.Name)
+			}
+		case SourceGit:
+			if src.RepoURL == "" || (src.Commit == "" && src.Ref == "") {
+				return fmt.Errorf("skill %s: git source needs repoUrl and commit", s.Name)
+			}
+			// 以 - 开头的值会被 git 当作选项解析（如 --upload-pack=...），可执行任意命令
+			if strings.HasPrefix(src.RepoURL, "-") || strings.HasPrefix(src.Ref, "-") || strings.HasPrefix(src.Commit, "-") {
+				return fmt.Errorf("skill %s: git repoUrl, ref and commit must not start with '-'", s.Name)
+			}
+			if src.Commit != "" && !commitPattern.MatchString(src.Commit) {
+				return fmt.Errorf("skill %s: git commit %q is not a hex commit hash", s.Name, src.Commit)
+			}
+		case SourceURL:
+			if src.URL == "" {
+				return fmt.Errorf("skill %s: url source without url", s.Name)
+			}
+		case SourceLocal:
+		default:
+			return fmt.Errorf("skill %s: unknown source type %q", s.Name, src.Type)
+		}
+	}
+	names := map[string]bool{}
+	for _, c := range f.Collections {
+		key := strings.ToLower(strings.TrimSpace(c.Name))
+		if key == "" {
+			return fmt.Errorf("lockfile collection without name")
+		}
+		if names[key] {
+			return fmt.Errorf("lockfile lists collection %s twice", c.Name)
+		}
+		names[key] = true
+	}
+	return nil
+}
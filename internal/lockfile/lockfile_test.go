@@ -0,0 +1,94 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	gitSkill := func(src Source) File {
+		src.Type = SourceGit
+		return File{Skills: []Skill{{Name: "demo", Source: src}}}
+	}
+	tests := []struct {
+		name    string
+		file    File
+		wantErr bool
+	}{
+		{"empty", File{}, false},
+		{"market", File{Skills: []Skill{{Name: "demo", Source: Source{Type: SourceMarket, MarketID: 3}}}}, false},
+		{"market without id", File{Skills: []Skill{{Name: "demo", Source: Source{Type: SourceMarket}}}}, true},
+		{"url", File{Skills: []Skill{{Name: "demo", Source: Source{Type: SourceURL, URL: "https://example.com/s.zip"}}}}, false},
+		{"url without url", File{Skills: []Skill{{Name: "demo", Source: Source{Type: SourceURL}}}}, true},
+		{"local", File{Skills: []Skill{{Name: "demo", Source: Source{Type: SourceLocal}}}}, false},
+		{"unknown type", File{Skills: []Skill{{Name: "demo", Source: Source{Type: "ftp"}}}}, true},
+		{"missing name", File{Skills: []Skill{{Name: " ", Source: Source{Type: SourceLocal}}}}, true},
+		{"duplicate skill", File{Skills: []Skill{
+			{Name: "demo", Source: Source{Type: SourceLocal}},
+			{Name: "demo", Source: Source{Type: SourceLocal}},
+		}}, true},
+
+		{"git commit", gitSkill(Source{RepoURL: "https://github.com/org/skills", Commit: "0123456789abcdef0123456789abcdef01234567"}), false},
+		{"git short commit", gitSkill(Source{RepoURL: "https://github.com/org/skills", Commit: "ABCDEF0"}), false},
+		{"git ref only", gitSkill(Source{RepoURL: "https://github.com/org/skills", Ref: "v1.2.0"}), false},
+		{"git without repo", gitSkill(Source{Commit: "abcdef0"}), true},
+		{"git without commit or ref", gitSkill(Source{RepoURL: "https://github.com/org/skills"}), true},
+		{"git option as repo", gitSkill(Source{RepoURL: "--upload-pack=touch /tmp/x", Ref: "main"}), true},
+		{"git option as ref", gitSkill(Source{RepoURL: "https://github.com/org/skills", Ref: "--output=/tmp/x"}), true},
+		{"git option as commit", gitSkill(Source{RepoURL: "https://github.com/org/skills", Commit: "-abcdef0"}), true},
+		{"git non-hex commit", gitSkill(Source{RepoURL: "https://github.com/org/skills", Commit: "main"}), true},
+		{"git commit too short", gitSkill(Source{RepoURL: "https://github.com/org/skills", Commit: "abc"}), true},
+		{"git commit too long", gitSkill(Source{RepoURL: "https://github.com/org/skills", Commit: "0123456789abcdef0123456789abcdef012345678"}), true},
+
+		{"collections", File{Collections: []Collection{{Name: "Frontend"}, {Name: "Backend"}}}, false},
+		{"collection without name", File{Collections: []Collection{{Name: ""}}}, true},
+		{"duplicate collection ignores case", File{Collections: []Collection{{Name: "Frontend"}, {Name: " frontend"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.file.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	f := File{
+		Skills: []Skill{
+			{Name: "zeta", Source: Source{Type: SourceLocal}, Tools: []string{"cursor", "claude_code"}},
+			{Name: "alpha", Source: Source{Type: SourceMarket, MarketID: 1}},
+		},
+	}
+	if err := Write(path, f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != Version {
+		t.Errorf("Version = %d, want %d", got.Version, Version)
+	}
+	names := []string{got.Skills[0].Name, got.Skills[1].Name}
+	if !reflect.DeepEqual(names, []string{"alpha", "zeta"}) {
+		t.Errorf("skills not sorted: %v", names)
+	}
+	if !reflect.DeepEqual(got.Skills[1].Tools, []string{"claude_code", "cursor"}) {
+		t.Errorf("tools not sorted: %v", got.Skills[1].Tools)
+	}
+}
+
+func TestReadRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(`{"version": 99, "skills": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("Read accepted a lockfile from a newer version")
+	}
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ParseLine parses a line written by RollingStore.Append
+// ("<RFC3339 timestamp> <stream> <line>")
+func ParseLine(s string) (Entry, bool) {
+	parts := strings.SplitN(s, " ", 3)
+	if len(parts) < 2 {
+		return Entry{}, false
+	}
+	ts, err := time.Parse(time.RFC3339, parts[0])
+	if err != nil {
+		return Entry{}, false
+	}
+	entry := Entry{Timestamp: ts, Stream: parts[1]}
+	if len(parts) == 3 {
+		entry.Line = parts[2]
+	}
+	return entry, true
+}
+
+// LogFiles returns the log files of a rolling store directory, oldest first
+func LogFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".log") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	// 文件名为创建时间，按名称排序即按时间排序
+	sort.Strings(files)
+	return files, nil
+}
+
+// ReadTail returns the last n entries persisted in a rolling store directory
+// (all when n <= 0)
+func ReadTail(dir string, n int) ([]Entry, error) {
+	files, err := LogFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0)
+	// 从最新的文件往前读，直到凑够 n 条
+	for i := len(files) - 1; i >= 0; i-- {
+		fileEntries, err := readFile(files[i])
+		if err != nil {
+			continue
+		}
+		entries = append(fileEntries, entries...)
+		if n > 0 && len(entries) >= n {
+			break
+		}
+	}
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
+
+func readFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if entry, ok := ParseLine(scanner.Text()); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
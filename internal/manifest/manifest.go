@@ -0,0 +1,193 @@
+// Package manifest records every file, directory and link SkillUI places into
+// tool rules directories, so that unsync and delete only ever remove what
+// SkillUI created itself. A separate manifest records the MCP server entries
+// SkillUI writes into tool config files.
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"skillui/internal/filelock"
+)
+
+// Entry kinds
+const (
+	KindLink = "link"
+	KindFile = "file"
+	KindDir  = "dir"
+	// KindMCPServer is a server entry in a tool's MCP config file; its Path
+	// is "<config file>#<server name>"
+	KindMCPServer = "mcp-server"
+)
+
+// Entry is one placed file, directory, link or MCP server entry
+type Entry struct {
+	Skill string `json:"skill"`
+	// Server 为 MCP 服务 ID（仅 KindMCPServer）
+	Server string `json:"server,omitempty"`
+	ToolID string `json:"toolId"`
+	// Project 为项目根目录，空表示全局规则目录
+	Project string `json:"project,omitempty"`
+	// Path is the absolute path of the placed entry
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	// Target is the link target (links only)
+	Target string `json:"target,omitempty"`
+	// Hash is the content hash of a placed file or directory (copies only)
+	Hash     string    `json:"hash,omitempty"`
+	PlacedAt time.Time `json:"placedAt"`
+}
+
+// Manifest is the persisted set of placed entries, keyed by path. Other
+// SkillUI instances (window and command line) may change the file: reads pick
+// up a changed file, and every change is applied to the file's current
+// content under a file lock.
+type Manifest struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]Entry
+	// stamp identifies the file version entries were read from
+	stamp fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Load reads the manifest file; a missing or corrupt file yields an empty manifest
+func Load(path string) *Manifest {
+	m := &Manifest{path: path}
+	m.reloadLocked()
+	return m
+}
+
+// reloadLocked reads the file into entries
+func (m *Manifest) reloadLocked() {
+	m.entries = map[string]Entry{}
+	m.stamp = m.statLocked()
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		return
+	}
+	var list []Entry
+	if json.Unmarshal(data, &list) != nil {
+		return
+	}
+	for _, e := range list {
+		m.entries[filepath.Clean(e.Path)] = e
+	}
+}
+
+func (m *Manifest) statLocked() fileStamp {
+	info, err := os.Stat(m.path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// refreshLocked reloads entries when another instance changed the file
+func (m *Manifest) refreshLocked() {
+	if m.statLocked() != m.stamp {
+		m.reloadLocked()
+	}
+}
+
+// update applies change to the current file content and saves it when
+// change reports a modification
+func (m *Manifest) update(change func() bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return err
+	}
+	lock, err := filelock.Exclusive(m.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	m.reloadLocked()
+	if !change() {
+		return nil
+	}
+	return m.saveLocked()
+}
+
+// Get returns the entry recorded for a path
+func (m *Manifest) Get(path string) (Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refreshLocked()
+	e, ok := m.entries[filepath.Clean(path)]
+	return e, ok
+}
+
+// Put records (or replaces) an entry and saves the manifest
+func (m *Manifest) Put(e Entry) error {
+	e.Path = filepath.Clean(e.Path)
+	if e.PlacedAt.IsZero() {
+		e.PlacedAt = time.Now()
+	}
+	return m.update(func() bool {
+		m.entries[e.Path] = e
+		return true
+	})
+}
+
+// Delete forgets a path and saves the manifest
+func (m *Manifest) Delete(path string) error {
+	path = filepath.Clean(path)
+	return m.update(func() bool {
+		if _, ok := m.entries[path]; !ok {
+			return false
+		}
+		delete(m.entries, path)
+		return true
+	})
+}
+
+// Entries returns the entries matching filter (all when filter is nil), sorted by path
+func (m *Manifest) Entries(filter func(Entry) bool) []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refreshLocked()
+	list := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		if filter == nil || filter(e) {
+			list = append(list, e)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
+
+func (m *Manifest) saveLocked() error {
+	list := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return err
+	}
+	// 先写临时文件再重命名，避免写入中断留下损坏的清单
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, m.path); err != nil {
+		return err
+	}
+	m.stamp = m.statLocked()
+	return nil
+}
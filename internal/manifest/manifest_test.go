@@ -0,0 +1,81 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPutGetDelete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync_manifest.json")
+	m := Load(path)
+	placed := filepath.Join(t.TempDir(), "rules", "demo.md")
+	if err := m.Put(Entry{Skill: "demo", ToolID: "trae", Path: placed + string(os.PathSeparator), Kind: KindFile, Hash: "h1"}); err != nil {
+		t.Fatal(err)
+	}
+	e, ok := m.Get(placed)
+	if !ok {
+		t.Fatal("entry not found by its cleaned path")
+	}
+	if e.Path != placed || e.Hash != "h1" || e.PlacedAt.IsZero() {
+		t.Errorf("entry = %+v", e)
+	}
+
+	// 重新加载后内容一致
+	if e, ok := Load(path).Get(placed); !ok || e.Hash != "h1" {
+		t.Errorf("reloaded entry = %+v, %v", e, ok)
+	}
+
+	if err := m.Delete(placed); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Get(placed); ok {
+		t.Error("entry still present after Delete")
+	}
+	if err := m.Delete(placed); err != nil {
+		t.Errorf("deleting a missing entry: %v", err)
+	}
+}
+
+func TestChangesFromOtherInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync_manifest.json")
+	window, cli := Load(path), Load(path)
+	if err := window.Put(Entry{Skill: "a", Path: "/rules/a.md", Kind: KindLink, Target: "/skills/a/SKILL.md"}); err != nil {
+		t.Fatal(err)
+	}
+	// 另一个实例在自己的旧内容上修改时不能丢掉 a
+	if err := cli.Put(Entry{Skill: "b", Path: "/rules/b.md", Kind: KindLink, Target: "/skills/b/SKILL.md"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/rules/a.md", "/rules/b.md"} {
+		if _, ok := window.Get(p); !ok {
+			t.Errorf("%s missing in the first instance", p)
+		}
+	}
+	got := cli.Entries(func(e Entry) bool { return e.Skill == "a" })
+	if len(got) != 1 || got[0].Path != filepath.Clean("/rules/a.md") {
+		t.Errorf("Entries(skill a) = %+v", got)
+	}
+}
+
+func TestLoadMissingOrCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	if n := len(Load(filepath.Join(dir, "missing.json")).Entries(nil)); n != 0 {
+		t.Errorf("missing file: %d entries", n)
+	}
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := Load(corrupt)
+	if n := len(m.Entries(nil)); n != 0 {
+		t.Errorf("corrupt file: %d entries", n)
+	}
+	// 损坏的文件在下次写入时被替换
+	if err := m.Put(Entry{Skill: "a", Path: "/rules/a.md", Kind: KindFile}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Load(corrupt).Get("/rules/a.md"); !ok {
+		t.Error("entry not saved over a corrupt file")
+	}
+}
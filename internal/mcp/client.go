@@ -0,0 +1,415 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"skillui/internal/process"
+)
+
+// DefaultProbeTimeout bounds a handshake test
+const DefaultProbeTimeout = 30 * time.Second
+
+// ProbeOptions describes how to reach a server for Probe
+type ProbeOptions struct {
+	// Transport is "stdio" (launch Command) or "http" (POST to URL)
+	Transport string
+	Command   string
+	Args      []string
+	Env       map[string]string
+	URL       string
+	Headers   map[string]string
+	// Timeout defaults to DefaultProbeTimeout
+	Timeout time.Duration
+}
+
+// ProbeResult is what a server reported during the handshake
+type ProbeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+	Tools           []Tool         `json:"tools"`
+	// Stderr is the tail of the error output of a stdio server
+	Stderr   string `json:"stderr,omitempty"`
+	Duration int64  `json:"durationMs"`
+}
+
+// clientConn sends requests to a server
+type clientConn interface {
+	call(ctx context.Context, method string, params interface{}) (json.RawMessage, error)
+	notify(ctx context.Context, method string) error
+	close()
+}
+
+// Probe starts (or connects to) a server, performs the initialize handshake
+// and lists its tools, then shuts it down again
+func Probe(ctx context.Context, opts ProbeOptions) (ProbeResult, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	start := time.Now()
+
+	var (
+		conn   clientConn
+		stderr *tailBuffer
+		err    error
+	)
+	switch opts.Transport {
+	case "stdio":
+		stderr = &tailBuffer{max: 4096}
+		conn, err = startStdio(ctx, opts, stderr)
+	case "http":
+		conn = &httpConn{url: opts.URL, headers: opts.Headers, client: &http.Client{}}
+	default:
+		err = fmt.Errorf("unknown transport %q", opts.Transport)
+	}
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	result, err := handshake(ctx, conn)
+	conn.close()
+	if stderr != nil {
+		result.Stderr = stderr.String()
+	}
+	result.Duration = time.Since(start).Milliseconds()
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("no answer within %s: %w", opts.Timeout, err)
+	}
+	return result, err
+}
+
+func handshake(ctx context.Context, conn clientConn) (ProbeResult, error) {
+	var result ProbeResult
+	raw, err := conn.call(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": LatestProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      Implementation{Name: "skillui", Version: "probe"},
+	})
+	if err != nil {
+		return result, fmt.Errorf("initialize: %w", err)
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return result, fmt.Errorf("initialize: %w", err)
+	}
+	if err := conn.notify(ctx, "notifications/initialized"); err != nil {
+		return result, err
+	}
+	result.Tools = []Tool{}
+	cursor := ""
+	for page := 0; page < 50; page++ {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		raw, err := conn.call(ctx, "tools/list", params)
+		if err != nil {
+			return result, fmt.Errorf("tools/list: %w", err)
+		}
+		var list struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return result, fmt.Errorf("tools/list: %w", err)
+		}
+		result.Tools = append(result.Tools, list.Tools...)
+		if list.NextCursor == "" {
+			break
+		}
+		cursor = list.NextCursor
+	}
+	return result, nil
+}
+
+// incoming is any message from a server
+type incoming struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+func requestBody(id int, method string, params interface{}) []byte {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if id > 0 {
+		msg["id"] = id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+// ---- stdio ----
+
+type stdioConn struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	mu    sync.Mutex
+	next  int
+	// responses receives every response read from stdout
+	responses chan incoming
+	done      chan struct{}
+}
+
+func startStdio(ctx context.Context, opts ProbeOptions, stderr io.Writer) (*stdioConn, error) {
+	if opts.Command == "" {
+		return nil, fmt.Errorf("no command")
+	}
+	cmd := process.Command(ctx, opts.Command, opts.Args, opts.Env)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c := &stdioConn{cmd: cmd, stdin: stdin, responses: make(chan incoming, 16), done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+		for scanner.Scan() {
+			var msg incoming
+			if json.Unmarshal(scanner.Bytes(), &msg) != nil {
+				// 非 JSON 输出（日志等）忽略
+				continue
+			}
+			if msg.Method != "" {
+				if len(msg.ID) > 0 {
+					// 服务端请求（如 roots/list）：探测客户端不支持，回复错误以免服务端等待
+					reply, _ := json.Marshal(response{JSONRPC: "2.0", ID: msg.ID, Error: Errorf(CodeMethodNotFound, "not supported")})
+					c.write(reply)
+				}
+				continue
+			}
+			select {
+			case c.responses <- msg:
+			default:
+			}
+		}
+	}()
+	return c, nil
+}
+
+func (c *stdioConn) write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (c *stdioConn) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	c.mu.Lock()
+	c.next++
+	id := c.next
+	c.mu.Unlock()
+	if err := c.write(requestBody(id, method, params)); err != nil {
+		return nil, err
+	}
+	want := fmt.Sprint(id)
+	for {
+		select {
+		case msg := <-c.responses:
+			if string(msg.ID) != want {
+				continue
+			}
+			if msg.Error != nil {
+				return nil, msg.Error
+			}
+			return msg.Result, nil
+		case <-c.done:
+			return nil, fmt.Errorf("server exited")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *stdioConn) notify(ctx context.Context, method string) error {
+	return c.write(requestBody(0, method, nil))
+}
+
+// close ends the session the way the spec asks: close stdin, then terminate
+func (c *stdioConn) close() {
+	_ = c.stdin.Close()
+	exited := make(chan struct{})
+	go func() {
+		_ = c.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		_ = process.KillTree(c.cmd)
+		<-exited
+	}
+}
+
+// ---- streamable HTTP ----
+
+type httpConn struct {
+	url      string
+	headers  map[string]string
+	client   *http.Client
+	session  string
+	protocol string
+	next     int
+}
+
+func (c *httpConn) post(ctx context.Context, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	if c.session != "" {
+		req.Header.Set(sessionHeader, c.session)
+	}
+	if c.protocol != "" {
+		req.Header.Set("MCP-Protocol-Version", c.protocol)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		c.session = id
+	}
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
+
+func (c *httpConn) call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	c.next++
+	want := fmt.Sprint(c.next)
+	resp, err := c.post(ctx, requestBody(c.next, method, params))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var msg incoming
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		msg, err = readSSEResponse(resp.Body, want)
+	} else {
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxMessageSize)).Decode(&msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if msg.Error != nil {
+		return nil, msg.Error
+	}
+	if method == "initialize" {
+		var init struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(msg.Result, &init)
+		c.protocol = init.ProtocolVersion
+	}
+	return msg.Result, nil
+}
+
+// readSSEResponse reads server-sent events until the response with the wanted id
+func readSSEResponse(body io.Reader, want string) (incoming, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+		var msg incoming
+		if json.Unmarshal([]byte(data.String()), &msg) == nil && msg.Method == "" && string(msg.ID) == want {
+			return msg, nil
+		}
+		data.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return incoming{}, err
+	}
+	return incoming{}, fmt.Errorf("event stream ended without a response")
+}
+
+func (c *httpConn) notify(ctx context.Context, method string) error {
+	resp, err := c.post(ctx, requestBody(0, method, nil))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// close ends the HTTP session when the server issued one
+func (c *httpConn) close() {
+	if c.session == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.url, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set(sessionHeader, c.session)
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	if resp, err := c.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.TrimSpace(string(t.buf))
+}
@@ -0,0 +1,402 @@
+// Package mcp implements the server side of the Model Context Protocol
+// (JSON-RPC 2.0 over stdio or streamable HTTP) for the parts SkillUI needs:
+// tools, resources and prompts. What is served comes from a Provider; the
+// skill provider lives in package main (app_mcp.go).
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// LatestProtocolVersion is the newest protocol revision this server speaks
+const LatestProtocolVersion = "2025-06-18"
+
+// supportedVersions are the revisions accepted from clients, newest first
+var supportedVersions = []string{LatestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	// CodeResourceNotFound is the MCP code for an unknown resource URI
+	CodeResourceNotFound = -32002
+)
+
+// Error is a JSON-RPC error. Providers return it to choose the code sent to
+// the client; any other error is reported as an internal error.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Errorf returns an *Error with the given code
+func Errorf(code int, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Implementation names a client or server
+type Implementation struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version"`
+}
+
+// Tool describes a callable tool
+type Tool struct {
+	Name        string                 `json:"name"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+}
+
+// Content is one content block of a tool result or prompt message
+type Content struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// TextContent returns a text content block
+func TextContent(text string) Content {
+	return Content{Type: "text", Text: text}
+}
+
+// ResourceContent returns an embedded resource content block
+func ResourceContent(r ResourceContents) Content {
+	return Content{Type: "resource", Resource: &r}
+}
+
+// CallToolResult is the result of tools/call. Failures the model should see
+// (bad arguments, unknown skill) are results with IsError set, not errors.
+type CallToolResult struct {
+	Content           []Content   `json:"content"`
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
+}
+
+// Resource describes a readable resource
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+}
+
+// ResourceTemplate describes a family of resources by RFC 6570 URI template
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the content of a resource: Text, or base64 Blob for binary files
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Prompt describes a prompt template
+type Prompt struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is an argument of a prompt
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is one message of a prompt
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// GetPromptResult is the result of prompts/get
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// Provider supplies what the server exposes
+type Provider interface {
+	Tools() []Tool
+	CallTool(ctx context.Context, name string, args json.RawMessage) (CallToolResult, error)
+	Resources() ([]Resource, error)
+	ResourceTemplates() []ResourceTemplate
+	ReadResource(uri string) ([]ResourceContents, error)
+	Prompts() ([]Prompt, error)
+	GetPrompt(name string, args map[string]string) (GetPromptResult, error)
+}
+
+// Notifications sent when the provider's lists change
+const (
+	NotifyResourcesChanged = "notifications/resources/list_changed"
+	NotifyPromptsChanged   = "notifications/prompts/list_changed"
+	NotifyToolsChanged     = "notifications/tools/list_changed"
+)
+
+// Server answers MCP requests from a Provider
+type Server struct {
+	info         Implementation
+	instructions string
+	provider     Provider
+
+	mu sync.Mutex
+	// sinks receive notifications (one per connected stdio stream)
+	sinks map[int]func([]byte)
+	next  int
+}
+
+// NewServer creates a server; instructions (optional) tell the model how to use it
+func NewServer(info Implementation, instructions string, p Provider) *Server {
+	return &Server{info: info, instructions: instructions, provider: p, sinks: map[int]func([]byte){}}
+}
+
+// request is an incoming JSON-RPC message; ID is absent for notifications
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// Handle processes one message (or batch) and returns the encoded response,
+// or nil when nothing is to be sent back (notifications, client responses).
+// initialized reports whether the message contained an initialize request.
+func (s *Server) Handle(ctx context.Context, msg []byte) (out []byte, initialized bool) {
+	msg = trimSpace(msg)
+	if len(msg) > 0 && msg[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(msg, &batch); err != nil || len(batch) == 0 {
+			return encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: Errorf(CodeInvalidRequest, "invalid batch")}), false
+		}
+		responses := make([]json.RawMessage, 0, len(batch))
+		for _, item := range batch {
+			resp, init := s.handleOne(ctx, item)
+			initialized = initialized || init
+			if resp != nil {
+				responses = append(responses, encode(resp))
+			}
+		}
+		if len(responses) == 0 {
+			return nil, initialized
+		}
+		return encode(responses), initialized
+	}
+	resp, init := s.handleOne(ctx, msg)
+	if resp == nil {
+		return nil, init
+	}
+	return encode(resp), init
+}
+
+func (s *Server) handleOne(ctx context.Context, msg []byte) (*response, bool) {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: Errorf(CodeParseError, "parse error: %v", err)}, false
+	}
+	if req.Method == "" {
+		// 客户端对服务端请求的响应；本服务端不发送请求，忽略
+		return nil, false
+	}
+	result, err := s.dispatch(ctx, req)
+	if len(req.ID) == 0 {
+		return nil, req.Method == "initialize"
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else if result != nil {
+		resp.Result = result
+	} else {
+		resp.Result = struct{}{}
+	}
+	return resp, req.Method == "initialize" && err == nil
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"protocolVersion": negotiateVersion(p.ProtocolVersion),
+			"capabilities": map[string]interface{}{
+				"tools":     map[string]interface{}{"listChanged": false},
+				"resources": map[string]interface{}{"listChanged": true},
+				"prompts":   map[string]interface{}{"listChanged": true},
+			},
+			"serverInfo":   s.info,
+			"instructions": s.instructions,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.provider.Tools()}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.Arguments) == 0 || string(p.Arguments) == "null" {
+			p.Arguments = json.RawMessage("{}")
+		}
+		result, err := s.provider.CallTool(ctx, p.Name, p.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		if result.Content == nil {
+			result.Content = []Content{}
+		}
+		return result, nil
+	case "resources/list":
+		resources, err := s.provider.Resources()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"resources": resources}, nil
+	case "resources/templates/list":
+		return map[string]interface{}{"resourceTemplates": s.provider.ResourceTemplates()}, nil
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		contents, err := s.provider.ReadResource(p.URI)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"contents": contents}, nil
+	case "prompts/list":
+		prompts, err := s.provider.Prompts()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"prompts": prompts}, nil
+	case "prompts/get":
+		var p struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.provider.GetPrompt(p.Name, p.Arguments)
+	}
+	if len(req.ID) == 0 {
+		// 未知通知直接忽略
+		return nil, nil
+	}
+	return nil, Errorf(CodeMethodNotFound, "method not found: %s", req.Method)
+}
+
+// Notify sends a notification to every connected stdio client
+func (s *Server) Notify(method string) {
+	data := encode(notification{JSONRPC: "2.0", Method: method})
+	s.mu.Lock()
+	sinks := make([]func([]byte), 0, len(s.sinks))
+	for _, sink := range s.sinks {
+		sinks = append(sinks, sink)
+	}
+	s.mu.Unlock()
+	for _, sink := range sinks {
+		sink(data)
+	}
+}
+
+// addSink registers a notification receiver and returns its removal function
+func (s *Server) addSink(sink func([]byte)) func() {
+	s.mu.Lock()
+	id := s.next
+	s.next++
+	s.sinks[id] = sink
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		delete(s.sinks, id)
+		s.mu.Unlock()
+	}
+}
+
+func negotiateVersion(requested string) string {
+	for _, v := range supportedVersions {
+		if v == requested {
+			return v
+		}
+	}
+	return LatestProtocolVersion
+}
+
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return Errorf(CodeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
+
+func encode(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: Errorf(CodeInternalError, "%v", err)})
+	}
+	return data
+}
+
+func trimSpace(b []byte) []byte {
+	for len(b) > 0 && (b[0] == ' ' || b[0] == '\t' || b[0] == '\r' || b[0] == '\n') {
+		b = b[1:]
+	}
+	return b
+}
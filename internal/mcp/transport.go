@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxMessageSize bounds one incoming message on either transport
+const maxMessageSize = 4 << 20
+
+// ServeStdio reads newline-delimited messages from in and writes responses
+// and notifications to out until in is closed or ctx is done
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	var mu sync.Mutex
+	write := func(data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = out.Write(append(data, '\n'))
+	}
+	remove := s.addSink(write)
+	defer remove()
+
+	lines := make(chan []byte)
+	errc := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		errc <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errc:
+			return err
+		case line := <-lines:
+			if len(trimSpace(line)) == 0 {
+				continue
+			}
+			if resp, _ := s.Handle(ctx, line); resp != nil {
+				write(resp)
+			}
+		}
+	}
+}
+
+// sessionHeader carries the session id of the streamable HTTP transport
+const sessionHeader = "Mcp-Session-Id"
+
+// HTTPHandler serves the streamable HTTP transport. Every POST is answered
+// with a single JSON response; the server never opens an SSE stream, so GET
+// is refused and clients fall back to polling the lists. A session id is
+// issued on initialize and, when a client sends one, must be known.
+func (s *Server) HTTPHandler() http.Handler {
+	var mu sync.Mutex
+	sessions := map[string]bool{}
+	known := func(id string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return sessions[id]
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(sessionHeader)
+		switch r.Method {
+		case http.MethodPost:
+		case http.MethodDelete:
+			mu.Lock()
+			delete(sessions, id)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		default:
+			w.Header().Set("Allow", "POST, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if id != "" && !known(id) {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		resp, initialized := s.Handle(r.Context(), body)
+		if initialized && id == "" {
+			id = newSessionID()
+			mu.Lock()
+			sessions[id] = true
+			mu.Unlock()
+		}
+		if id != "" {
+			w.Header().Set(sessionHeader, id)
+		}
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resp)
+	})
+}
+
+func newSessionID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
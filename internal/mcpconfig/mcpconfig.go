@@ -0,0 +1,295 @@
+// Package mcpconfig writes MCP server entries into the configuration files
+// of AI tools (Claude Code's ~/.claude.json, Cursor's mcp.json, Zed and
+// VS Code settings, ...). Each tool keeps its servers in a map under one
+// top-level key, but the entry layout differs per tool; a Format names the
+// layout. Files are edited in place: top-level keys keep their order and
+// only the one server entry is added, replaced or removed.
+package mcpconfig
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Transports
+const (
+	TransportStdio = "stdio"
+	TransportHTTP  = "http"
+)
+
+// Formats of tool MCP config files
+const (
+	// FormatClaude: mcpServers, {"type":"stdio","command",...} / {"type":"http","url",...}
+	FormatClaude = "claude"
+	// FormatCursor: mcpServers, {"command","args","env"} / {"url","headers"}
+	FormatCursor = "cursor"
+	// FormatWindsurf: mcpServers, like cursor but HTTP servers use "serverUrl"
+	FormatWindsurf = "windsurf"
+	// FormatVSCode: servers, {"type":"stdio",...} / {"type":"http","url",...}
+	FormatVSCode = "vscode"
+	// FormatZed: context_servers in settings.json, {"source":"custom","command",...} / {"url",...}
+	FormatZed = "zed"
+	// FormatGemini: mcpServers, streamable HTTP servers use "httpUrl"
+	FormatGemini = "gemini"
+	// FormatOpenCode: mcp, {"type":"local","command":[cmd, args...],"environment"} / {"type":"remote","url"}
+	FormatOpenCode = "opencode"
+)
+
+// Server is an MCP server as it is written into tool configs
+type Server struct {
+	Transport string            `json:"transport"`
+	Command   string            `json:"command,omitempty"`
+	Args      []string          `json:"args,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// Formats lists the supported formats
+func Formats() []string {
+	return []string{FormatClaude, FormatCursor, FormatWindsurf, FormatVSCode, FormatZed, FormatGemini, FormatOpenCode}
+}
+
+// ServersKey returns the top-level key holding the server map of a format
+func ServersKey(format string) (string, error) {
+	switch format {
+	case FormatClaude, FormatCursor, FormatWindsurf, FormatGemini:
+		return "mcpServers", nil
+	case FormatVSCode:
+		return "servers", nil
+	case FormatZed:
+		return "context_servers", nil
+	case FormatOpenCode:
+		return "mcp", nil
+	}
+	return "", fmt.Errorf("unknown MCP config format %q", format)
+}
+
+// Entry renders a server in the layout of a format
+func Entry(format string, s Server) (json.RawMessage, error) {
+	if _, err := ServersKey(format); err != nil {
+		return nil, err
+	}
+	e := map[string]interface{}{}
+	switch s.Transport {
+	case TransportStdio:
+		if s.Command == "" {
+			return nil, fmt.Errorf("stdio server without command")
+		}
+		switch format {
+		case FormatOpenCode:
+			e["type"] = "local"
+			e["command"] = append([]string{s.Command}, s.Args...)
+			if len(s.Env) > 0 {
+				e["environment"] = s.Env
+			}
+			e["enabled"] = true
+			return json.Marshal(e)
+		case FormatClaude, FormatVSCode:
+			e["type"] = "stdio"
+		case FormatZed:
+			e["source"] = "custom"
+		}
+		e["command"] = s.Command
+		e["args"] = nonNil(s.Args)
+		if len(s.Env) > 0 {
+			e["env"] = s.Env
+		}
+	case TransportHTTP:
+		if s.URL == "" {
+			return nil, fmt.Errorf("http server without url")
+		}
+		urlKey := "url"
+		switch format {
+		case FormatOpenCode:
+			e["type"] = "remote"
+			e["enabled"] = true
+		case FormatClaude, FormatVSCode:
+			e["type"] = "http"
+		case FormatWindsurf:
+			urlKey = "serverUrl"
+		case FormatGemini:
+			urlKey = "httpUrl"
+		}
+		e[urlKey] = s.URL
+		if len(s.Headers) > 0 {
+			e["headers"] = s.Headers
+		}
+	default:
+		return nil, fmt.Errorf("unknown transport %q", s.Transport)
+	}
+	return json.Marshal(e)
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// Hash returns a hash of an entry that ignores formatting and key order, used
+// to tell whether an entry written by SkillUI was changed since
+func Hash(raw json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return ""
+	}
+	canonical, _ := json.Marshal(v)
+	sum := md5.Sum(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// Read returns the server entries of a config file; a missing file has none
+func Read(path, format string) (map[string]json.RawMessage, error) {
+	key, err := ServersKey(format)
+	if err != nil {
+		return nil, err
+	}
+	doc, _, err := readObject(path)
+	if err != nil {
+		return nil, err
+	}
+	servers := map[string]json.RawMessage{}
+	raw, ok := doc.get(key)
+	if !ok {
+		return servers, nil
+	}
+	inner, err := parseObject(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is not an object", path, key)
+	}
+	for _, m := range inner {
+		servers[m.Key] = m.Value
+	}
+	return servers, nil
+}
+
+// Put adds or replaces one server entry
+func Put(path, format, name string, entry json.RawMessage) error {
+	return edit(path, format, func(servers *object) bool {
+		servers.set(name, entry)
+		return true
+	})
+}
+
+// Remove deletes one server entry; it reports whether the entry existed
+func Remove(path, format, name string) (bool, error) {
+	removed := false
+	err := edit(path, format, func(servers *object) bool {
+		removed = servers.remove(name)
+		return removed
+	})
+	return removed, err
+}
+
+// BackupSuffix starts the suffix of the copy saved each time SkillUI
+// rewrites a config file whose comments the rewrite drops; a timestamp
+// follows, so every commented version is kept
+const BackupSuffix = ".skillui-backup"
+
+// edit applies change to the server map of a config file and saves it when
+// change reports a modification
+func edit(path, format string, change func(servers *object) bool) error {
+	key, err := ServersKey(format)
+	if err != nil {
+		return err
+	}
+	doc, hadComments, err := readObject(path)
+	if err != nil {
+		return err
+	}
+	servers := object{}
+	if raw, ok := doc.get(key); ok {
+		if servers, err = parseObject(raw); err != nil {
+			return fmt.Errorf("%s: %q is not an object", path, key)
+		}
+	}
+	if !change(&servers) {
+		return nil
+	}
+	encoded, err := servers.marshal()
+	if err != nil {
+		return err
+	}
+	doc.set(key, encoded)
+	data, err := doc.marshal()
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+
+	if hadComments {
+		if err := backup(path); err != nil {
+			return fmt.Errorf("backup %s: %w", path, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// backup copies a config file to a new timestamped file next to it
+func backup(path string) error {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	base := path + BackupSuffix + "-" + time.Now().Format("20060102-150405")
+	name := base
+	for i := 2; ; i++ {
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if os.IsExist(err) {
+			name = fmt.Sprintf("%s-%d", base, i)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(original); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+}
+
+// readObject reads a JSON (or JSON with comments) object file; a missing or
+// empty file is an empty object
+func readObject(path string) (object, bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return object{}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	clean, hadComments := stripJSONC(data)
+	if len(bytes.TrimSpace(clean)) == 0 {
+		return object{}, hadComments, nil
+	}
+	doc, err := parseObject(clean)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, hadComments, nil
+}
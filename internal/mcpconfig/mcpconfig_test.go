@@ -0,0 +1,159 @@
+package mcpconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		want        string
+		hadComments bool
+	}{
+		{"plain", `{"a": 1}`, `{"a":1}`, false},
+		{"line comment", "{\n  // servers\n  \"a\": 1\n}", `{"a":1}`, true},
+		{"block comment", `{/* x */"a": /* y */ 1}`, `{"a":1}`, true},
+		{"multi-line block comment", "{\n/*\n * a\n */\n\"a\": 1}", `{"a":1}`, true},
+		{"slashes in string", `{"url": "https://example.com//x"}`, `{"url":"https://example.com//x"}`, false},
+		{"comment markers in string", `{"a": "/* not */ // a comment"}`, `{"a":"/* not */ // a comment"}`, false},
+		{"escaped quote in string", `{"a": "say \"//hi\""} // c`, `{"a":"say \"//hi\""}`, true},
+		{"trailing comma in object", "{\"a\": 1,\n}", `{"a":1}`, false},
+		{"trailing comma in array", `{"a": [1, 2, ]}`, `{"a":[1,2]}`, false},
+		{"trailing comma before comment", "{\"a\": [1,  // last\n]}", `{"a":[1]}`, true},
+		{"comma in string kept", `{"a": ",}"}`, `{"a":",}"}`, false},
+		{"comment at end without newline", `{"a": 1} // end`, `{"a":1}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, hadComments := stripJSONC([]byte(tt.in))
+			if hadComments != tt.hadComments {
+				t.Errorf("hadComments = %v, want %v", hadComments, tt.hadComments)
+			}
+			var got bytes.Buffer
+			if err := json.Compact(&got, out); err != nil {
+				t.Fatalf("output is not JSON: %v\n%s", err, out)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEntry(t *testing.T) {
+	stdio := Server{Transport: TransportStdio, Command: "npx", Args: []string{"-y", "srv"}, Env: map[string]string{"K": "v"}}
+	bare := Server{Transport: TransportStdio, Command: "srv"}
+	http := Server{Transport: TransportHTTP, URL: "https://example.com/mcp", Headers: map[string]string{"Authorization": "Bearer t"}}
+	tests := []struct {
+		format string
+		server Server
+		want   string
+	}{
+		{FormatClaude, stdio, `{"args":["-y","srv"],"command":"npx","env":{"K":"v"},"type":"stdio"}`},
+		{FormatClaude, http, `{"headers":{"Authorization":"Bearer t"},"type":"http","url":"https://example.com/mcp"}`},
+		{FormatCursor, stdio, `{"args":["-y","srv"],"command":"npx","env":{"K":"v"}}`},
+		{FormatCursor, bare, `{"args":[],"command":"srv"}`},
+		{FormatCursor, http, `{"headers":{"Authorization":"Bearer t"},"url":"https://example.com/mcp"}`},
+		{FormatWindsurf, stdio, `{"args":["-y","srv"],"command":"npx","env":{"K":"v"}}`},
+		{FormatWindsurf, http, `{"headers":{"Authorization":"Bearer t"},"serverUrl":"https://example.com/mcp"}`},
+		{FormatVSCode, stdio, `{"args":["-y","srv"],"command":"npx","env":{"K":"v"},"type":"stdio"}`},
+		{FormatVSCode, http, `{"headers":{"Authorization":"Bearer t"},"type":"http","url":"https://example.com/mcp"}`},
+		{FormatZed, stdio, `{"args":["-y","srv"],"command":"npx","env":{"K":"v"},"source":"custom"}`},
+		{FormatZed, http, `{"headers":{"Authorization":"Bearer t"},"url":"https://example.com/mcp"}`},
+		{FormatGemini, stdio, `{"args":["-y","srv"],"command":"npx","env":{"K":"v"}}`},
+		{FormatGemini, http, `{"headers":{"Authorization":"Bearer t"},"httpUrl":"https://example.com/mcp"}`},
+		{FormatOpenCode, stdio, `{"command":["npx","-y","srv"],"enabled":true,"environment":{"K":"v"},"type":"local"}`},
+		{FormatOpenCode, http, `{"enabled":true,"headers":{"Authorization":"Bearer t"},"type":"remote","url":"https://example.com/mcp"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.server.Transport, func(t *testing.T) {
+			got, err := Entry(tt.format, tt.server)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntryErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		server Server
+	}{
+		{"unknown format", "emacs", Server{Transport: TransportStdio, Command: "srv"}},
+		{"stdio without command", FormatClaude, Server{Transport: TransportStdio}},
+		{"http without url", FormatClaude, Server{Transport: TransportHTTP}},
+		{"unknown transport", FormatClaude, Server{Transport: "sse", URL: "https://example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Entry(tt.format, tt.server); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestPutKeepsOtherKeysAndBacksUpComments(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.json")
+	original := "{\n  // theme\n  \"theme\": \"dark\",\n  \"context_servers\": {\"old\": {\"command\": \"x\"}},\n}\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := Entry(FormatZed, Server{Transport: TransportStdio, Command: "srv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Put(path, FormatZed, "new", entry); err != nil {
+		t.Fatal(err)
+	}
+	servers, err := Read(path, FormatZed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := servers["old"]; !ok {
+		t.Error("existing server dropped")
+	}
+	if Hash(servers["new"]) != Hash(entry) {
+		t.Errorf("new server = %s, want %s", servers["new"], entry)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "{\n  \"theme\": \"dark\"") {
+		t.Errorf("top-level key order changed:\n%s", data)
+	}
+
+	// 每次丢弃注释的改写都另存一份备份
+	if err := os.WriteFile(path, []byte("// again\n"+string(data)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := Remove(path, FormatZed, "old"); err != nil || !removed {
+		t.Fatalf("Remove = %v, %v", removed, err)
+	}
+	backups, _ := filepath.Glob(path + BackupSuffix + "*")
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2", len(backups))
+	}
+	first, _ := os.ReadFile(backups[0])
+	if string(first) != original {
+		t.Errorf("first backup = %q, want the original file", first)
+	}
+
+	// 没有注释时不再备份
+	if removed, err := Remove(path, FormatZed, "new"); err != nil || !removed {
+		t.Fatalf("Remove = %v, %v", removed, err)
+	}
+	if backups, _ := filepath.Glob(path + BackupSuffix + "*"); len(backups) != 2 {
+		t.Errorf("got %d backups after rewriting a file without comments, want 2", len(backups))
+	}
+}
@@ -0,0 +1,65 @@
+package process
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultBackoffInitial is the delay before the first automatic restart
+	DefaultBackoffInitial = time.Second
+	// DefaultBackoffMultiplier grows the delay for every restart in a row
+	DefaultBackoffMultiplier = 2.0
+	// DefaultBackoffMax caps the delay between restarts
+	DefaultBackoffMax = time.Minute
+	// DefaultHealthyAfter is how long a run must stay up to reset the restart counter
+	DefaultHealthyAfter = time.Minute
+	// DefaultCrashLoopRestarts is the number of restarts in a row reported as a crash loop
+	DefaultCrashLoopRestarts = 5
+)
+
+// Delay returns the delay before the n-th restart in a row (n starts at 1)
+func (b Backoff) Delay(n int) time.Duration {
+	initial := time.Duration(b.InitialMs) * time.Millisecond
+	if initial <= 0 {
+		initial = DefaultBackoffInitial
+	}
+	max := time.Duration(b.MaxMs) * time.Millisecond
+	if max <= 0 {
+		max = DefaultBackoffMax
+	}
+	if max < initial {
+		max = initial
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultBackoffMultiplier
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(n-1))
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	if jitter := math.Min(b.Jitter, 1); jitter > 0 {
+		delay *= 1 + jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(math.Min(delay, float64(max)))
+}
+
+func (d Definition) healthyAfter() time.Duration {
+	if d.HealthyAfterSec > 0 {
+		return time.Duration(d.HealthyAfterSec) * time.Second
+	}
+	return DefaultHealthyAfter
+}
+
+func (d Definition) crashLoopRestarts() int {
+	if d.CrashLoopRestarts > 0 {
+		return d.CrashLoopRestarts
+	}
+	return DefaultCrashLoopRestarts
+}
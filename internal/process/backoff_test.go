@@ -0,0 +1,65 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		n       int
+		want    time.Duration
+	}{
+		{"defaults first restart", Backoff{}, 1, time.Second},
+		{"defaults double", Backoff{}, 3, 4 * time.Second},
+		{"defaults capped at a minute", Backoff{}, 10, time.Minute},
+		{"n below 1 counts as first", Backoff{}, 0, time.Second},
+		{"negative n", Backoff{}, -3, time.Second},
+		{"huge n does not overflow", Backoff{}, 5000, time.Minute},
+		{"custom initial", Backoff{InitialMs: 200}, 1, 200 * time.Millisecond},
+		{"custom multiplier", Backoff{InitialMs: 100, Multiplier: 3}, 3, 900 * time.Millisecond},
+		{"multiplier 1 keeps the delay", Backoff{InitialMs: 500, Multiplier: 1}, 8, 500 * time.Millisecond},
+		{"multiplier below 1 uses the default", Backoff{InitialMs: 100, Multiplier: 0.5}, 3, 400 * time.Millisecond},
+		{"custom max", Backoff{InitialMs: 100, MaxMs: 250}, 3, 250 * time.Millisecond},
+		{"max below initial is raised to initial", Backoff{InitialMs: 2000, MaxMs: 500}, 4, 2 * time.Second},
+		{"negative values use the defaults", Backoff{InitialMs: -1, MaxMs: -1, Multiplier: -2}, 2, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backoff.Delay(tt.n); got != tt.want {
+				t.Errorf("Delay(%d) = %v, want %v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffDelayJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		backoff  Backoff
+		n        int
+		min, max time.Duration
+	}{
+		{"within the jitter range", Backoff{InitialMs: 1000, Jitter: 0.2}, 1, 800 * time.Millisecond, 1200 * time.Millisecond},
+		{"never above max", Backoff{InitialMs: 1000, MaxMs: 1000, Jitter: 0.5}, 3, 500 * time.Millisecond, time.Second},
+		{"jitter above 1 is clamped", Backoff{InitialMs: 1000, Jitter: 5}, 1, 0, 2 * time.Second},
+		{"negative jitter is ignored", Backoff{InitialMs: 1000, Jitter: -1}, 1, time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := map[time.Duration]bool{}
+			for i := 0; i < 200; i++ {
+				got := tt.backoff.Delay(tt.n)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("Delay(%d) = %v, want between %v and %v", tt.n, got, tt.min, tt.max)
+				}
+				seen[got] = true
+			}
+			if tt.min != tt.max && len(seen) < 2 {
+				t.Error("jitter did not vary the delay")
+			}
+		})
+	}
+}
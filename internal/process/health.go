@@ -0,0 +1,250 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Readiness is the result of a process's health checks
+type Readiness string
+
+const (
+	// ReadinessStarting: no check has passed yet since the process started
+	ReadinessStarting Readiness = "starting"
+	// ReadinessHealthy: the last check passed, or fewer than FailureThreshold failed since
+	ReadinessHealthy Readiness = "healthy"
+	// ReadinessUnhealthy: FailureThreshold checks in a row failed
+	ReadinessUnhealthy Readiness = "unhealthy"
+)
+
+// Health check types
+const (
+	HealthCheckHTTP = "http"
+	HealthCheckTCP  = "tcp"
+	HealthCheckExec = "exec"
+)
+
+const (
+	// DefaultHealthInterval is the time between health checks
+	DefaultHealthInterval = 10 * time.Second
+	// DefaultHealthTimeout bounds a single health check
+	DefaultHealthTimeout = 5 * time.Second
+	// DefaultHealthFailureThreshold is the number of failures in a row that makes a process unhealthy
+	DefaultHealthFailureThreshold = 3
+)
+
+// HealthCheck probes a running process. Zero durations and threshold use the
+// defaults above.
+type HealthCheck struct {
+	// Type is http (GET URL), tcp (connect to Address) or exec (run Command, exit code 0 passes)
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+	// ExpectedStatus is the HTTP status that passes (0 = any 2xx or 3xx)
+	ExpectedStatus int      `json:"expectedStatus,omitempty"`
+	Address        string   `json:"address,omitempty"`
+	Command        string   `json:"command,omitempty"`
+	Args           []string `json:"args,omitempty"`
+
+	IntervalSec      int `json:"intervalSec"`
+	TimeoutSec       int `json:"timeoutSec"`
+	FailureThreshold int `json:"failureThreshold"`
+	// StartPeriodSec is a grace period after start in which failures are not
+	// counted until the first check passes
+	StartPeriodSec int `json:"startPeriodSec"`
+	// RestartWhenUnhealthy restarts the process once it is unhealthy,
+	// whatever its restart policy
+	RestartWhenUnhealthy bool `json:"restartWhenUnhealthy"`
+}
+
+// Validate checks the fields required by the check type
+func (h HealthCheck) Validate() error {
+	switch h.Type {
+	case HealthCheckHTTP:
+		if !strings.HasPrefix(h.URL, "http://") && !strings.HasPrefix(h.URL, "https://") {
+			return fmt.Errorf("http health check needs an http(s) url")
+		}
+		if h.ExpectedStatus != 0 && (h.ExpectedStatus < 100 || h.ExpectedStatus > 599) {
+			return fmt.Errorf("invalid expected status %d", h.ExpectedStatus)
+		}
+	case HealthCheckTCP:
+		if _, _, err := net.SplitHostPort(h.Address); err != nil {
+			return fmt.Errorf("tcp health check needs a host:port address: %w", err)
+		}
+	case HealthCheckExec:
+		if strings.TrimSpace(h.Command) == "" {
+			return fmt.Errorf("exec health check needs a command")
+		}
+	default:
+		return fmt.Errorf("unknown health check type %q", h.Type)
+	}
+	if h.IntervalSec < 0 || h.TimeoutSec < 0 || h.FailureThreshold < 0 || h.StartPeriodSec < 0 {
+		return fmt.Errorf("health check durations and threshold must not be negative")
+	}
+	return nil
+}
+
+func (h HealthCheck) interval() time.Duration {
+	if h.IntervalSec > 0 {
+		return time.Duration(h.IntervalSec) * time.Second
+	}
+	return DefaultHealthInterval
+}
+
+func (h HealthCheck) timeout() time.Duration {
+	if h.TimeoutSec > 0 {
+		return time.Duration(h.TimeoutSec) * time.Second
+	}
+	return DefaultHealthTimeout
+}
+
+func (h HealthCheck) failureThreshold() int {
+	if h.FailureThreshold > 0 {
+		return h.FailureThreshold
+	}
+	return DefaultHealthFailureThreshold
+}
+
+// Probe runs the check once; exec checks run in dir with the process's env
+func (h HealthCheck) Probe(ctx context.Context, dir string, env Environment) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout())
+	defer cancel()
+
+	switch h.Type {
+	case HealthCheckHTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		if h.ExpectedStatus != 0 && resp.StatusCode != h.ExpectedStatus {
+			return fmt.Errorf("HTTP %d, expected %d", resp.StatusCode, h.ExpectedStatus)
+		}
+		if h.ExpectedStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode >= 400) {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		return nil
+	case HealthCheckTCP:
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", h.Address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case HealthCheckExec:
+		cmd := Command(ctx, h.Command, h.Args, env)
+		cmd.Dir = dir
+		cmd.Cancel = func() error { return killProcess(cmd) }
+		cmd.WaitDelay = time.Second
+		var output tailWriter
+		cmd.Stdout, cmd.Stderr = &output, &output
+		err := cmd.Run()
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("no result within %s", h.timeout())
+		}
+		if err != nil {
+			if text := strings.TrimSpace(string(output)); text != "" {
+				return fmt.Errorf("%v: %s", err, text)
+			}
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown health check type %q", h.Type)
+}
+
+// tailWriter keeps the last bytes of a check's output for the error message
+type tailWriter []byte
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	*w = append(*w, p...)
+	if len(*w) > 512 {
+		*w = (*w)[len(*w)-512:]
+	}
+	return len(p), nil
+}
+
+// watchHealth runs the health check of a process until ctx ends; cmd is the
+// run it belongs to
+func (m *Manager) watchHealth(ctx context.Context, id string, cmd *exec.Cmd, def Definition) {
+	h := *def.HealthCheck
+	started := time.Now()
+	ticker := time.NewTicker(h.interval())
+	defer ticker.Stop()
+
+	passed := false
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := h.Probe(ctx, def.WorkingDir, def.Env)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			passed = true
+			failures = 0
+			m.setReadiness(id, cmd, ReadinessHealthy, "")
+			continue
+		}
+		if !passed && time.Since(started) < time.Duration(h.StartPeriodSec)*time.Second {
+			m.setReadiness(id, cmd, ReadinessStarting, err.Error())
+			continue
+		}
+		failures++
+		if failures < h.failureThreshold() {
+			// 未达到阈值时保持当前状态，只记录错误
+			m.setReadiness(id, cmd, "", err.Error())
+			continue
+		}
+		m.setReadiness(id, cmd, ReadinessUnhealthy, err.Error())
+		if h.RestartWhenUnhealthy && m.markHealthRestart(id, cmd) {
+			_ = stopCmd(cmd)
+			return
+		}
+	}
+}
+
+// setReadiness records a health check result of the run cmd; an empty
+// readiness keeps the current one
+func (m *Manager) setReadiness(id string, cmd *exec.Cmd, readiness Readiness, healthErr string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.entries[id]
+	if !ok || item.cmd != cmd {
+		return
+	}
+	if readiness != "" {
+		item.readiness = readiness
+	}
+	item.healthError = healthErr
+	if readiness == ReadinessHealthy && time.Since(item.startedAt) >= item.definition.healthyAfter() {
+		item.resetRestarts()
+	}
+}
+
+// markHealthRestart flags the run cmd to be restarted for failing its health
+// check; it reports false when the process is being stopped anyway
+func (m *Manager) markHealthRestart(id string, cmd *exec.Cmd) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.entries[id]
+	if !ok || item.cmd != cmd || item.manuallyStopped {
+		return false
+	}
+	item.healthRestart = true
+	return true
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
@@ -27,15 +28,32 @@ type Manager struct {
 	mu          sync.RWMutex
 	entries     map[string]*entry
 	logCallback LogCallback
+	// pidDir 为 PID 文件目录，为空表示不写 PID 文件（见 pidfile.go）
+	pidDir string
 }
 
 type entry struct {
 	definition      Definition
-	restarts        int
+	restarts        int // automatic restarts since the process last stayed up
 	status          Status
 	cmd             *exec.Cmd
 	lastError       string
 	manuallyStopped bool // true when stopped by user, false when stopped automatically
+	active          bool // true while the run loop supervises the process (running or waiting to restart)
+	// gen is incremented by every Start; a run loop of an older generation exits
+	gen          int
+	crashLoop    bool
+	statusReason string
+	startedAt    time.Time
+	stoppedAt    time.Time
+	nextRestart  time.Time
+	// wake is closed by Stop or Start to end a restart back-off early
+	wake chan struct{}
+	// readiness and healthError are the health check state of the current run
+	readiness   Readiness
+	healthError string
+	// healthRestart is set when the current run is stopped for failing its health check
+	healthRestart bool
 }
 
 func NewManager() *Manager {
@@ -57,17 +75,39 @@ func (m *Manager) List() []Snapshot {
 
 	snapshots := make([]Snapshot, 0, len(m.entries))
 	for _, item := range m.entries {
-		snapshots = append(snapshots, Snapshot{
-			Definition: item.definition,
-			PID:        pidOf(item.cmd),
-			Status:     item.status,
-			Restarts:   item.restarts,
-			LastError:  item.lastError,
-		})
+		snapshots = append(snapshots, item.snapshot())
 	}
 	return snapshots
 }
 
+func (e *entry) snapshot() Snapshot {
+	snap := Snapshot{
+		Definition:   e.definition,
+		PID:          pidOf(e.cmd),
+		Status:       e.status,
+		Restarts:     e.restarts,
+		LastError:    e.lastError,
+		StatusReason: e.statusReason,
+		Readiness:    e.readiness,
+		HealthError:  e.healthError,
+	}
+	if e.wake != nil {
+		// 等待重启期间进程已退出
+		snap.PID = 0
+	}
+	snap.NextRestartAt = timeRef(e.nextRestart)
+	snap.StartedAt = timeRef(e.startedAt)
+	snap.StoppedAt = timeRef(e.stoppedAt)
+	return snap
+}
+
+func timeRef(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
 func (m *Manager) Register(def Definition) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -88,7 +128,7 @@ func (m *Manager) Unregister(id string) error {
 	}
 
 	// Stop the process if running
-	if item.status == StatusRunning || item.status == StatusStarting {
+	if item.active {
 		m.mu.Unlock()
 		_ = m.Stop(id)
 		m.mu.Lock()
@@ -109,21 +149,15 @@ func (m *Manager) Get(id string) (Snapshot, error) {
 		return Snapshot{}, ErrNotFound
 	}
 
-	return Snapshot{
-		Definition: item.definition,
-		PID:        pidOf(item.cmd),
-		Status:     item.status,
-		Restarts:   item.restarts,
-		LastError:  item.lastError,
-	}, nil
+	return item.snapshot(), nil
 }
 
-// StopAll stops all running processes
+// StopAll stops all running processes, including those waiting to be restarted
 func (m *Manager) StopAll() {
 	m.mu.RLock()
 	ids := make([]string, 0, len(m.entries))
 	for id, item := range m.entries {
-		if item.status == StatusRunning || item.status == StatusStarting {
+		if item.active {
 			ids = append(ids, id)
 		}
 	}
@@ -142,18 +176,45 @@ func (m *Manager) Start(ctx context.Context, id string) error {
 		return ErrNotFound
 	}
 
-	if item.status == StatusRunning || item.status == StatusStarting {
+	if item.active && !item.manuallyStopped {
+		// 正在等待自动重启时立即重启，并重新计数
+		if item.wake != nil {
+			item.resetRestarts()
+			close(item.wake)
+			item.wake = nil
+		}
 		m.mu.Unlock()
 		return nil
 	}
 
+	item.gen++
+	gen := item.gen
 	item.status = StatusStarting
+	item.active = true
+	item.manuallyStopped = false
+	item.resetRestarts()
 	m.mu.Unlock()
 
-	go m.run(ctx, id)
+	go m.run(ctx, id, gen)
 	return nil
 }
 
+// resetRestarts clears the restart counter and the crash loop state
+func (e *entry) resetRestarts() {
+	e.restarts = 0
+	e.crashLoop = false
+	e.statusReason = ""
+}
+
+// Active reports whether a process is supervised: running, starting or
+// waiting to be restarted after it exited
+func (m *Manager) Active(id string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	item, ok := m.entries[id]
+	return ok && item.active
+}
+
 func (m *Manager) Stop(id string) error {
 	m.mu.Lock()
 	item, ok := m.entries[id]
@@ -164,9 +225,22 @@ func (m *Manager) Stop(id string) error {
 
 	item.status = StatusStopped
 	item.manuallyStopped = true // Mark as manually stopped to prevent auto-restart
+	item.crashLoop = false
+	item.statusReason = ""
 	cmd := item.cmd
+	if item.wake != nil {
+		// 等待重启中：进程已退出，结束等待即可
+		close(item.wake)
+		item.wake = nil
+		cmd = nil
+	}
 	m.mu.Unlock()
 
+	return stopCmd(cmd)
+}
+
+// stopCmd stops a running command gracefully, killing it after GracefulStopTimeout
+func stopCmd(cmd *exec.Cmd) error {
 	if cmd != nil && cmd.Process != nil {
 		// Try graceful stop first
 		done := make(chan struct{})
@@ -200,18 +274,24 @@ func (m *Manager) Stop(id string) error {
 	return nil
 }
 
-func (m *Manager) run(ctx context.Context, id string) {
+func (m *Manager) run(ctx context.Context, id string, gen int) {
+	defer func() {
+		m.mu.Lock()
+		if item, ok := m.entries[id]; ok && item.gen == gen {
+			item.active = false
+		}
+		m.mu.Unlock()
+	}()
 	for {
 		m.mu.Lock()
 		item, ok := m.entries[id]
-		if !ok {
+		if !ok || item.gen != gen || item.manuallyStopped {
 			m.mu.Unlock()
 			return
 		}
 
 		// Clear previous error on restart
 		item.lastError = ""
-		item.manuallyStopped = false // Reset manual stop flag when starting
 
 		cmd := exec.CommandContext(ctx, item.definition.Command, item.definition.Args...)
 		cmd.Dir = item.definition.WorkingDir
@@ -227,19 +307,40 @@ func (m *Manager) run(ctx context.Context, id string) {
 
 		item.cmd = cmd
 		item.status = StatusRunning
+		item.startedAt = time.Now()
+		item.stoppedAt = time.Time{}
+		item.readiness, item.healthError, item.healthRestart = "", "", false
+		if item.definition.HealthCheck != nil {
+			item.readiness = ReadinessStarting
+		}
+		def := item.definition
+		healthyAfter := def.healthyAfter()
 		logCb := m.logCallback
 		m.mu.Unlock()
 
 		err := cmd.Start()
 		if err != nil {
-			m.recordError(id, err)
-			if !m.shouldRestart(id) {
+			m.recordError(id, gen, err)
+			if !m.shouldRestart(id, gen, false) {
+				return
+			}
+			if !m.waitForRetry(ctx, id, gen) {
 				return
 			}
-			m.waitForRetry(id)
 			continue
 		}
 
+		pidWritten, err := m.writePID(id, cmd.Process.Pid)
+		if err != nil && logCb != nil {
+			logCb(id, "stderr", fmt.Sprintf("skillui: failed to write PID file, the command line cannot stop this process: %v", err))
+		}
+		// 持续运行足够久后重置重启计数，偶发的崩溃不会累积到 MaxRetries
+		healthy := time.AfterFunc(healthyAfter, func() { m.markHealthy(id, cmd) })
+		healthCtx, stopHealth := context.WithCancel(ctx)
+		if def.HealthCheck != nil {
+			go m.watchHealth(healthCtx, id, cmd, def)
+		}
+
 		// Stream stdout
 		if stdout != nil && logCb != nil {
 			go m.streamOutput(id, "stdout", stdout, logCb)
@@ -251,23 +352,49 @@ func (m *Manager) run(ctx context.Context, id string) {
 		}
 
 		err = cmd.Wait()
-		if err != nil {
-			m.recordError(id, err)
-		}
+		healthy.Stop()
+		stopHealth()
+		// PID 文件已被删除说明进程是由其他实例（如命令行）停止的，视为手动停止；
+		// 本次运行未能写入 PID 文件时不据此判断
+		external := !m.releasePID(id, cmd.Process.Pid, pidWritten)
 
 		m.mu.Lock()
+		if item.gen != gen || m.entries[id] != item {
+			// 进程已被重新启动或移除，由新的运行循环接管
+			m.mu.Unlock()
+			return
+		}
+		item.stoppedAt = time.Now()
+		item.readiness = ""
+		if external {
+			item.manuallyStopped = true
+		}
 		// Check if manually stopped - don't auto-restart if user explicitly stopped
 		if item.manuallyStopped {
 			item.status = StatusStopped
+			// 被停止时的退出信号不是错误
+			item.lastError = ""
 			m.mu.Unlock()
 			return
 		}
+		// 健康检查失败而重启：无论重启策略如何都重新启动
+		forced := item.healthRestart
+		item.healthRestart = false
+		if forced {
+			item.lastError = "health check failed: " + item.healthError
+			item.status = StatusErrored
+		}
 		m.mu.Unlock()
+		if err != nil && !forced {
+			m.recordError(id, gen, err)
+		}
 
-		if !m.shouldRestart(id) {
+		if !m.shouldRestart(id, gen, forced) {
+			return
+		}
+		if !m.waitForRetry(ctx, id, gen) {
 			return
 		}
-		m.waitForRetry(id)
 	}
 }
 
@@ -281,12 +408,30 @@ func (m *Manager) streamOutput(id, stream string, reader io.Reader, callback Log
 	}
 }
 
-func (m *Manager) shouldRestart(id string) bool {
+// markHealthy resets the restart counter once a run has stayed up long
+// enough; a process with a health check must also be healthy (otherwise the
+// counter is reset by the first passing check after that, see setReadiness)
+func (m *Manager) markHealthy(id string, cmd *exec.Cmd) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.entries[id]
+	if !ok || item.cmd != cmd || item.wake != nil {
+		return
+	}
+	if item.definition.HealthCheck != nil && item.readiness != ReadinessHealthy {
+		return
+	}
+	item.resetRestarts()
+}
+
+// shouldRestart counts a restart and reports whether the run loop should
+// restart the process; force skips the restart policy
+func (m *Manager) shouldRestart(id string, gen int, force bool) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	item, ok := m.entries[id]
-	if !ok {
+	if !ok || item.gen != gen {
 		return false
 	}
 
@@ -295,6 +440,10 @@ func (m *Manager) shouldRestart(id string) bool {
 		policy = RestartOnFailure
 	}
 
+	if force {
+		policy = RestartAlways
+	}
+
 	if policy == RestartNever {
 		item.status = StatusStopped
 		return false
@@ -306,31 +455,80 @@ func (m *Manager) shouldRestart(id string) bool {
 	}
 
 	item.restarts++
+	healthyAfter := item.definition.healthyAfter()
 	if item.definition.MaxRetries > 0 && item.restarts > item.definition.MaxRetries {
 		item.status = StatusErrored
+		item.crashLoop = false
+		if force {
+			item.statusReason = fmt.Sprintf("gave up after %d restarts without passing the health check: %s", item.definition.MaxRetries, item.healthError)
+		} else {
+			item.statusReason = fmt.Sprintf("gave up after %d restarts without staying up for %s", item.definition.MaxRetries, healthyAfter)
+		}
 		return false
 	}
+	switch {
+	case force:
+		// 进程仍在运行但健康检查失败，与启动后很快退出区分开
+		item.statusReason = fmt.Sprintf("restarted for failing its health check (%d in a row): %s", item.restarts, item.healthError)
+		item.crashLoop = item.restarts >= item.definition.crashLoopRestarts()
+	case item.restarts >= item.definition.crashLoopRestarts():
+		item.crashLoop = true
+		item.statusReason = fmt.Sprintf("exited %d times in a row, each within %s of starting", item.restarts, healthyAfter)
+	default:
+		item.statusReason = ""
+	}
 
 	return true
 }
 
-func (m *Manager) waitForRetry(id string) {
-	m.mu.RLock()
-	_, ok := m.entries[id]
-	m.mu.RUnlock()
-	if !ok {
-		return
+// waitForRetry waits out the restart back-off; it reports false when the
+// process was stopped, restarted by Start or removed in the meantime
+func (m *Manager) waitForRetry(ctx context.Context, id string, gen int) bool {
+	m.mu.Lock()
+	item, ok := m.entries[id]
+	if !ok || item.gen != gen {
+		m.mu.Unlock()
+		return false
 	}
-	// Simple cooldown window to avoid immediate thrash.
-	time.Sleep(2 * time.Second)
+	delay := item.definition.Backoff.Delay(item.restarts)
+	wake := make(chan struct{})
+	item.wake = wake
+	item.nextRestart = time.Now().Add(delay)
+	if item.crashLoop {
+		item.status = StatusCrashLoop
+	}
+	m.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-wake:
+	case <-ctx.Done():
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if item.wake == wake {
+		item.wake = nil
+	}
+	item.nextRestart = time.Time{}
+	if item.gen != gen || m.entries[id] != item || item.manuallyStopped {
+		return false
+	}
+	if ctx.Err() != nil {
+		item.status = StatusStopped
+		return false
+	}
+	return true
 }
 
-func (m *Manager) recordError(id string, err error) {
+func (m *Manager) recordError(id string, gen int, err error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	item, ok := m.entries[id]
-	if !ok {
+	if !ok || item.gen != gen {
 		return
 	}
 	item.lastError = err.Error()
@@ -344,6 +542,21 @@ func pidOf(cmd *exec.Cmd) int {
 	return cmd.Process.Pid
 }
 
+// Command builds a command the way managed processes are started: the
+// current environment plus extra, in its own process group and without a
+// console window. Stop it with KillTree.
+func Command(ctx context.Context, name string, args []string, extra Environment) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), envFromMap(extra)...)
+	setupProcessGroup(cmd)
+	return cmd
+}
+
+// KillTree kills a command started from Command together with its children
+func KillTree(cmd *exec.Cmd) error {
+	return killProcess(cmd)
+}
+
 func envFromMap(extra Environment) []string {
 	output := make([]string, 0, len(extra))
 	for key, value := range extra {
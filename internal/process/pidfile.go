@@ -0,0 +1,106 @@
+package process
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PID files let another SkillUI instance (e.g. the command line) see which
+// processes are running and stop them. The manager writes <dir>/<id>.pid
+// while a process runs; StopPID removes the file before signalling, which the
+// owning manager takes as a manual stop and does not restart the process.
+
+// ErrNotRunning is returned by StopPID when no live process is recorded
+var ErrNotRunning = errors.New("process not running")
+
+// SetPIDDir enables PID files in dir (empty disables them)
+func (m *Manager) SetPIDDir(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pidDir = dir
+}
+
+func pidPath(dir, id string) string {
+	return filepath.Join(dir, id+".pid")
+}
+
+// writePID records a running process and reports whether a PID file was
+// written (false when PID files are disabled)
+func (m *Manager) writePID(id string, pid int) (bool, error) {
+	m.mu.RLock()
+	dir := m.pidDir
+	m.mu.RUnlock()
+	if dir == "" {
+		return false, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(pidPath(dir, id), []byte(strconv.Itoa(pid)), 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// releasePID removes the PID file of a process that exited and reports
+// whether it was still there (false means it was stopped through StopPID).
+// written tells whether this run wrote the file: a file that was never
+// written is not missing because of StopPID.
+func (m *Manager) releasePID(id string, pid int, written bool) bool {
+	m.mu.RLock()
+	dir := m.pidDir
+	m.mu.RUnlock()
+	if dir == "" || !written {
+		return true
+	}
+	recorded, err := readPID(pidPath(dir, id))
+	if err != nil {
+		return false
+	}
+	if recorded == pid {
+		_ = os.Remove(pidPath(dir, id))
+	}
+	return true
+}
+
+func readPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// RunningPID returns the PID recorded for a process in dir when that process
+// is still alive, otherwise 0
+func RunningPID(dir, id string) int {
+	pid, err := readPID(pidPath(dir, id))
+	if err != nil || pid <= 0 || !isProcessRunning(pid) {
+		return 0
+	}
+	return pid
+}
+
+// StopPID stops a process recorded in dir by any manager: it removes the PID
+// file so the owner will not restart it, asks the process group to exit and
+// kills it after GracefulStopTimeout
+func StopPID(dir, id string) error {
+	pid := RunningPID(dir, id)
+	_ = os.Remove(pidPath(dir, id))
+	if pid == 0 {
+		return ErrNotRunning
+	}
+	terminatePID(pid)
+	deadline := time.Now().Add(GracefulStopTimeout)
+	for time.Now().Before(deadline) {
+		if !isProcessRunning(pid) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return killPID(pid)
+}
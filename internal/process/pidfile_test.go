@@ -0,0 +1,85 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPIDFileRelease(t *testing.T) {
+	const pid = 4242
+	tests := []struct {
+		name string
+		// pidDir returns the PID dir of the manager ("" disables PID files)
+		pidDir func(t *testing.T) string
+		// stop removes the PID file as StopPID does
+		stop        bool
+		wantWritten bool
+		wantErr     bool
+		// wantOwn is what releasePID reports: false means stopped externally
+		wantOwn bool
+	}{
+		{name: "disabled", pidDir: func(t *testing.T) string { return "" }, wantOwn: true},
+		{name: "normal exit", pidDir: func(t *testing.T) string { return t.TempDir() }, wantWritten: true, wantOwn: true},
+		{name: "stopped through StopPID", pidDir: func(t *testing.T) string { return t.TempDir() }, stop: true, wantWritten: true, wantOwn: false},
+		{
+			// 写入失败时 PID 文件不存在，不能当作被其他实例停止
+			name: "PID file could not be written",
+			pidDir: func(t *testing.T) string {
+				file := filepath.Join(t.TempDir(), "pids")
+				if err := os.WriteFile(file, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return file
+			},
+			wantErr: true,
+			wantOwn: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			dir := tt.pidDir(t)
+			m.SetPIDDir(dir)
+			written, err := m.writePID("web", pid)
+			if written != tt.wantWritten || (err != nil) != tt.wantErr {
+				t.Fatalf("writePID = %v, %v; want %v, error %v", written, err, tt.wantWritten, tt.wantErr)
+			}
+			if written {
+				if got, err := readPID(pidPath(dir, "web")); err != nil || got != pid {
+					t.Fatalf("PID file = %d, %v", got, err)
+				}
+			}
+			if tt.stop {
+				os.Remove(pidPath(dir, "web"))
+			}
+			if got := m.releasePID("web", pid, written); got != tt.wantOwn {
+				t.Errorf("releasePID = %v, want %v", got, tt.wantOwn)
+			}
+			if dir != "" && written {
+				if _, err := os.Stat(pidPath(dir, "web")); !os.IsNotExist(err) {
+					t.Error("PID file left after release")
+				}
+			}
+		})
+	}
+}
+
+func TestReleasePIDKeepsFileOfNewerRun(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager()
+	m.SetPIDDir(dir)
+	if _, err := m.writePID("web", 1); err != nil {
+		t.Fatal(err)
+	}
+	// 新一轮运行已写入自己的 PID
+	if _, err := m.writePID("web", 2); err != nil {
+		t.Fatal(err)
+	}
+	if !m.releasePID("web", 1, true) {
+		t.Error("exit of the old run taken as an external stop")
+	}
+	if got, err := readPID(pidPath(dir, "web")); err != nil || got != 2 {
+		t.Errorf("PID file = %d, %v; want the newer run's PID", got, err)
+	}
+}
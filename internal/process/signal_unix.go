@@ -77,3 +77,20 @@ func getExitCode(cmd *exec.Cmd) int {
 	}
 	return cmd.ProcessState.ExitCode()
 }
+
+// terminatePID asks a process group (or the process) started by any manager to exit
+func terminatePID(pid int) {
+	if pgid, err := syscall.Getpgid(pid); err == nil {
+		_ = syscall.Kill(-pgid, syscall.SIGTERM)
+		return
+	}
+	_ = syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// killPID force kills a process group (or the process) by PID
+func killPID(pid int) error {
+	if pgid, err := syscall.Getpgid(pid); err == nil {
+		return syscall.Kill(-pgid, syscall.SIGKILL)
+	}
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
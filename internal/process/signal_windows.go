@@ -95,3 +95,30 @@ func getExitCode(cmd *exec.Cmd) int {
 	}
 	return cmd.ProcessState.ExitCode()
 }
+
+// terminatePID sends CTRL_BREAK_EVENT to the process group of a PID
+func terminatePID(pid int) {
+	dll, err := syscall.LoadDLL("kernel32.dll")
+	if err != nil {
+		return
+	}
+	if proc, err := dll.FindProc("GenerateConsoleCtrlEvent"); err == nil {
+		_, _, _ = proc.Call(1, uintptr(pid))
+	}
+}
+
+// killPID kills the process tree of a PID with taskkill
+func killPID(pid int) error {
+	kill := exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(pid))
+	kill.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow: true,
+	}
+	if err := kill.Run(); err != nil {
+		p, err := os.FindProcess(pid)
+		if err != nil {
+			return err
+		}
+		return p.Kill()
+	}
+	return nil
+}
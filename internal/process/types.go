@@ -9,6 +9,9 @@ const (
 	StatusStopped  Status = "stopped"
 	StatusErrored  Status = "errored"
 	StatusStarting Status = "starting"
+	// StatusCrashLoop: the process keeps exiting soon after it starts and is
+	// waiting out a growing back-off before the next restart
+	StatusCrashLoop Status = "crash_loop"
 )
 
 type RestartPolicy string
@@ -31,7 +34,27 @@ type Definition struct {
 	AutoStart     bool          `json:"autoStart"`     // Auto-start on app launch
 	AutoRestart   bool          `json:"autoRestart"`   // Deprecated: use RestartPolicy
 	RestartPolicy RestartPolicy `json:"restartPolicy"` // Restart policy
-	MaxRetries    int           `json:"maxRetries"`    // Max restart attempts
+	MaxRetries    int           `json:"maxRetries"`    // Max restart attempts since the process last stayed up
+	Backoff       Backoff       `json:"backoff"`       // Delay between automatic restarts
+	// HealthyAfterSec is how long a run must stay up before the restart
+	// counter resets (0 = DefaultHealthyAfter)
+	HealthyAfterSec int `json:"healthyAfterSec"`
+	// CrashLoopRestarts is the number of restarts in a row without staying up
+	// that is reported as a crash loop (0 = DefaultCrashLoopRestarts)
+	CrashLoopRestarts int `json:"crashLoopRestarts"`
+	// HealthCheck probes the running process (nil = none, see health.go)
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
+}
+
+// Backoff configures the delay before each automatic restart: Initial after
+// the first failure, multiplied by Multiplier for every further restart in a
+// row, capped at Max. Jitter spreads the delay randomly by up to that
+// fraction. Zero values use the defaults in backoff.go.
+type Backoff struct {
+	InitialMs  int     `json:"initialMs"`
+	Multiplier float64 `json:"multiplier"`
+	MaxMs      int     `json:"maxMs"`
+	Jitter     float64 `json:"jitter"`
 }
 
 type Snapshot struct {
@@ -40,8 +63,17 @@ type Snapshot struct {
 	Status     Status     `json:"status"`
 	Restarts   int        `json:"restarts"`
 	LastError  string     `json:"lastError"`
-	StartedAt  *time.Time `json:"startedAt,omitempty"`
-	StoppedAt  *time.Time `json:"stoppedAt,omitempty"`
+	// StatusReason explains StatusCrashLoop (kept after the next restart until
+	// the process stays up) and StatusErrored once restarts were given up
+	StatusReason string `json:"statusReason,omitempty"`
+	// NextRestartAt is set while waiting to restart the process
+	NextRestartAt *time.Time `json:"nextRestartAt,omitempty"`
+	// Readiness is set while a process with a health check runs
+	Readiness Readiness `json:"readiness,omitempty"`
+	// HealthError is the error of the last failed health check
+	HealthError string     `json:"healthError,omitempty"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	StoppedAt   *time.Time `json:"stoppedAt,omitempty"`
 }
 
 // ProcessStats contains resource usage statistics
@@ -0,0 +1,161 @@
+package skill
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrNoFrontmatter 表示 SKILL.md 没有以 --- 开头的 frontmatter 块
+	ErrNoFrontmatter = errors.New("missing frontmatter")
+	// ErrUnterminatedFrontmatter 表示 frontmatter 缺少结束分隔符 ---
+	ErrUnterminatedFrontmatter = errors.New("unterminated frontmatter")
+)
+
+// Metadata is the typed view of a SKILL.md frontmatter block.
+// Keys without a dedicated field are preserved in Extras.
+type Metadata struct {
+	Name         string                 `yaml:"name" json:"name"`
+	Title        string                 `yaml:"title" json:"title"`
+	Description  string                 `yaml:"description" json:"description"`
+	Owner        string                 `yaml:"owner" json:"owner"`
+	Version      string                 `yaml:"version" json:"version"`
+	License      string                 `yaml:"license" json:"license"`
+	Tags         StringList             `yaml:"tags" json:"tags"`
+	AllowedTools StringList             `yaml:"allowed-tools" json:"allowedTools"`
+	Extras       map[string]interface{} `yaml:",inline" json:"extras"`
+}
+
+// Document is a parsed SKILL.md: its frontmatter and the markdown body after it.
+type Document struct {
+	Metadata Metadata
+	// Raw is the frontmatter text between the two --- lines
+	Raw string
+	// Body is the markdown content following the frontmatter
+	Body string
+	// BodyLine is the 1-based line number where Body starts in the file
+	BodyLine int
+}
+
+// StringList accepts either a YAML sequence or a comma separated scalar,
+// so `tags: [a, b]`, a block list and `tags: a, b` all decode the same way.
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, child := range node.Content {
+			if child.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: list item must be a scalar", child.Line)
+			}
+			if v := strings.TrimSpace(child.Value); v != "" {
+				items = append(items, v)
+			}
+		}
+		*l = items
+	case yaml.ScalarNode:
+		items := make([]string, 0)
+		for _, part := range strings.Split(node.Value, ",") {
+			if v := strings.TrimSpace(part); v != "" {
+				items = append(items, v)
+			}
+		}
+		*l = items
+	default:
+		return fmt.Errorf("line %d: expected a list or a comma separated string", node.Line)
+	}
+	return nil
+}
+
+// SplitFrontmatter separates the leading --- block from the rest of the content.
+// It returns ErrNoFrontmatter when the first line is not ---.
+func SplitFrontmatter(content []byte) (raw string, body string, bodyLine int, err error) {
+	text := strings.TrimPrefix(string(content), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	if len(lines) == 0 || strings.TrimRight(lines[0], " \t") != "---" {
+		return "", text, 1, ErrNoFrontmatter
+	}
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		if line == "---" || line == "..." {
+			raw = strings.Join(lines[1:i], "\n")
+			body = strings.Join(lines[i+1:], "\n")
+			return raw, body, i + 2, nil
+		}
+	}
+	return "", text, 1, ErrUnterminatedFrontmatter
+}
+
+// Parse decodes SKILL.md content. On error the returned Document carries no
+// metadata, so callers never see a half-filled struct.
+func Parse(content []byte) (Document, error) {
+	raw, body, bodyLine, err := SplitFrontmatter(content)
+	if err != nil {
+		return Document{Body: body, BodyLine: bodyLine}, err
+	}
+	doc := Document{Raw: raw, Body: body, BodyLine: bodyLine}
+	if strings.TrimSpace(raw) == "" {
+		return doc, nil
+	}
+
+	var meta Metadata
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(raw)))
+	if err := dec.Decode(&meta); err != nil && !errors.Is(err, io.EOF) {
+		return Document{Body: body, BodyLine: bodyLine}, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	meta.Name = strings.TrimSpace(meta.Name)
+	meta.Title = strings.TrimSpace(meta.Title)
+	meta.Description = strings.TrimSpace(meta.Description)
+	meta.Extras = normalizeExtras(meta.Extras)
+	doc.Metadata = meta
+	return doc, nil
+}
+
+// ParseFile reads and parses a SKILL.md file
+func ParseFile(path string) (Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Document{}, err
+	}
+	return Parse(data)
+}
+
+// normalizeExtras converts nested YAML values into JSON friendly types
+// (map[string]interface{} / []interface{}), so they survive the Wails bridge.
+func normalizeExtras(extras map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(extras))
+	for k, v := range extras {
+		out[k] = normalizeValue(v)
+	}
+	return out
+}
+
+func normalizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return normalizeExtras(val)
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[fmt.Sprint(k)] = normalizeValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = normalizeValue(item)
+		}
+		return out
+	default:
+		return val
+	}
+}
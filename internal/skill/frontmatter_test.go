@@ -0,0 +1,198 @@
+package skill
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitFrontmatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		raw      string
+		body     string
+		bodyLine int
+		err      error
+	}{
+		{
+			name:     "frontmatter and body",
+			content:  "---\nname: demo\ndescription: d\n---\n# Demo\n",
+			raw:      "name: demo\ndescription: d",
+			body:     "# Demo\n",
+			bodyLine: 5,
+		},
+		{
+			name:     "crlf and bom",
+			content:  "\ufeff---\r\nname: demo\r\n---\r\nbody",
+			raw:      "name: demo",
+			body:     "body",
+			bodyLine: 4,
+		},
+		{
+			name:     "dots close the block",
+			content:  "---\nname: demo\n...\nbody",
+			raw:      "name: demo",
+			body:     "body",
+			bodyLine: 4,
+		},
+		{
+			name:     "trailing spaces on delimiters",
+			content:  "--- \nname: demo\n---\t\nbody",
+			raw:      "name: demo",
+			body:     "body",
+			bodyLine: 4,
+		},
+		{
+			name:     "empty frontmatter",
+			content:  "---\n---\nbody",
+			raw:      "",
+			body:     "body",
+			bodyLine: 3,
+		},
+		{
+			name:     "no frontmatter",
+			content:  "# Demo\n---\n",
+			body:     "# Demo\n---\n",
+			bodyLine: 1,
+			err:      ErrNoFrontmatter,
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			body:     "",
+			bodyLine: 1,
+			err:      ErrNoFrontmatter,
+		},
+		{
+			name:     "unterminated",
+			content:  "---\nname: demo\nbody",
+			body:     "---\nname: demo\nbody",
+			bodyLine: 1,
+			err:      ErrUnterminatedFrontmatter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, body, bodyLine, err := SplitFrontmatter([]byte(tt.content))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if raw != tt.raw {
+				t.Errorf("raw = %q, want %q", raw, tt.raw)
+			}
+			if body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+			if bodyLine != tt.bodyLine {
+				t.Errorf("bodyLine = %d, want %d", bodyLine, tt.bodyLine)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Metadata
+	}{
+		{
+			name:    "basic fields trimmed",
+			content: "---\nname: ' demo '\ntitle: Demo\ndescription: Formats code\nowner: team\nversion: 1.2.0\nlicense: MIT\n---\nbody",
+			want:    Metadata{Name: "demo", Title: "Demo", Description: "Formats code", Owner: "team", Version: "1.2.0", License: "MIT", Extras: map[string]interface{}{}},
+		},
+		{
+			name:    "tags as flow list",
+			content: "---\nname: demo\ntags: [go, ' lint ', '']\n---\n",
+			want:    Metadata{Name: "demo", Tags: StringList{"go", "lint"}, Extras: map[string]interface{}{}},
+		},
+		{
+			name:    "tags as block list",
+			content: "---\nname: demo\ntags:\n  - go\n  - lint\n---\n",
+			want:    Metadata{Name: "demo", Tags: StringList{"go", "lint"}, Extras: map[string]interface{}{}},
+		},
+		{
+			name:    "tags as comma string",
+			content: "---\nname: demo\ntags: go, lint ,, review\n---\n",
+			want:    Metadata{Name: "demo", Tags: StringList{"go", "lint", "review"}, Extras: map[string]interface{}{}},
+		},
+		{
+			name:    "allowed-tools",
+			content: "---\nname: demo\nallowed-tools: Read, Grep, Bash(git:*)\n---\n",
+			want:    Metadata{Name: "demo", AllowedTools: StringList{"Read", "Grep", "Bash(git:*)"}, Extras: map[string]interface{}{}},
+		},
+		{
+			name:    "allowed-tools as list",
+			content: "---\nname: demo\nallowed-tools:\n  - Read\n  - Edit\n---\n",
+			want:    Metadata{Name: "demo", AllowedTools: StringList{"Read", "Edit"}, Extras: map[string]interface{}{}},
+		},
+		{
+			name:    "unknown keys kept in extras, nested maps included",
+			content: "---\nname: demo\ncursor:\n  globs: ['*.go']\n  alwaysApply: true\nmetadata:\n  owner:\n    team: infra\npriority: 3\n---\n",
+			want: Metadata{Name: "demo", Extras: map[string]interface{}{
+				"cursor":   map[string]interface{}{"globs": []interface{}{"*.go"}, "alwaysApply": true},
+				"metadata": map[string]interface{}{"owner": map[string]interface{}{"team": "infra"}},
+				"priority": 3,
+			}},
+		},
+		{
+			name:    "folded block scalar",
+			content: "---\nname: demo\ndescription: >\n  Formats Go code\n  and explains changes.\n---\n",
+			want:    Metadata{Name: "demo", Description: "Formats Go code and explains changes.", Extras: map[string]interface{}{}},
+		},
+		{
+			name:    "literal block scalar",
+			content: "---\nname: demo\ndescription: |\n  line one\n  line two\n---\n",
+			want:    Metadata{Name: "demo", Description: "line one\nline two", Extras: map[string]interface{}{}},
+		},
+		{
+			name:    "empty frontmatter",
+			content: "---\n---\nbody",
+			want:    Metadata{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(doc.Metadata, tt.want) {
+				t.Errorf("metadata = %#v\nwant %#v", doc.Metadata, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		is      error
+	}{
+		{"no frontmatter", "# Demo\n", ErrNoFrontmatter},
+		{"unterminated", "---\nname: demo\n", ErrUnterminatedFrontmatter},
+		{"invalid yaml", "---\nname: demo\ndescription: [unclosed\n---\nbody", nil},
+		{"tags map", "---\nname: demo\ntags:\n  a: b\n---\nbody", nil},
+		{"nested list in tags", "---\nname: demo\ntags: [[a]]\n---\nbody", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.content))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if tt.is != nil && !errors.Is(err, tt.is) {
+				t.Errorf("err = %v, want %v", err, tt.is)
+			}
+			// 出错时不返回部分解析的元数据
+			if !reflect.DeepEqual(doc.Metadata, Metadata{}) {
+				t.Errorf("metadata = %#v, want empty", doc.Metadata)
+			}
+			if doc.Body == "" {
+				t.Error("body dropped on error")
+			}
+		})
+	}
+}
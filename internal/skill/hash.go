@@ -0,0 +1,121 @@
+package skill
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TreeHash is a content hash of a skill directory
+type TreeHash struct {
+	// Hash is the md5 over the sorted "path:md5" lines of all files
+	Hash string `json:"hash"`
+	// Files maps slash separated relative paths to their md5
+	Files map[string]string `json:"files"`
+}
+
+// hashIgnoredNames are never part of a tree hash: SkillUI's own metadata,
+// VCS data and OS clutter that does not belong to the skill content.
+var hashIgnoredNames = map[string]bool{
+	"skillui.json": true,
+	".git":         true,
+	".DS_Store":    true,
+	"Thumbs.db":    true,
+}
+
+// HashFile returns the hex md5 of a file
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashTree computes the content hash of every regular file below dir
+func HashTree(dir string) (TreeHash, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && hashIgnoredNames[d.Name()] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		sum, err := HashFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return TreeHash{}, err
+	}
+	return TreeHash{Hash: hashFileMap(files), Files: files}, nil
+}
+
+// hashFileMap folds a path -> md5 map into a single stable hash
+func hashFileMap(files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	h := md5.New()
+	for _, p := range paths {
+		io.WriteString(h, p+":"+files[p]+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// TreeDiff lists the differences between a recorded and a current file map
+type TreeDiff struct {
+	Modified []string `json:"modified"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+}
+
+// Empty reports whether both trees have identical content
+func (d TreeDiff) Empty() bool {
+	return len(d.Modified) == 0 && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DiffTree compares a recorded file map against the current one
+func DiffTree(recorded, current map[string]string) TreeDiff {
+	diff := TreeDiff{Modified: []string{}, Added: []string{}, Removed: []string{}}
+	for p, sum := range current {
+		old, ok := recorded[p]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, p)
+		case !strings.EqualFold(old, sum):
+			diff.Modified = append(diff.Modified, p)
+		}
+	}
+	for p := range recorded {
+		if _, ok := current[p]; !ok {
+			diff.Removed = append(diff.Removed, p)
+		}
+	}
+	sort.Strings(diff.Modified)
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
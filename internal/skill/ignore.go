@@ -0,0 +1,147 @@
+package skill
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile is the name of the per-skill ignore file. It lists files that
+// stay in the skill directory but are not placed into tool directories
+// (tests, build scripts, drafts, ...).
+const IgnoreFile = ".skilluiignore"
+
+// alwaysIgnored are never synced to tools
+var alwaysIgnored = []string{".git", "skillui.json", IgnoreFile, ".DS_Store", "Thumbs.db"}
+
+// Ignore holds the patterns of a .skilluiignore file.
+//
+// The syntax is a subset of .gitignore: one glob per line, # comments,
+// a trailing / matches directories only, a pattern containing / is matched
+// against the path relative to the skill directory, otherwise against the
+// base name at any depth, and a leading ! re-includes a previously ignored path.
+type Ignore struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	glob     string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// LoadIgnore reads the ignore file of a skill directory; a missing file yields
+// an Ignore that only skips the always-ignored entries
+func LoadIgnore(dir string) (*Ignore, error) {
+	ig := &Ignore{}
+	f, err := os.Open(filepath.Join(dir, IgnoreFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ig, nil
+		}
+		return ig, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := ignorePattern{}
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			p.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		p.glob = line
+		ig.patterns = append(ig.patterns, p)
+	}
+	return ig, scanner.Err()
+}
+
+// Empty reports whether the ignore file defines no patterns
+func (ig *Ignore) Empty() bool {
+	return ig == nil || len(ig.patterns) == 0
+}
+
+// Match reports whether a slash separated path relative to the skill
+// directory is ignored. Paths below an ignored directory are ignored too.
+func (ig *Ignore) Match(rel string, isDir bool) bool {
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		// 逐级检查父目录，父目录被忽略时其下所有文件都被忽略
+		prefixIsDir := isDir || i < len(parts)-1
+		if ig.matchOne(strings.Join(parts[:i+1], "/"), prefixIsDir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ig *Ignore) matchOne(rel string, isDir bool) bool {
+	base := path.Base(rel)
+	for _, name := range alwaysIgnored {
+		if base == name {
+			return true
+		}
+	}
+	if ig == nil {
+		return false
+	}
+	ignored := false
+	for _, p := range ig.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		target := base
+		if p.anchored {
+			target = rel
+		}
+		if ok, _ := path.Match(p.glob, target); ok {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// Files lists the non-ignored regular files of a skill directory as slash
+// separated relative paths, in walk order
+func (ig *Ignore) Files(dir string) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		if ig.matchOne(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
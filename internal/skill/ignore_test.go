@@ -0,0 +1,116 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadTestIgnore(t *testing.T, content string) *Ignore {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFile), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ig, err := LoadIgnore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ig
+}
+
+func TestIgnoreMatch(t *testing.T) {
+	const patterns = `# build output
+*.log
+tests/
+/docs/draft.md
+scripts/*.sh
+!keep.log
+build
+`
+	ig := loadTestIgnore(t, patterns)
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"SKILL.md", false, false},
+		{"debug.log", false, true},
+		{"logs/debug.log", false, true},
+		{"keep.log", false, false},
+		{"tests", true, true},
+		{"tests/case.md", false, true},
+		{"a/tests/case.md", false, true},
+		// 只匹配目录的模式不匹配同名文件
+		{"tests", false, false},
+		{"docs/draft.md", false, true},
+		{"other/docs/draft.md", false, false},
+		{"scripts/run.sh", false, true},
+		{"scripts/lib/run.sh", false, false},
+		{"build", true, true},
+		{"build/out.js", false, true},
+		// 始终忽略的文件
+		{"skillui.json", false, true},
+		{".git/config", false, true},
+		{IgnoreFile, false, true},
+		{"sub/.DS_Store", false, true},
+	}
+	for _, tt := range tests {
+		if got := ig.Match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreNegateUnderIgnoredDir(t *testing.T) {
+	// 与 .gitignore 一致：父目录被忽略时，无法重新包含其中的文件
+	ig := loadTestIgnore(t, "drafts/\n!drafts/keep.md\n")
+	if !ig.Match("drafts/keep.md", false) {
+		t.Error("file below an ignored directory was re-included")
+	}
+}
+
+func TestIgnoreMissingFile(t *testing.T) {
+	ig, err := LoadIgnore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ig.Empty() {
+		t.Error("ignore without file has patterns")
+	}
+	if ig.Match("notes.md", false) {
+		t.Error("notes.md ignored without patterns")
+	}
+	if !ig.Match("skillui.json", false) {
+		t.Error("skillui.json not ignored without patterns")
+	}
+}
+
+func TestIgnoreFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{"SKILL.md", "skillui.json", "ref/guide.md", "tests/case.md", "out.log"} {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(rel), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("tests/\n*.log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ig, err := LoadIgnore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := ig.Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"SKILL.md", "ref/guide.md"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Files = %v, want %v", files, want)
+	}
+}
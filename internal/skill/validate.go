@@ -0,0 +1,270 @@
+package skill
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Severity is the level of a validation diagnostic
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Diagnostic codes, stable identifiers the frontend can translate
+const (
+	CodeMissingSkillFile      = "missing_skill_md"
+	CodeMissingFrontmatter    = "missing_frontmatter"
+	CodeInvalidFrontmatter    = "invalid_frontmatter"
+	CodeMissingName           = "missing_name"
+	CodeInvalidName           = "invalid_name"
+	CodeNameMismatch          = "name_mismatch"
+	CodeMissingDescription    = "missing_description"
+	CodeDescriptionTooLong    = "description_too_long"
+	CodeDescriptionTooShort   = "description_too_short"
+	CodeMissingReference      = "missing_reference"
+	CodeReferenceOutside      = "reference_outside_skill"
+	CodeAbsolutePath          = "absolute_path"
+	CodeSkillFileTooLarge     = "skill_md_too_large"
+	CodeSkillFileTooManyLines = "skill_md_too_many_lines"
+	CodeFileTooLarge          = "file_too_large"
+	CodeSkillTooLarge         = "skill_too_large"
+)
+
+// Diagnostic is a single validation finding for a skill
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	// File is relative to the skill directory, empty for skill level findings
+	File string `json:"file,omitempty"`
+	// Line is 1-based, 0 when not applicable
+	Line int `json:"line,omitempty"`
+}
+
+// Limits configures the size related checks of Validate
+type Limits struct {
+	MaxDescriptionLen int
+	MinDescriptionLen int
+	MaxSkillFileBytes int64
+	MaxSkillFileLines int
+	MaxFileBytes      int64
+	MaxTotalBytes     int64
+}
+
+// DefaultLimits follows the published SKILL.md guidelines
+var DefaultLimits = Limits{
+	MaxDescriptionLen: 1024,
+	MinDescriptionLen: 20,
+	MaxSkillFileBytes: 100 * 1024,
+	MaxSkillFileLines: 500,
+	MaxFileBytes:      5 * 1024 * 1024,
+	MaxTotalBytes:     20 * 1024 * 1024,
+}
+
+var (
+	// skillNamePattern: lowercase letters, digits and hyphens, at most 64 chars
+	skillNamePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	// markdownLinkPattern matches [text](target) and ![alt](target)
+	markdownLinkPattern = regexp.MustCompile(`!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	// windowsAbsPattern matches C:\ or C:/ style paths
+	windowsAbsPattern = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+)
+
+// Severities: errors are structural problems that stop a skill from being
+// synced (SKILL.md unreadable, frontmatter not parseable, references that
+// are absolute or leave the skill directory); missing or incomplete metadata
+// only warns, so plain Markdown skills of older versions keep syncing.
+
+// HasErrors reports whether any diagnostic has error severity
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a skill directory and returns all findings.
+// A nil/empty result means the skill is clean.
+func Validate(dir string, limits Limits) []Diagnostic {
+	diags := make([]Diagnostic, 0)
+	add := func(sev Severity, code, file string, line int, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{
+			Severity: sev,
+			Code:     code,
+			Message:  fmt.Sprintf(format, args...),
+			File:     file,
+			Line:     line,
+		})
+	}
+
+	skillFile := filepath.Join(dir, "SKILL.md")
+	info, err := os.Stat(skillFile)
+	if err != nil || info.IsDir() {
+		add(SeverityError, CodeMissingSkillFile, "SKILL.md", 0, "缺少 SKILL.md 文件")
+		return diags
+	}
+	data, err := os.ReadFile(skillFile)
+	if err != nil {
+		add(SeverityError, CodeMissingSkillFile, "SKILL.md", 0, "无法读取 SKILL.md: %v", err)
+		return diags
+	}
+
+	if limits.MaxSkillFileBytes > 0 && info.Size() > limits.MaxSkillFileBytes {
+		add(SeverityWarning, CodeSkillFileTooLarge, "SKILL.md", 0,
+			"SKILL.md 大小 %s 超过建议上限 %s", formatBytes(info.Size()), formatBytes(limits.MaxSkillFileBytes))
+	}
+	if lines := strings.Count(string(data), "\n") + 1; limits.MaxSkillFileLines > 0 && lines > limits.MaxSkillFileLines {
+		add(SeverityWarning, CodeSkillFileTooManyLines, "SKILL.md", 0,
+			"SKILL.md 共 %d 行，超过建议上限 %d 行，建议拆分到引用文件", lines, limits.MaxSkillFileLines)
+	}
+
+	doc, err := Parse(data)
+	switch {
+	case errors.Is(err, ErrNoFrontmatter):
+		add(SeverityWarning, CodeMissingFrontmatter, "SKILL.md", 1, "SKILL.md 缺少 frontmatter（以 --- 包裹的 name/description）")
+	case err != nil:
+		add(SeverityError, CodeInvalidFrontmatter, "SKILL.md", 1, "frontmatter 解析失败: %v", err)
+	default:
+		validateMetadata(doc.Metadata, filepath.Base(dir), limits, add)
+	}
+
+	validateReferences(dir, doc, add)
+	validateSizes(dir, limits, add)
+	return diags
+}
+
+func validateMetadata(meta Metadata, dirName string, limits Limits, add func(Severity, string, string, int, string, ...interface{})) {
+	switch {
+	case meta.Name == "":
+		add(SeverityWarning, CodeMissingName, "SKILL.md", 0, "frontmatter 缺少 name 字段")
+	default:
+		if len(meta.Name) > 64 || !skillNamePattern.MatchString(meta.Name) {
+			add(SeverityWarning, CodeInvalidName, "SKILL.md", 0,
+				"name \"%s\" 应只包含小写字母、数字和连字符，且不超过 64 个字符", meta.Name)
+		}
+		if meta.Name != dirName {
+			add(SeverityWarning, CodeNameMismatch, "SKILL.md", 0,
+				"name \"%s\" 与目录名 \"%s\" 不一致", meta.Name, dirName)
+		}
+	}
+
+	descLen := len([]rune(meta.Description))
+	switch {
+	case descLen == 0:
+		add(SeverityWarning, CodeMissingDescription, "SKILL.md", 0, "frontmatter 缺少 description 字段")
+	case limits.MaxDescriptionLen > 0 && descLen > limits.MaxDescriptionLen:
+		add(SeverityWarning, CodeDescriptionTooLong, "SKILL.md", 0,
+			"description 长度 %d 超过上限 %d", descLen, limits.MaxDescriptionLen)
+	case limits.MinDescriptionLen > 0 && descLen < limits.MinDescriptionLen:
+		add(SeverityInfo, CodeDescriptionTooShort, "SKILL.md", 0,
+			"description 过短（%d 字符），建议说明技能的用途和触发场景", descLen)
+	}
+}
+
+// validateReferences checks markdown links in the SKILL.md body: relative
+// targets must exist inside the skill directory and absolute paths are rejected.
+func validateReferences(dir string, doc Document, add func(Severity, string, string, int, string, ...interface{})) {
+	root := filepath.Clean(dir)
+	inFence := false
+	for i, line := range strings.Split(doc.Body, "\n") {
+		lineNo := doc.BodyLine + i
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, m := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
+			target := m[1]
+			if target == "" || strings.HasPrefix(target, "#") {
+				continue
+			}
+			if u, err := url.Parse(target); err == nil && u.Scheme != "" && !windowsAbsPattern.MatchString(target) {
+				continue // http(s)://, mailto:, etc.
+			}
+			if idx := strings.IndexAny(target, "#?"); idx >= 0 {
+				target = target[:idx]
+			}
+			if unescaped, err := url.PathUnescape(target); err == nil {
+				target = unescaped
+			}
+			if isAbsoluteRef(target) {
+				add(SeverityError, CodeAbsolutePath, "SKILL.md", lineNo,
+					"引用了绝对路径 %s，技能同步到其他机器后将失效，请改用相对路径", target)
+				continue
+			}
+			resolved := filepath.Clean(filepath.Join(root, filepath.FromSlash(target)))
+			if resolved != root && !strings.HasPrefix(resolved, root+string(os.PathSeparator)) {
+				add(SeverityError, CodeReferenceOutside, "SKILL.md", lineNo,
+					"引用 %s 指向技能目录之外", target)
+				continue
+			}
+			if _, err := os.Stat(resolved); err != nil {
+				add(SeverityWarning, CodeMissingReference, "SKILL.md", lineNo,
+					"引用的文件 %s 不存在", target)
+			}
+		}
+	}
+}
+
+// validateSizes walks the skill directory and checks per-file and total size limits
+func validateSizes(dir string, limits Limits, add func(Severity, string, string, int, string, ...interface{})) {
+	var total int64
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		total += info.Size()
+		rel, _ := filepath.Rel(dir, path)
+		if limits.MaxFileBytes > 0 && info.Size() > limits.MaxFileBytes {
+			add(SeverityWarning, CodeFileTooLarge, filepath.ToSlash(rel), 0,
+				"文件大小 %s 超过上限 %s", formatBytes(info.Size()), formatBytes(limits.MaxFileBytes))
+		}
+		return nil
+	})
+	if limits.MaxTotalBytes > 0 && total > limits.MaxTotalBytes {
+		add(SeverityWarning, CodeSkillTooLarge, "", 0,
+			"技能目录总大小 %s 超过上限 %s", formatBytes(total), formatBytes(limits.MaxTotalBytes))
+	}
+}
+
+func isAbsoluteRef(target string) bool {
+	return strings.HasPrefix(target, "/") ||
+		strings.HasPrefix(target, "\\") ||
+		strings.HasPrefix(target, "~") ||
+		windowsAbsPattern.MatchString(target)
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(n)/1024/1024)
+	case n >= 1024:
+		return fmt.Sprintf("%.1fKB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
@@ -0,0 +1,197 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const validSkill = "---\nname: demo\ndescription: Formats Go code and explains every change it makes\n---\n# Demo\n"
+
+func writeSkillDir(t *testing.T, name string, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for rel, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestValidate(t *testing.T) {
+	withBody := func(body string) string { return validSkill + body }
+	tests := []struct {
+		name   string
+		dir    string
+		files  map[string]string
+		limits Limits
+		// want lists "severity:code" of every expected diagnostic
+		want []string
+	}{
+		{
+			name:  "clean",
+			files: map[string]string{"SKILL.md": validSkill},
+		},
+		{
+			name:  "missing SKILL.md",
+			files: map[string]string{"README.md": "# Demo"},
+			want:  []string{"error:" + CodeMissingSkillFile},
+		},
+		{
+			name:  "SKILL.md is a directory",
+			files: map[string]string{"SKILL.md/x": ""},
+			want:  []string{"error:" + CodeMissingSkillFile},
+		},
+		{
+			name:  "no frontmatter only warns",
+			files: map[string]string{"SKILL.md": "# Demo\n"},
+			want:  []string{"warning:" + CodeMissingFrontmatter},
+		},
+		{
+			name:  "unparseable frontmatter",
+			files: map[string]string{"SKILL.md": "---\nname: [demo\n---\n"},
+			want:  []string{"error:" + CodeInvalidFrontmatter},
+		},
+		{
+			name:  "unterminated frontmatter",
+			files: map[string]string{"SKILL.md": "---\nname: demo\n"},
+			want:  []string{"error:" + CodeInvalidFrontmatter},
+		},
+		{
+			name:  "missing name only warns",
+			files: map[string]string{"SKILL.md": "---\ndescription: Formats Go code and explains every change\n---\n"},
+			want:  []string{"warning:" + CodeMissingName},
+		},
+		{
+			name:  "invalid name",
+			dir:   "Demo_Skill",
+			files: map[string]string{"SKILL.md": "---\nname: Demo_Skill\ndescription: Formats Go code and explains every change\n---\n"},
+			want:  []string{"warning:" + CodeInvalidName},
+		},
+		{
+			name:  "name differs from directory",
+			dir:   "other",
+			files: map[string]string{"SKILL.md": validSkill},
+			want:  []string{"warning:" + CodeNameMismatch},
+		},
+		{
+			name:  "missing description only warns",
+			files: map[string]string{"SKILL.md": "---\nname: demo\n---\n"},
+			want:  []string{"warning:" + CodeMissingDescription},
+		},
+		{
+			name:   "description too long",
+			files:  map[string]string{"SKILL.md": "---\nname: demo\ndescription: " + strings.Repeat("x", 30) + "\n---\n"},
+			limits: Limits{MaxDescriptionLen: 20},
+			want:   []string{"warning:" + CodeDescriptionTooLong},
+		},
+		{
+			name:   "description too short",
+			files:  map[string]string{"SKILL.md": "---\nname: demo\ndescription: Short\n---\n"},
+			limits: Limits{MinDescriptionLen: 20},
+			want:   []string{"info:" + CodeDescriptionTooShort},
+		},
+		{
+			name: "existing references, urls, anchors and code blocks",
+			files: map[string]string{
+				"SKILL.md":         withBody("[guide](ref/guide.md) ![img](<ref/a b.png>) [site](https://example.com) [top](#demo)\n```\n[x](/etc/passwd)\n```\n"),
+				"ref/guide.md":     "guide",
+				"ref/a b.png":      "png",
+				"scripts/setup.sh": "echo",
+			},
+		},
+		{
+			name:  "missing reference",
+			files: map[string]string{"SKILL.md": withBody("See [guide](ref/missing.md#part).\n")},
+			want:  []string{"warning:" + CodeMissingReference},
+		},
+		{
+			name:  "reference outside the skill",
+			files: map[string]string{"SKILL.md": withBody("See [other](../other/SKILL.md).\n")},
+			want:  []string{"error:" + CodeReferenceOutside},
+		},
+		{
+			name:  "absolute references",
+			files: map[string]string{"SKILL.md": withBody("[a](/etc/hosts) [b](~/notes.md) [c](C:/tools/x.md)\n")},
+			want:  []string{"error:" + CodeAbsolutePath, "error:" + CodeAbsolutePath, "error:" + CodeAbsolutePath},
+		},
+		{
+			name:   "SKILL.md too large",
+			files:  map[string]string{"SKILL.md": withBody(strings.Repeat("x", 200))},
+			limits: Limits{MaxSkillFileBytes: 100},
+			want:   []string{"warning:" + CodeSkillFileTooLarge},
+		},
+		{
+			name:   "SKILL.md too many lines",
+			files:  map[string]string{"SKILL.md": withBody(strings.Repeat("line\n", 20))},
+			limits: Limits{MaxSkillFileLines: 10},
+			want:   []string{"warning:" + CodeSkillFileTooManyLines},
+		},
+		{
+			name:   "file too large",
+			files:  map[string]string{"SKILL.md": validSkill, "data.bin": strings.Repeat("x", 200)},
+			limits: Limits{MaxFileBytes: 150},
+			want:   []string{"warning:" + CodeFileTooLarge},
+		},
+		{
+			name:   "skill too large, .git not counted",
+			files:  map[string]string{"SKILL.md": validSkill, "a.txt": strings.Repeat("x", 100), ".git/objects/pack": strings.Repeat("x", 1000)},
+			limits: Limits{MaxTotalBytes: 150},
+			want:   []string{"warning:" + CodeSkillTooLarge},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirName := tt.dir
+			if dirName == "" {
+				dirName = "demo"
+			}
+			diags := Validate(writeSkillDir(t, dirName, tt.files), tt.limits)
+			got := make([]string, 0, len(diags))
+			for _, d := range diags {
+				got = append(got, string(d.Severity)+":"+d.Code)
+			}
+			want := append([]string{}, tt.want...)
+			sort.Strings(got)
+			sort.Strings(want)
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("diagnostics = %v, want %v", diags, want)
+			}
+		})
+	}
+}
+
+func TestValidateReferenceLines(t *testing.T) {
+	dir := writeSkillDir(t, "demo", map[string]string{"SKILL.md": validSkill + "\n[a](missing.md)\n"})
+	diags := Validate(dir, Limits{})
+	if len(diags) != 1 || diags[0].Line != 7 || diags[0].File != "SKILL.md" {
+		t.Fatalf("diagnostics = %+v, want one on SKILL.md line 7", diags)
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		diags []Diagnostic
+		want  bool
+	}{
+		{"none", nil, false},
+		{"warnings and info", []Diagnostic{{Severity: SeverityWarning}, {Severity: SeverityInfo}}, false},
+		{"one error", []Diagnostic{{Severity: SeverityWarning}, {Severity: SeverityError}}, true},
+	}
+	for _, tt := range tests {
+		if got := HasErrors(tt.diags); got != tt.want {
+			t.Errorf("%s: HasErrors = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
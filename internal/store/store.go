@@ -1,17 +1,23 @@
 package store
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"sync"
 
 	"skillui/internal/config"
+	"skillui/internal/filelock"
 )
 
 type Store struct {
 	mu   sync.Mutex
 	path string
+	// base is the config as this process last loaded or saved it. Save only
+	// writes the top-level fields that changed since, on top of what is on
+	// disk, so the window and the command line do not drop each other's changes.
+	base map[string]json.RawMessage
 }
 
 func NewStore(baseDir string) *Store {
@@ -27,16 +33,18 @@ func (s *Store) Load() (config.AppConfig, error) {
 	data, err := os.ReadFile(s.path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return config.DefaultConfig(), nil
+			cfg := config.DefaultConfig()
+			s.base, _ = fields(cfg)
+			return cfg, nil
 		}
 		return config.AppConfig{}, err
 	}
 
 	var cfg config.AppConfig
 	if err := json.Unmarshal(data, &cfg); err != nil {
-		return config.DefaultConfig(), nil
+		cfg = config.DefaultConfig()
 	}
-
+	s.base, _ = fields(cfg)
 	return cfg, nil
 }
 
@@ -47,11 +55,61 @@ func (s *Store) Save(cfg config.AppConfig) error {
 	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
 		return err
 	}
+	lock, err := filelock.Exclusive(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	ours, err := fields(cfg)
+	if err != nil {
+		return err
+	}
+	// 重新读取磁盘上的配置，只覆盖本进程修改过的字段
+	merged := ours
+	if data, err := os.ReadFile(s.path); err == nil && s.base != nil {
+		var disk map[string]json.RawMessage
+		if json.Unmarshal(data, &disk) == nil {
+			merged = disk
+			for key, value := range ours {
+				if _, onDisk := disk[key]; !onDisk || !bytes.Equal(value, s.base[key]) {
+					merged[key] = value
+				}
+			}
+		}
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	// 经结构体重新编码，字段保持定义顺序
+	var out config.AppConfig
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.path, data, 0o644)
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.base = ours
+	return nil
+}
+
+// fields splits a config into its top-level JSON fields
+func fields(cfg config.AppConfig) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	err = json.Unmarshal(data, &m)
+	return m, err
 }
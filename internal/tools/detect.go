@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Capabilities a tool can declare in the registry ("capabilities": {name: minVersion})
+const (
+	// CapFolderSkills means the tool loads skill folders (<name>/SKILL.md with resources)
+	CapFolderSkills = "folderSkills"
+	// CapFrontmatter means the tool reads rule frontmatter (description, globs, ...)
+	CapFrontmatter = "frontmatter"
+)
+
+// versionTimeout bounds running a tool binary to ask for its version
+const versionTimeout = 3 * time.Second
+
+var (
+	versionPattern = regexp.MustCompile(`\d+(?:\.\d+)+(?:[-+][0-9A-Za-z.-]+)?`)
+	plistVersion   = regexp.MustCompile(`<key>CFBundleShortVersionString</key>\s*<string>([^<]+)</string>`)
+)
+
+// VersionFilesFor returns the expanded product metadata files for an OS (empty = current)
+func (d Def) VersionFilesFor(goos string) []string {
+	return d.expandList(d.VersionFiles, goos)
+}
+
+// ReadDirsFor returns the expanded extra directories the tool reads rules or
+// skills from for an OS (empty = current)
+func (d Def) ReadDirsFor(goos string) []string {
+	return d.expandList(d.ReadDirs, goos)
+}
+
+// LegacyRulesDirsFor returns the expanded rules dirs older versions synced
+// skills to for an OS (empty = current)
+func (d Def) LegacyRulesDirsFor(goos string) []string {
+	return d.expandList(d.LegacyRulesDirs, goos)
+}
+
+func (d Def) expandList(m map[string][]string, goos string) []string {
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	paths := make([]string, 0, len(m[goos]))
+	for _, tmpl := range m[goos] {
+		if p := Expand(tmpl); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// Supports reports whether the tool declares a capability and the installed
+// version (when known) is at least the declared minimum
+func (d Def) Supports(capability, version string) bool {
+	min, ok := d.Capabilities[capability]
+	if !ok {
+		return false
+	}
+	return min == "" || version == "" || CompareVersions(version, min) >= 0
+}
+
+// BinaryVersion runs a tool binary with args (default --version) and returns
+// the version it prints, or "" when it fails or prints none
+func BinaryVersion(path string, args []string) string {
+	if len(args) == 0 {
+		args = []string{"--version"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = nil
+	hideConsole(cmd)
+	out, err := cmd.CombinedOutput()
+	if err != nil && len(out) == 0 {
+		return ""
+	}
+	return ParseVersion(string(out))
+}
+
+// MetadataVersion reads the version from a product metadata file: a macOS
+// Info.plist (CFBundleShortVersionString), a package.json / product.json
+// ("version") or a plain text file
+func MetadataVersion(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".plist":
+		if m := plistVersion.FindSubmatch(data); m != nil {
+			return strings.TrimSpace(string(m[1]))
+		}
+		return ""
+	case ".json":
+		var meta struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(data, &meta) != nil {
+			return ""
+		}
+		return meta.Version
+	default:
+		return ParseVersion(string(data))
+	}
+}
+
+// ParseVersion returns the first dotted version number in s
+func ParseVersion(s string) string {
+	return versionPattern.FindString(s)
+}
+
+// CompareVersions compares the numeric parts of two dotted versions
+// (pre-release suffixes are ignored) and returns -1, 0 or 1
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if idx := strings.IndexAny(v, "-+ "); idx >= 0 {
+		v = v[:idx]
+	}
+	parts := make([]int, 0, 3)
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package tools
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// hideConsole keeps version probes from flashing a console window
+func hideConsole(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: 0x08000000, // CREATE_NO_WINDOW
+		HideWindow:    true,
+	}
+}
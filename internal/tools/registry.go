@@ -0,0 +1,278 @@
+// Package tools holds the registry of AI tools SkillUI can sync skills to.
+//
+// Definitions come from the bundled tools.json and can be overridden or
+// extended by further files in the same format (the copy refreshed from the
+// marketplace, the user's own file in the data dir). A later file replaces the
+// fields it sets on a tool with the same id and appends tools with new ids;
+// "disabled": true hides a tool.
+package tools
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+//go:embed tools.json
+var bundled []byte
+
+// Def is the definition of one tool. Paths are templates that may use
+// ${HOME}, ${APPDATA}, ${LOCALAPPDATA}, ${USERPROFILE}, ${XDG_CONFIG_HOME}
+// and any other environment variable; / works as separator on every OS.
+type Def struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Format 为同步时使用的输出格式（见 internal/adapter），为空表示原样同步 SKILL.md
+	Format string `json:"format,omitempty"`
+	// ProjectRulesDir 为项目级规则目录（相对项目根目录，/ 分隔），为空表示不支持项目级同步
+	ProjectRulesDir string `json:"projectRulesDir,omitempty"`
+	// FileName 为文件名模式，{name} 替换为技能名，为空使用输出格式的默认文件名
+	FileName string `json:"fileName,omitempty"`
+	// Binaries are command names looked up in PATH to detect CLI tools
+	Binaries []string `json:"binaries,omitempty"`
+	// VersionArgs are passed to the binary to print its version (default --version)
+	VersionArgs []string `json:"versionArgs,omitempty"`
+	// CheckPaths, RulesDir, VersionFiles and ReadDirs are keyed by GOOS (darwin / windows / linux)
+	CheckPaths map[string][]string `json:"checkPaths,omitempty"`
+	RulesDir   map[string]string   `json:"rulesDir,omitempty"`
+	// VersionFiles are product metadata files (Info.plist, package.json) holding the app version
+	VersionFiles map[string][]string `json:"versionFiles,omitempty"`
+	// ReadDirs 为工具除规则目录外还会读取规则 / 技能的目录
+	ReadDirs map[string][]string `json:"readDirs,omitempty"`
+	// LegacyRulesDirs 为旧版本同步过的规则目录（{name}.md），仅用于检测和清理
+	LegacyRulesDirs map[string][]string `json:"legacyRulesDirs,omitempty"`
+	// Capabilities maps a capability (see CapFolderSkills) to the minimum
+	// version supporting it ("" = every version)
+	Capabilities map[string]string `json:"capabilities,omitempty"`
+	// MCPFormat 为工具 MCP 配置文件的格式（见 internal/mcpconfig），为空表示不支持写入 MCP 服务
+	MCPFormat string `json:"mcpFormat,omitempty"`
+	// MCPConfig is the tool's MCP config file, keyed by GOOS
+	MCPConfig map[string]string `json:"mcpConfig,omitempty"`
+	Disabled  bool              `json:"disabled,omitempty"`
+}
+
+// File is the format of tools.json and its override files
+type File struct {
+	Version int               `json:"version"`
+	Tools   []json.RawMessage `json:"tools"`
+}
+
+// Bundled returns the definitions shipped with the application
+func Bundled() ([]Def, error) {
+	return Merge(nil, bundled)
+}
+
+// BundledVersion returns the version of the bundled tools.json
+func BundledVersion() int {
+	v, _ := FileVersion(bundled)
+	return v
+}
+
+// FileVersion returns the version field of a registry file
+func FileVersion(data []byte) (int, error) {
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return 0, err
+	}
+	return f.Version, nil
+}
+
+// Load returns the bundled definitions overlaid by each existing file in
+// order, without disabled tools. Missing files are skipped; a broken file is
+// skipped too and reported in the returned error.
+func Load(files ...string) ([]Def, error) {
+	defs, err := Bundled()
+	if err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+			}
+			continue
+		}
+		merged, err := Merge(defs, data)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+			continue
+		}
+		defs = merged
+	}
+	enabled := make([]Def, 0, len(defs))
+	for _, d := range defs {
+		if !d.Disabled {
+			enabled = append(enabled, d)
+		}
+	}
+	if len(errs) > 0 {
+		return enabled, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return enabled, nil
+}
+
+// Merge overlays the tools of a registry file on base and returns the result;
+// base is not modified
+func Merge(base []Def, data []byte) ([]Def, error) {
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	defs := make([]Def, len(base))
+	for i, d := range base {
+		defs[i] = d.clone()
+	}
+	for i, raw := range f.Tools {
+		var head struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &head); err != nil {
+			return nil, fmt.Errorf("tools[%d]: %w", i, err)
+		}
+		if head.ID == "" {
+			return nil, fmt.Errorf("tools[%d]: missing id", i)
+		}
+		idx := -1
+		for j := range defs {
+			if defs[j].ID == head.ID {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			defs = append(defs, Def{})
+			idx = len(defs) - 1
+		}
+		// 只覆盖文件中出现的字段（checkPaths / rulesDir 按系统合并）
+		if err := json.Unmarshal(raw, &defs[idx]); err != nil {
+			return nil, fmt.Errorf("tools[%d] (%s): %w", i, head.ID, err)
+		}
+		if defs[idx].Name == "" {
+			defs[idx].Name = defs[idx].ID
+		}
+	}
+	return defs, nil
+}
+
+func (d Def) clone() Def {
+	c := d
+	c.Binaries = append([]string(nil), d.Binaries...)
+	c.VersionArgs = append([]string(nil), d.VersionArgs...)
+	c.CheckPaths = cloneLists(d.CheckPaths)
+	c.VersionFiles = cloneLists(d.VersionFiles)
+	c.ReadDirs = cloneLists(d.ReadDirs)
+	c.LegacyRulesDirs = cloneLists(d.LegacyRulesDirs)
+	c.RulesDir = cloneStrings(d.RulesDir)
+	c.Capabilities = cloneStrings(d.Capabilities)
+	c.MCPConfig = cloneStrings(d.MCPConfig)
+	return c
+}
+
+func cloneLists(m map[string][]string) map[string][]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string][]string, len(m))
+	for k, v := range m {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+func cloneStrings(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// CheckPathsFor returns the expanded check paths for an OS (empty = current),
+// leaving out paths whose variables are not set
+func (d Def) CheckPathsFor(goos string) []string {
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	paths := make([]string, 0, len(d.CheckPaths[goos]))
+	for _, tmpl := range d.CheckPaths[goos] {
+		if p := Expand(tmpl); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// RulesDirFor returns the expanded global rules dir for an OS (empty = current)
+func (d Def) RulesDirFor(goos string) string {
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	return Expand(d.RulesDir[goos])
+}
+
+// MCPConfigFor returns the expanded MCP config file for an OS (empty = current),
+// or "" when the tool has none
+func (d Def) MCPConfigFor(goos string) string {
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if d.MCPFormat == "" {
+		return ""
+	}
+	return Expand(d.MCPConfig[goos])
+}
+
+// Expand resolves the variables of a path template and converts it to the
+// OS separator. It returns "" when a referenced variable is empty, so that
+// e.g. ${APPDATA} paths do not turn into relative paths outside Windows.
+func Expand(tmpl string) string {
+	if tmpl == "" {
+		return ""
+	}
+	missing := false
+	p := os.Expand(tmpl, func(name string) string {
+		v := lookupVar(name)
+		if v == "" {
+			missing = true
+		}
+		return v
+	})
+	if missing {
+		return ""
+	}
+	if strings.HasPrefix(p, "~/") || p == "~" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		p = home + p[1:]
+	}
+	return filepath.Clean(filepath.FromSlash(p))
+}
+
+func lookupVar(name string) string {
+	switch name {
+	case "HOME":
+		home, _ := os.UserHomeDir()
+		return home
+	case "XDG_CONFIG_HOME":
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		if home, err := os.UserHomeDir(); err == nil && home != "" {
+			return filepath.Join(home, ".config")
+		}
+		return ""
+	default:
+		return os.Getenv(name)
+	}
+}
@@ -0,0 +1,194 @@
+// Package watcher watches a directory of skills recursively and reports,
+// after a quiet period, which top-level entries (skills) changed.
+package watcher
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is the quiet period used when New is given zero
+const DefaultDebounce = 500 * time.Millisecond
+
+// Watcher watches root and every directory below it. Top-level entries whose
+// name starts with "." (staging and backup dirs of installs) and .git
+// directories are not watched.
+type Watcher struct {
+	root     string
+	debounce time.Duration
+	onChange func(names []string)
+	fsw      *fsnotify.Watcher
+
+	mu      sync.Mutex
+	pending map[string]bool
+	timer   *time.Timer
+	// flushMu keeps onChange calls from overlapping
+	flushMu sync.Mutex
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New starts watching root (created when missing). onChange is called, never
+// concurrently, with the sorted names of the top-level entries that changed
+// once no further event arrived for the debounce period.
+func New(root string, debounce time.Duration, onChange func(names []string)) (*Watcher, error) {
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		root:     filepath.Clean(root),
+		debounce: debounce,
+		onChange: onChange,
+		fsw:      fsw,
+		pending:  map[string]bool{},
+		done:     make(chan struct{}),
+	}
+	if err := w.addTree(w.root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	go w.loop()
+	return w, nil
+}
+
+// Root returns the watched directory
+func (w *Watcher) Root() string {
+	return w.root
+}
+
+// Close stops watching; pending changes are dropped
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.fsw.Close()
+		w.mu.Lock()
+		if w.timer != nil {
+			w.timer.Stop()
+		}
+		w.mu.Unlock()
+	})
+	return err
+}
+
+// addTree watches dir and all directories below it
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 目录在遍历过程中被删除（如安装暂存目录）时忽略
+			if p == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != w.root && w.skipped(p) {
+			return filepath.SkipDir
+		}
+		return w.fsw.Add(p)
+	})
+}
+
+// skipped reports whether a path lies in an entry that is not watched
+func (w *Watcher) skipped(p string) bool {
+	rel, err := filepath.Rel(w.root, p)
+	if err != nil || rel == "." {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if strings.HasPrefix(parts[0], ".") {
+		return true
+	}
+	for _, part := range parts[1:] {
+		if part == ".git" {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) loop() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handle(ev)
+		case _, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func (w *Watcher) handle(ev fsnotify.Event) {
+	path := filepath.Clean(ev.Name)
+	if path == w.root || w.skipped(path) {
+		return
+	}
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return
+	}
+	name := strings.Split(filepath.ToSlash(rel), "/")[0]
+
+	// 新建（或改名进来）的目录需要单独加入监听，fsnotify 不会递归
+	if ev.Has(fsnotify.Create) {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			w.addTree(path)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending[name] = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.debounce, w.flush)
+}
+
+// flush hands the accumulated names to onChange
+func (w *Watcher) flush() {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.mu.Lock()
+	names := make([]string, 0, len(w.pending))
+	for name := range w.pending {
+		names = append(names, name)
+	}
+	w.pending = map[string]bool{}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return
+	default:
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	w.onChange(names)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"embed"
+	"os"
 	goruntime "runtime"
 
 	"github.com/wailsapp/wails/v2"
@@ -87,6 +88,11 @@ func wrapShutdown(app *App) func(ctx context.Context) {
 }
 
 func main() {
+	// 命令行模式：skillui skill|tool|proc|lock ... 不打开窗口（见 cli.go）
+	if isCLICommand(os.Args[1:]) {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 	globalApp = app